@@ -0,0 +1,129 @@
+// Package analysis implements item analysis of problems.
+package analysis
+
+import (
+	"math"
+)
+
+// Response represents graded answer of one attempt for problem.
+type Response struct {
+	// Correct contains flag that answer is correct.
+	Correct bool
+	// Rest contains score of attempt without score of problem.
+	Rest float64
+	// Options contains original indexes of selected options.
+	Options []int
+	// Duration contains time spent on problem in seconds.
+	//
+	// Zero value means that time is unknown.
+	Duration int64
+}
+
+// OptionStatistics represents statistics of choice option.
+type OptionStatistics struct {
+	// Count contains amount of responses that selected option.
+	Count int
+	// Frequency contains fraction of responses that selected option.
+	Frequency float64
+}
+
+// Statistics represents item statistics of problem.
+type Statistics struct {
+	// Responses contains amount of analyzed responses.
+	Responses int
+	// Difficulty contains proportion of correct responses.
+	Difficulty float64
+	// Discrimination contains point-biserial correlation between
+	// correctness of response and rest score of attempt.
+	//
+	// Discrimination is nil when correlation is not defined, for
+	// example when all responses are correct.
+	Discrimination *float64
+	// Options contains statistics of options for choice problems.
+	Options []OptionStatistics
+	// AverageTime contains average time spent on problem in seconds.
+	//
+	// AverageTime is nil when time of responses is unknown.
+	AverageTime *float64
+}
+
+// Analyze computes item statistics of problem from responses.
+//
+// Statistics of options are computed only if options is positive.
+func Analyze(responses []Response, options int) Statistics {
+	stats := Statistics{Responses: len(responses)}
+	if len(responses) == 0 {
+		return stats
+	}
+	if options > 0 {
+		stats.Options = make([]OptionStatistics, options)
+	}
+	correct := 0
+	var timeSum float64
+	var timeCount int
+	for _, response := range responses {
+		if response.Correct {
+			correct++
+		}
+		for _, option := range response.Options {
+			if option >= 0 && option < len(stats.Options) {
+				stats.Options[option].Count++
+			}
+		}
+		if response.Duration > 0 {
+			timeSum += float64(response.Duration)
+			timeCount++
+		}
+	}
+	for i := range stats.Options {
+		stats.Options[i].Frequency =
+			float64(stats.Options[i].Count) / float64(len(responses))
+	}
+	stats.Difficulty = float64(correct) / float64(len(responses))
+	stats.Discrimination = pointBiserial(responses)
+	if timeCount > 0 {
+		average := timeSum / float64(timeCount)
+		stats.AverageTime = &average
+	}
+	return stats
+}
+
+// pointBiserial computes point-biserial correlation coefficient.
+//
+// Coefficient is computed using formula:
+//
+//	r = (M1 - M0) / S * sqrt(p * q)
+//
+// where M1 and M0 are mean rest scores of correct and wrong responses,
+// S is standard deviation of rest scores and p, q are proportions of
+// correct and wrong responses.
+func pointBiserial(responses []Response) *float64 {
+	var sum, sum1 float64
+	count1 := 0
+	for _, response := range responses {
+		sum += response.Rest
+		if response.Correct {
+			sum1 += response.Rest
+			count1++
+		}
+	}
+	count0 := len(responses) - count1
+	if count1 == 0 || count0 == 0 {
+		return nil
+	}
+	n := float64(len(responses))
+	mean := sum / n
+	var variance float64
+	for _, response := range responses {
+		variance += (response.Rest - mean) * (response.Rest - mean)
+	}
+	deviation := math.Sqrt(variance / n)
+	if deviation < 1e-9 {
+		return nil
+	}
+	mean1 := sum1 / float64(count1)
+	mean0 := (sum - sum1) / float64(count0)
+	p := float64(count1) / n
+	r := (mean1 - mean0) / deviation * math.Sqrt(p*(1-p))
+	return &r
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	if stats := Analyze(nil, 3); stats.Responses != 0 ||
+		stats.Options != nil || stats.Discrimination != nil {
+		t.Fatalf("Unexpected statistics: %+v", stats)
+	}
+	responses := []Response{
+		{Correct: true, Rest: 4, Options: []int{1}, Duration: 10},
+		{Correct: true, Rest: 3, Options: []int{1}, Duration: 20},
+		{Correct: false, Rest: 1, Options: []int{0}, Duration: 30},
+		{Correct: false, Rest: 0, Options: []int{2}},
+	}
+	stats := Analyze(responses, 3)
+	if stats.Responses != 4 {
+		t.Fatalf("Expected %d responses, got %d", 4, stats.Responses)
+	}
+	if stats.Difficulty != 0.5 {
+		t.Fatalf("Expected difficulty %v, got %v", 0.5, stats.Difficulty)
+	}
+	// Mean rest scores are 3.5 and 0.5, deviation is sqrt(2.5).
+	expected := 3 / math.Sqrt(2.5) * 0.5
+	if stats.Discrimination == nil ||
+		math.Abs(*stats.Discrimination-expected) > 1e-9 {
+		t.Fatalf("Expected discrimination %v, got %v", expected, stats.Discrimination)
+	}
+	counts := []int{1, 2, 1}
+	for i, count := range counts {
+		if stats.Options[i].Count != count {
+			t.Fatalf("Expected count %d, got %d", count, stats.Options[i].Count)
+		}
+	}
+	if stats.Options[1].Frequency != 0.5 {
+		t.Fatalf("Expected frequency %v, got %v", 0.5, stats.Options[1].Frequency)
+	}
+	if stats.AverageTime == nil || *stats.AverageTime != 20 {
+		t.Fatalf("Expected average time %v, got %v", 20, stats.AverageTime)
+	}
+	stats = Analyze([]Response{
+		{Correct: true, Rest: 1},
+		{Correct: true, Rest: 2},
+	}, 0)
+	if stats.Discrimination != nil || stats.AverageTime != nil {
+		t.Fatalf("Unexpected statistics: %+v", stats)
+	}
+	if stats.Options != nil {
+		t.Fatalf("Unexpected options: %+v", stats.Options)
+	}
+	stats = Analyze([]Response{
+		{Correct: true, Rest: 1},
+		{Correct: false, Rest: 1},
+	}, 0)
+	if stats.Discrimination != nil {
+		t.Fatalf("Unexpected discrimination: %v", *stats.Discrimination)
+	}
+}
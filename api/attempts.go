@@ -0,0 +1,982 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/grading"
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// QuizAttempt represents attempt of quiz.
+type QuizAttempt struct {
+	// ID contains attempt ID.
+	ID int64 `json:"id"`
+	// QuizID contains quiz ID.
+	QuizID int64 `json:"quiz_id"`
+	// AccountID contains ID of account that started attempt.
+	AccountID int64 `json:"account_id"`
+	// Status contains attempt status.
+	Status models.QuizAttemptStatus `json:"status"`
+	// StartTime contains time when attempt was started.
+	StartTime int64 `json:"start_time"`
+	// Deadline contains time when attempt should be finished.
+	Deadline int64 `json:"deadline,omitempty"`
+	// FinishTime contains time when attempt was finished.
+	FinishTime int64 `json:"finish_time,omitempty"`
+	// Score contains total score of finished attempt.
+	Score *float64 `json:"score,omitempty"`
+}
+
+type quizAttemptSorter []QuizAttempt
+
+func (v quizAttemptSorter) Len() int {
+	return len(v)
+}
+
+func (v quizAttemptSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v quizAttemptSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// QuizAttempts represents quiz attempts response.
+type QuizAttempts struct {
+	Attempts []QuizAttempt `json:"attempts"`
+}
+
+// QuizAttemptAnswer represents answer for problem of quiz attempt.
+type QuizAttemptAnswer struct {
+	// Options contains positions of selected options in attempt order.
+	//
+	// For matching problems i-th element contains position of option
+	// that is matched with i-th prompt or -1 if prompt is not matched.
+	Options []int `json:"options,omitempty"`
+	// Text contains text answer.
+	Text string `json:"text,omitempty"`
+	// Value contains numeric answer.
+	Value *float64 `json:"value,omitempty"`
+}
+
+// QuizAttemptProblem represents problem of quiz attempt.
+type QuizAttemptProblem struct {
+	// ID contains problem ID.
+	ID int64 `json:"id"`
+	// Kind contains problem kind.
+	Kind models.ProblemKind `json:"kind"`
+	// Title contains problem title.
+	Title string `json:"title"`
+	// Statement contains problem statement.
+	Statement string `json:"statement"`
+	// Options contains options of choice problem in attempt order.
+	Options []ProblemOption `json:"options,omitempty"`
+	// Prompts contains prompts of matching problem.
+	Prompts []ProblemOption `json:"prompts,omitempty"`
+	// Points contains maximal amount of points for problem.
+	Points int64 `json:"points"`
+	// Answer contains given answer.
+	Answer *QuizAttemptAnswer `json:"answer,omitempty"`
+	// Verdict contains verdict of answer for finished attempt.
+	Verdict models.Verdict `json:"verdict,omitempty"`
+	// Score contains score of answer for finished attempt.
+	Score *float64 `json:"score,omitempty"`
+	// Comment contains comment of grader for finished attempt.
+	Comment string `json:"comment,omitempty"`
+}
+
+// QuizAttemptProblems represents quiz attempt problems response.
+type QuizAttemptProblems struct {
+	Problems []QuizAttemptProblem `json:"problems"`
+}
+
+// registerQuizAttemptHandlers registers handlers for quiz attempts.
+func (v *View) registerQuizAttemptHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/attempts", v.observeQuizAttempts,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/attempts", v.createQuizAttempt,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.CreateQuizAttemptRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/regrade", v.regradeQuizAttempts,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.UpdateQuizRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/attempts/:attempt", v.observeQuizAttempt,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractQuiz,
+		v.extractQuizAttempt,
+		v.requirePermission(models.ObserveQuizAttemptRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/attempts/:attempt/problems",
+		v.observeQuizAttemptProblems,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractQuiz,
+		v.extractQuizAttempt,
+		v.requirePermission(models.ObserveQuizAttemptRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/attempts/:attempt/problems/:problem/answer",
+		v.updateQuizAttemptAnswer,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.extractQuizAttempt,
+		v.requirePermission(models.UpdateQuizAttemptRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/attempts/:attempt/finish",
+		v.finishQuizAttempt,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.extractQuizAttempt,
+		v.requirePermission(models.UpdateQuizAttemptRole),
+	)
+}
+
+// registerSocketQuizAttemptHandlers registers socket handlers for
+// quiz attempts.
+func (v *View) registerSocketQuizAttemptHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/attempts", v.observeQuizAttempts,
+		v.extractQuiz,
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/regrade", v.regradeQuizAttempts,
+		v.extractQuiz,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/attempts/:attempt", v.observeQuizAttempt,
+		v.extractQuiz, v.extractQuizAttempt,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/attempts/:attempt/problems",
+		v.observeQuizAttemptProblems,
+		v.extractQuiz, v.extractQuizAttempt,
+	)
+}
+
+func makeQuizAttempt(attempt models.QuizAttempt) (QuizAttempt, error) {
+	resp := QuizAttempt{
+		ID:         attempt.ID,
+		QuizID:     attempt.QuizID,
+		AccountID:  attempt.AccountID,
+		Status:     attempt.Status,
+		StartTime:  attempt.StartTime,
+		Deadline:   int64(attempt.Deadline),
+		FinishTime: int64(attempt.FinishTime),
+	}
+	if attempt.Status == models.FinishedAttempt {
+		var state models.QuizAttemptState
+		if err := attempt.ScanState(&state); err != nil {
+			return QuizAttempt{}, err
+		}
+		resp.Score = &state.Score
+	}
+	return resp, nil
+}
+
+func makeQuizAttemptProblem(
+	attempt models.QuizAttempt, attemptProblem models.QuizAttemptProblem,
+	problem models.Problem,
+) (QuizAttemptProblem, error) {
+	var config models.ProblemConfig
+	if err := problem.ScanConfig(&config); err != nil {
+		return QuizAttemptProblem{}, err
+	}
+	// Parameterized problems are shown with values drawn for attempt.
+	values := attemptProblem.Values
+	resp := QuizAttemptProblem{
+		ID:        problem.ID,
+		Kind:      problem.Kind,
+		Title:     models.FormatProblemText(problem.Title, values),
+		Statement: models.FormatProblemText(problem.Statement, values),
+		Points:    attemptProblem.Points,
+	}
+	positions := map[int]int{}
+	for _, index := range attemptProblem.Options {
+		if index >= 0 && index < len(config.Options) {
+			positions[index] = len(resp.Options)
+			resp.Options = append(resp.Options, ProblemOption{
+				Text: models.FormatProblemText(config.Options[index].Text, values),
+			})
+		}
+	}
+	for _, prompt := range config.Prompts {
+		resp.Prompts = append(resp.Prompts, ProblemOption{
+			Text: models.FormatProblemText(prompt.Text, values),
+		})
+	}
+	if answer := attemptProblem.Answer; answer != nil {
+		resp.Answer = &QuizAttemptAnswer{
+			Text:  answer.Text,
+			Value: answer.Value,
+		}
+		for _, index := range answer.Options {
+			if position, ok := positions[index]; ok {
+				resp.Answer.Options = append(resp.Answer.Options, position)
+			} else if problem.Kind == models.MatchingProblem {
+				resp.Answer.Options = append(resp.Answer.Options, -1)
+			}
+		}
+	}
+	if attempt.Status == models.FinishedAttempt {
+		resp.Verdict = attemptProblem.Verdict
+		score := attemptProblem.Score
+		resp.Score = &score
+		resp.Comment = attemptProblem.Comment
+	}
+	return resp, nil
+}
+
+func (v *View) observeQuizAttempts(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.PermissionSet)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	var attempts []models.QuizAttempt
+	var err error
+	if permissions.HasPermission(models.ObserveQuizAttemptsRole) {
+		attempts, err = v.core.QuizAttempts.FindByQuiz(quiz.ID)
+	} else if account := accountCtx.Account; account != nil {
+		attempts, err = v.core.QuizAttempts.FindByQuizAccount(
+			quiz.ID, account.ID,
+		)
+	}
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizAttempts{Attempts: []QuizAttempt{}}
+	for _, attempt := range attempts {
+		attemptPermissions := v.getQuizAttemptPermissions(
+			accountCtx, permissions, attempt,
+		)
+		if attemptPermissions.HasPermission(models.ObserveQuizAttemptRole) {
+			attemptResp, err := makeQuizAttempt(attempt)
+			if err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			resp.Attempts = append(resp.Attempts, attemptResp)
+		}
+	}
+	sort.Sort(quizAttemptSorter(resp.Attempts))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observeQuizAttempt(c echo.Context) error {
+	attempt, ok := c.Get(quizAttemptKey).(models.QuizAttempt)
+	if !ok {
+		c.Logger().Error("attempt not extracted")
+		return fmt.Errorf("attempt not extracted")
+	}
+	resp, err := makeQuizAttempt(attempt)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) createQuizAttempt(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	account := accountCtx.Account
+	if account == nil {
+		return c.JSON(http.StatusForbidden, errorResponse{
+			Message: "attempt can be started only by account",
+		})
+	}
+	now := time.Now()
+	schedule, err := v.getQuizSchedule(quiz, account.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	if !schedule.IsOpen(now) {
+		message := fmt.Sprintf("quiz %d is not open yet", quiz.ID)
+		if schedule.IsClosed(now) {
+			message = fmt.Sprintf("quiz %d is closed", quiz.ID)
+		}
+		return c.JSON(http.StatusBadRequest, errorResponse{Message: message})
+	}
+	sections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	var state models.QuizAttemptState
+	if err := state.GenerateSeed(); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	state.Problems, err = models.DrawQuizAttemptProblems(
+		state.Seed, sections,
+		v.core.PoolProblems.FindByPool, v.core.Problems.Get,
+	)
+	if err != nil {
+		c.Logger().Warn(err)
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "unable to draw problems for attempt",
+		})
+	}
+	if len(state.Problems) == 0 {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: fmt.Sprintf("quiz %d does not have problems", quiz.ID),
+		})
+	}
+	for i, problem := range state.Problems {
+		revision, err := v.core.Problems.LastRevision(
+			getContext(c), problem.ProblemID,
+		)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		state.Problems[i].Revision = revision
+	}
+	attempt := models.QuizAttempt{
+		QuizID:    quiz.ID,
+		AccountID: account.ID,
+		Status:    models.StartedAttempt,
+		StartTime: now.Unix(),
+	}
+	attempt.Deadline = models.NInt64(schedule.Deadline(now))
+	if err := attempt.SetState(state); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	if err := v.core.QuizAttempts.CreateActive(
+		getContext(c), &attempt, now,
+	); err != nil {
+		if active, ok := err.(models.ActiveQuizAttemptError); ok {
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: fmt.Sprintf(
+					"quiz %d already has active attempt %d",
+					quiz.ID, active.ID,
+				),
+			})
+		}
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeQuizAttempt(attempt)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (v *View) observeQuizAttemptProblems(c echo.Context) error {
+	attempt, ok := c.Get(quizAttemptKey).(models.QuizAttempt)
+	if !ok {
+		c.Logger().Error("attempt not extracted")
+		return fmt.Errorf("attempt not extracted")
+	}
+	var state models.QuizAttemptState
+	if err := attempt.ScanState(&state); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizAttemptProblems{Problems: []QuizAttemptProblem{}}
+	for _, attemptProblem := range state.Problems {
+		problem, err := v.getQuizAttemptProblem(getContext(c), attemptProblem)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.Logger().Warnf(
+					"Problem %v not found", attemptProblem.ProblemID,
+				)
+				continue
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		problemResp, err := makeQuizAttemptProblem(
+			attempt, attemptProblem, problem,
+		)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		resp.Problems = append(resp.Problems, problemResp)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// updateQuizAttemptAnswerForm represents form for answering problem
+// of quiz attempt.
+type updateQuizAttemptAnswerForm QuizAttemptAnswer
+
+func (f updateQuizAttemptAnswerForm) Update(
+	answer *models.QuizAttemptAnswer, problem models.Problem,
+	attemptProblem models.QuizAttemptProblem,
+) *errorResponse {
+	errors := errorFields{}
+	*answer = models.QuizAttemptAnswer{}
+	switch problem.Kind {
+	case models.SingleChoiceProblem, models.MultipleChoiceProblem:
+		if problem.Kind == models.SingleChoiceProblem && len(f.Options) > 1 {
+			errors["options"] = errorField{
+				Message: "only one option can be selected",
+			}
+		}
+		selected := map[int]struct{}{}
+		for _, position := range f.Options {
+			if position < 0 || position >= len(attemptProblem.Options) {
+				errors["options"] = errorField{
+					Message: fmt.Sprintf("invalid option %d", position),
+				}
+				break
+			}
+			if _, ok := selected[position]; ok {
+				errors["options"] = errorField{
+					Message: fmt.Sprintf("option %d is duplicated", position),
+				}
+				break
+			}
+			selected[position] = struct{}{}
+			answer.Options = append(
+				answer.Options, attemptProblem.Options[position],
+			)
+		}
+		if len(f.Text) > 0 || f.Value != nil {
+			errors["options"] = errorField{
+				Message: "only options can be specified",
+			}
+		}
+	case models.MatchingProblem:
+		var config models.ProblemConfig
+		if err := problem.ScanConfig(&config); err != nil {
+			return &errorResponse{Message: "unable to parse problem config"}
+		}
+		if len(f.Options) > 0 && len(f.Options) != len(config.Prompts) {
+			errors["options"] = errorField{
+				Message: "option should be specified for every prompt",
+			}
+		}
+		for _, position := range f.Options {
+			if position == -1 {
+				answer.Options = append(answer.Options, -1)
+				continue
+			}
+			if position < 0 || position >= len(attemptProblem.Options) {
+				errors["options"] = errorField{
+					Message: fmt.Sprintf("invalid option %d", position),
+				}
+				break
+			}
+			answer.Options = append(
+				answer.Options, attemptProblem.Options[position],
+			)
+		}
+		if len(f.Text) > 0 || f.Value != nil {
+			errors["options"] = errorField{
+				Message: "only options can be specified",
+			}
+		}
+	case models.TextProblem, models.EssayProblem:
+		maxLength := 4096
+		if problem.Kind == models.EssayProblem {
+			maxLength = 65536
+		}
+		if len(f.Text) > maxLength {
+			errors["text"] = errorField{
+				Message: fmt.Sprintf("text too long (>%d)", maxLength),
+			}
+		}
+		if len(f.Options) > 0 || f.Value != nil {
+			errors["text"] = errorField{
+				Message: "only text can be specified",
+			}
+		}
+		answer.Text = f.Text
+	case models.NumericProblem:
+		if f.Value != nil &&
+			(math.IsNaN(*f.Value) || math.IsInf(*f.Value, 0)) {
+			errors["value"] = errorField{Message: "value should be finite"}
+		}
+		if len(f.Options) > 0 || len(f.Text) > 0 {
+			errors["value"] = errorField{
+				Message: "only value can be specified",
+			}
+		}
+		answer.Value = f.Value
+	default:
+		errors["kind"] = errorField{Message: "kind is not supported"}
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	return nil
+}
+
+func (v *View) updateQuizAttemptAnswer(c echo.Context) error {
+	attempt, ok := c.Get(quizAttemptKey).(models.QuizAttempt)
+	if !ok {
+		c.Logger().Error("attempt not extracted")
+		return fmt.Errorf("attempt not extracted")
+	}
+	var form updateQuizAttemptAnswerForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	problemID, err := strconv.ParseInt(c.Param("problem"), 10, 64)
+	if err != nil {
+		c.Logger().Warn(err)
+		resp := errorResponse{Message: "invalid problem ID"}
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+	// Answer should be checked against revision used by attempt, so
+	// problem is not required to exist in actual store.
+	var extractedState models.QuizAttemptState
+	if err := attempt.ScanState(&extractedState); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	var problem models.Problem
+	found := false
+	for _, attemptProblem := range extractedState.Problems {
+		if attemptProblem.ProblemID != problemID {
+			continue
+		}
+		problem, err = v.getQuizAttemptProblem(getContext(c), attemptProblem)
+		if err != nil && err != sql.ErrNoRows {
+			c.Logger().Error(err)
+			return err
+		}
+		found = err == nil
+		break
+	}
+	if !found {
+		resp := errorResponse{
+			Message: fmt.Sprintf(
+				"attempt %d does not have problem %d",
+				attempt.ID, problemID,
+			),
+		}
+		return c.JSON(http.StatusNotFound, resp)
+	}
+	var attemptProblem models.QuizAttemptProblem
+	if err := v.updateQuizAttempt(
+		getContext(c), &attempt,
+		func(ctx context.Context, attempt *models.QuizAttempt) error {
+			if !attempt.IsActive(time.Now()) {
+				return errorResponse{
+					Code: http.StatusBadRequest,
+					Message: fmt.Sprintf(
+						"attempt %d is already finished", attempt.ID,
+					),
+				}
+			}
+			var state models.QuizAttemptState
+			if err := attempt.ScanState(&state); err != nil {
+				return err
+			}
+			pos := -1
+			for i, attemptProblem := range state.Problems {
+				if attemptProblem.ProblemID == problem.ID {
+					pos = i
+					break
+				}
+			}
+			if pos == -1 {
+				return errorResponse{
+					Code: http.StatusNotFound,
+					Message: fmt.Sprintf(
+						"attempt %d does not have problem %d",
+						attempt.ID, problem.ID,
+					),
+				}
+			}
+			var answer models.QuizAttemptAnswer
+			if resp := form.Update(
+				&answer, problem, state.Problems[pos],
+			); resp != nil {
+				resp.Code = http.StatusBadRequest
+				return *resp
+			}
+			if answer.IsEmpty() {
+				state.Problems[pos].Answer = nil
+			} else {
+				state.Problems[pos].Answer = &answer
+			}
+			state.Problems[pos].AnswerTime = time.Now().Unix()
+			attemptProblem = state.Problems[pos]
+			return attempt.SetState(state)
+		},
+	); err != nil {
+		if _, ok := err.(errorResponse); !ok {
+			c.Logger().Error(err)
+		}
+		return err
+	}
+	resp, err := makeQuizAttemptProblem(attempt, attemptProblem, problem)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) finishQuizAttempt(c echo.Context) error {
+	attempt, ok := c.Get(quizAttemptKey).(models.QuizAttempt)
+	if !ok {
+		c.Logger().Error("attempt not extracted")
+		return fmt.Errorf("attempt not extracted")
+	}
+	// Attempt can be already finished by deadline or by concurrent
+	// request, then it is returned as is.
+	if attempt.Status == models.StartedAttempt {
+		if err := v.closeQuizAttempt(
+			getContext(c), &attempt, time.Now(),
+		); err != nil {
+			if _, ok := err.(errorResponse); !ok {
+				c.Logger().Error(err)
+				return err
+			}
+			attempt, err = v.core.QuizAttempts.Get(attempt.ID)
+			if err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+		}
+	}
+	resp, err := makeQuizAttempt(attempt)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// updateQuizAttempt applies update to actual version of attempt.
+//
+// Attempts are updated concurrently by answers of participant, so
+// update is always applied to attempt synced inside transaction.
+func (v *View) updateQuizAttempt(
+	ctx context.Context, attempt *models.QuizAttempt,
+	update func(context.Context, *models.QuizAttempt) error,
+) error {
+	return v.core.WrapTx(ctx, func(ctx context.Context) error {
+		if err := v.core.QuizAttempts.Sync(ctx); err != nil {
+			return err
+		}
+		actual, err := v.core.QuizAttempts.Get(attempt.ID)
+		if err != nil {
+			return err
+		}
+		if err := update(ctx, &actual); err != nil {
+			return err
+		}
+		if err := v.core.QuizAttempts.Update(ctx, actual); err != nil {
+			return err
+		}
+		*attempt = actual
+		return nil
+	}, sqlRepeatableRead)
+}
+
+// closeQuizAttempt finishes and grades started attempt.
+//
+// Finish time is never greater than attempt deadline, so attempt that
+// exceeded deadline is considered as finished at deadline.
+func (v *View) closeQuizAttempt(
+	ctx context.Context, attempt *models.QuizAttempt, now time.Time,
+) error {
+	return v.updateQuizAttempt(ctx, attempt, func(ctx context.Context, attempt *models.QuizAttempt) error {
+		if attempt.Status != models.StartedAttempt {
+			return errorResponse{
+				Code: http.StatusBadRequest,
+				Message: fmt.Sprintf(
+					"attempt %d is already finished", attempt.ID,
+				),
+			}
+		}
+		finishTime := now.Unix()
+		if attempt.Deadline != 0 && finishTime > int64(attempt.Deadline) {
+			finishTime = int64(attempt.Deadline)
+		}
+		var state models.QuizAttemptState
+		if err := attempt.ScanState(&state); err != nil {
+			return err
+		}
+		if err := v.gradeQuizAttempt(ctx, &state, attempt.QuizID); err != nil {
+			return err
+		}
+		if err := v.createQuizGrades(ctx, *attempt, state); err != nil {
+			return err
+		}
+		if err := attempt.SetState(state); err != nil {
+			return err
+		}
+		attempt.Status = models.FinishedAttempt
+		attempt.FinishTime = models.NInt64(finishTime)
+		return nil
+	})
+}
+
+// getQuizSchedule returns effective schedule of quiz for account.
+func (v *View) getQuizSchedule(
+	quiz models.Quiz, accountID int64,
+) (models.QuizSchedule, error) {
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		return models.QuizSchedule{}, err
+	}
+	override, err := v.core.QuizOverrides.GetByQuizAccount(quiz.ID, accountID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return models.QuizSchedule{}, err
+		}
+		return models.MakeQuizSchedule(config, nil), nil
+	}
+	return models.MakeQuizSchedule(config, &override), nil
+}
+
+// gradeQuizAttempt grades attempt state using scoring policies of quiz.
+//
+// Policy of section is used when it is specified, otherwise policy of
+// quiz is used. Problems are graded in revisions recorded in attempt,
+// problems without recorded revision are graded in actual revision.
+func (v *View) gradeQuizAttempt(
+	ctx context.Context, state *models.QuizAttemptState, quizID int64,
+) error {
+	quiz, err := v.core.Quizes.Get(quizID)
+	if err != nil {
+		return err
+	}
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		return err
+	}
+	sections, err := v.core.QuizSections.FindByQuiz(quizID)
+	if err != nil {
+		return err
+	}
+	policies := map[int64]models.ScoringPolicy{}
+	for _, section := range sections {
+		var sectionConfig models.QuizSectionConfig
+		if err := section.ScanConfig(&sectionConfig); err != nil {
+			return err
+		}
+		if sectionConfig.Scoring != 0 {
+			policies[section.ID] = sectionConfig.Scoring
+		}
+	}
+	problems := map[int64]models.Problem{}
+	for i, attemptProblem := range state.Problems {
+		if attemptProblem.Revision == 0 {
+			revision, err := v.core.Problems.LastRevision(
+				ctx, attemptProblem.ProblemID,
+			)
+			if err != nil {
+				return err
+			}
+			state.Problems[i].Revision = revision
+		}
+		problem, err := v.getQuizAttemptProblem(ctx, state.Problems[i])
+		if err != nil {
+			return err
+		}
+		problems[problem.ID] = problem
+	}
+	return grading.GradeAttempt(
+		state,
+		func(id int64) (models.Problem, error) {
+			if problem, ok := problems[id]; ok {
+				return problem, nil
+			}
+			return models.Problem{}, sql.ErrNoRows
+		},
+		func(problem models.QuizAttemptProblem) models.ScoringPolicy {
+			if policy, ok := policies[problem.SectionID]; ok {
+				return policy
+			}
+			if config.Scoring != 0 {
+				return config.Scoring
+			}
+			return models.AllOrNothingScoring
+		},
+	)
+}
+
+// getQuizAttemptProblem returns problem in revision used by attempt.
+func (v *View) getQuizAttemptProblem(
+	ctx context.Context, attemptProblem models.QuizAttemptProblem,
+) (models.Problem, error) {
+	if attemptProblem.Revision == 0 {
+		return v.core.Problems.Get(attemptProblem.ProblemID)
+	}
+	return v.core.Problems.GetRevision(
+		ctx, attemptProblem.ProblemID, attemptProblem.Revision,
+	)
+}
+
+func (v *View) regradeQuizAttempts(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	attempts, err := v.core.QuizAttempts.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizAttempts{Attempts: []QuizAttempt{}}
+	for _, attempt := range attempts {
+		if attempt.Status != models.FinishedAttempt {
+			continue
+		}
+		if err := v.updateQuizAttempt(
+			getContext(c), &attempt,
+			func(ctx context.Context, attempt *models.QuizAttempt) error {
+				var state models.QuizAttemptState
+				if err := attempt.ScanState(&state); err != nil {
+					return err
+				}
+				if err := v.gradeQuizAttempt(ctx, &state, quiz.ID); err != nil {
+					return err
+				}
+				if err := v.createQuizGrades(ctx, *attempt, state); err != nil {
+					return err
+				}
+				return attempt.SetState(state)
+			},
+		); err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		attemptResp, err := makeQuizAttempt(attempt)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		resp.Attempts = append(resp.Attempts, attemptResp)
+	}
+	sort.Sort(quizAttemptSorter(resp.Attempts))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) extractQuizAttempt(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		quiz, ok := c.Get(quizKey).(models.Quiz)
+		if !ok {
+			c.Logger().Error("quiz not extracted")
+			return fmt.Errorf("quiz not extracted")
+		}
+		id, err := strconv.ParseInt(c.Param("attempt"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid attempt ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+		if !ok {
+			c.Logger().Error("auth not extracted")
+			return fmt.Errorf("auth not extracted")
+		}
+		permissions, ok := c.Get(permissionCtxKey).(managers.PermissionSet)
+		if !ok {
+			c.Logger().Error("permissions not extracted")
+			return fmt.Errorf("permissions not extracted")
+		}
+		attempt, err := v.core.QuizAttempts.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.QuizAttempts.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			attempt, err = v.core.QuizAttempts.Get(id)
+		}
+		if err == nil && attempt.QuizID != quiz.ID {
+			err = sql.ErrNoRows
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("attempt %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		if attempt.IsExpired(time.Now()) {
+			if err := v.closeQuizAttempt(
+				getContext(c), &attempt, time.Now(),
+			); err != nil {
+				// Attempt can be concurrently finished.
+				if _, ok := err.(errorResponse); !ok {
+					c.Logger().Error(err)
+					return err
+				}
+				attempt, err = v.core.QuizAttempts.Get(id)
+				if err != nil {
+					c.Logger().Error(err)
+					return err
+				}
+			}
+		}
+		c.Set(quizAttemptKey, attempt)
+		c.Set(permissionCtxKey, v.getQuizAttemptPermissions(
+			accountCtx, permissions, attempt,
+		))
+		return next(c)
+	}
+}
+
+// getQuizAttemptPermissions returns permissions for attempt using
+// permissions for its quiz.
+func (v *View) getQuizAttemptPermissions(
+	ctx *managers.AccountContext, quizPermissions managers.PermissionSet,
+	attempt models.QuizAttempt,
+) managers.PermissionSet {
+	permissions := quizPermissions.Clone()
+	if permissions.HasPermission(models.ObserveQuizAttemptsRole) {
+		permissions[models.ObserveQuizAttemptRole] = struct{}{}
+	}
+	if account := ctx.Account; account != nil &&
+		account.ID == attempt.AccountID {
+		permissions[models.ObserveQuizAttemptRole] = struct{}{}
+		permissions[models.UpdateQuizAttemptRole] = struct{}{}
+	}
+	return permissions
+}
@@ -0,0 +1,456 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func TestQuizAttemptSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	choice, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("single_choice"),
+		Title:     getPtr("Choice"),
+		Statement: getPtr("Choose B"),
+		Options: &[]ProblemOption{
+			{Text: "A"}, {Text: "B"}, {Text: "C"},
+		},
+		Answer: &ProblemAnswer{Options: []int{1}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	numeric, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	pool, err := testSocketCreatePool(updatePoolForm{Name: getPtr("Pool")})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := testSocketCreatePoolProblem(pool.ID, numeric.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:    getPtr("Quiz"),
+		Duration: getPtr[int64](3600),
+		Sections: &[]QuizSection{
+			{Points: 1, Problems: []int64{choice.ID}},
+			{Points: 2, PoolID: pool.ID, ProblemCount: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(quiz)
+	testCreateUser(t, "first", "qwerty123")
+	testCreateUser(t, "second", "qwerty123")
+	first := newTestClient(testSrv.URL + "/api")
+	if _, err := first.Login("first", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	second := newTestClient(testSrv.URL + "/api")
+	if _, err := second.Login("second", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := first.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if attempt.Deadline != attempt.StartTime+3600 {
+		t.Fatalf("Expected deadline %d, got %d", attempt.StartTime+3600, attempt.Deadline)
+	}
+	testCheck(testClearQuizAttempt(attempt))
+	testSyncManagers(t)
+	if _, err := first.CreateQuizAttempt(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	problems, err := first.ObserveQuizAttemptProblems(quiz.ID, attempt.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	// Options are shuffled, so only set of options can be checked.
+	var options []string
+	correct := -1
+	for i, option := range problems.Problems[0].Options {
+		options = append(options, option.Text)
+		if option.Text == "B" {
+			correct = i
+		}
+	}
+	sort.Strings(options)
+	testCheck(options)
+	problems.Problems[0].Options = nil
+	testCheck(problems)
+	for _, form := range []updateQuizAttemptAnswerForm{
+		{Options: []int{0, 1}},
+		{Options: []int{3}},
+		{Text: "B"},
+	} {
+		if _, err := first.UpdateQuizAttemptAnswer(
+			quiz.ID, attempt.ID, choice.ID, form,
+		); err == nil {
+			t.Fatal("Expected error")
+		} else {
+			testCheck(err)
+		}
+	}
+	if answered, err := first.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, choice.ID,
+		updateQuizAttemptAnswerForm{Options: []int{correct}},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if answered.Answer == nil ||
+		!reflect.DeepEqual(answered.Answer.Options, []int{correct}) {
+		t.Fatalf("Unexpected answer: %v", answered.Answer)
+	}
+	// Answer should be applied to actual attempt without sync.
+	if answered, err := first.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, numeric.ID,
+		updateQuizAttemptAnswerForm{Value: getPtr(5.0)},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(answered)
+	}
+	if _, err := second.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, numeric.ID,
+		updateQuizAttemptAnswerForm{Value: getPtr(4.0)},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := second.ObserveQuizAttempt(quiz.ID, attempt.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if attempts, err := second.ObserveQuizAttempts(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(attempts)
+	}
+	if finished, err := first.FinishQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizAttempt(finished))
+	}
+	// Finished attempt is returned as is.
+	if finished, err := first.FinishQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizAttempt(finished))
+	}
+	if _, err := first.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, numeric.ID,
+		updateQuizAttemptAnswerForm{Value: getPtr(4.0)},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	testSyncManagers(t)
+	if problems, err := first.ObserveQuizAttemptProblems(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		problems.Problems[0].Options = nil
+		problems.Problems[0].Answer = nil
+		testCheck(problems)
+	}
+	testSyncManagers(t)
+	expired, err := first.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	// Move attempt deadline to the past.
+	deadline := time.Now().Add(-time.Minute).Unix()
+	if err := testView.core.WrapTx(
+		context.Background(),
+		func(ctx context.Context) error {
+			attempt, err := testView.core.QuizAttempts.Get(expired.ID)
+			if err != nil {
+				return err
+			}
+			attempt.Deadline = models.NInt64(deadline)
+			return testView.core.QuizAttempts.Update(ctx, attempt)
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if observed, err := first.ObserveQuizAttempt(
+		quiz.ID, expired.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		if observed.FinishTime != deadline {
+			t.Fatalf("Expected finish time %d, got %d", deadline, observed.FinishTime)
+		}
+		testCheck(testClearQuizAttempt(observed))
+	}
+	// Attempt finished at deadline is returned to late submit.
+	if finished, err := first.FinishQuizAttempt(
+		quiz.ID, expired.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if finished.FinishTime != deadline {
+		t.Fatalf("Expected finish time %d, got %d", deadline, finished.FinishTime)
+	}
+	testSyncManagers(t)
+	if attempts, err := first.ObserveQuizAttempts(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		for i := range attempts.Attempts {
+			attempts.Attempts[i] = testClearQuizAttempt(attempts.Attempts[i])
+		}
+		testCheck(attempts)
+	}
+	if attempts, err := testSocketObserveQuizAttempts(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(attempts.Attempts) != 2 {
+		t.Fatalf("Expected %d attempts, got %d", 2, len(attempts.Attempts))
+	}
+}
+
+// testClearQuizAttempt clears timestamps of attempt.
+//
+// Canonical tests does not support current timestamps.
+func testClearQuizAttempt(attempt QuizAttempt) QuizAttempt {
+	attempt.StartTime = 0
+	attempt.Deadline = 0
+	attempt.FinishTime = 0
+	return attempt
+}
+
+func (c *testClient) ObserveQuizAttempts(quiz int64) (QuizAttempts, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/attempts", quiz), nil,
+	)
+	if err != nil {
+		return QuizAttempts{}, err
+	}
+	var respData QuizAttempts
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) CreateQuizAttempt(quiz int64) (QuizAttempt, error) {
+	req, err := http.NewRequest(
+		http.MethodPost, c.getURL("/v0/quizzes/%d/attempts", quiz), nil,
+	)
+	if err != nil {
+		return QuizAttempt{}, err
+	}
+	var respData QuizAttempt
+	err = c.doRequest(req, http.StatusCreated, &respData)
+	return respData, err
+}
+
+func (c *testClient) ObserveQuizAttempt(
+	quiz, attempt int64,
+) (QuizAttempt, error) {
+	req, err := http.NewRequest(
+		http.MethodGet,
+		c.getURL("/v0/quizzes/%d/attempts/%d", quiz, attempt), nil,
+	)
+	if err != nil {
+		return QuizAttempt{}, err
+	}
+	var respData QuizAttempt
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ObserveQuizAttemptProblems(
+	quiz, attempt int64,
+) (QuizAttemptProblems, error) {
+	req, err := http.NewRequest(
+		http.MethodGet,
+		c.getURL("/v0/quizzes/%d/attempts/%d/problems", quiz, attempt),
+		nil,
+	)
+	if err != nil {
+		return QuizAttemptProblems{}, err
+	}
+	var respData QuizAttemptProblems
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) FinishQuizAttempt(
+	quiz, attempt int64,
+) (QuizAttempt, error) {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		c.getURL("/v0/quizzes/%d/attempts/%d/finish", quiz, attempt),
+		nil,
+	)
+	if err != nil {
+		return QuizAttempt{}, err
+	}
+	var respData QuizAttempt
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) UpdateQuizAttemptAnswer(
+	quiz, attempt, problem int64, form updateQuizAttemptAnswerForm,
+) (QuizAttemptProblem, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return QuizAttemptProblem{}, err
+	}
+	req, err := http.NewRequest(
+		http.MethodPost,
+		c.getURL(
+			"/v0/quizzes/%d/attempts/%d/problems/%d/answer",
+			quiz, attempt, problem,
+		),
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return QuizAttemptProblem{}, err
+	}
+	var respData QuizAttemptProblem
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func testSocketObserveQuizAttempts(quiz int64) (QuizAttempts, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/quizzes/%d/attempts", quiz),
+		nil,
+	)
+	var resp QuizAttempts
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func TestQuizAttemptScoring(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("multiple_choice"),
+		Title:     getPtr("Choice"),
+		Statement: getPtr("Choose A and B"),
+		Options: &[]ProblemOption{
+			{Text: "A"}, {Text: "B"}, {Text: "C"}, {Text: "D"},
+		},
+		Answer: &ProblemAnswer{Options: []int{0, 1}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketCreateQuiz(updateQuizForm{
+		Title:   getPtr("Quiz"),
+		Scoring: getPtr("unknown"),
+		Sections: &[]QuizSection{
+			{Points: 4, Problems: []int64{problem.ID}},
+		},
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:   getPtr("Quiz"),
+		Scoring: getPtr("all_or_nothing"),
+		Sections: &[]QuizSection{
+			{Points: 4, Scoring: "proportional", Problems: []int64{problem.ID}},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(quiz)
+	testCreateUser(t, "test", "qwerty123")
+	client := newTestClient(testSrv.URL + "/api")
+	if _, err := client.Login("test", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := client.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	problems, err := client.ObserveQuizAttemptProblems(quiz.ID, attempt.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	var options []int
+	for i, option := range problems.Problems[0].Options {
+		if option.Text == "A" {
+			options = append(options, i)
+		}
+	}
+	if _, err := client.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, problem.ID,
+		updateQuizAttemptAnswerForm{Options: options},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if finished, err := client.FinishQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizAttempt(finished))
+	}
+	testSyncManagers(t)
+	for _, scoring := range []string{"penalty", ""} {
+		if _, err := testSocketUpdateQuiz(quiz.ID, updateQuizForm{
+			Sections: &[]QuizSection{
+				{Points: 4, Scoring: scoring, Problems: []int64{problem.ID}},
+			},
+		}); err != nil {
+			t.Fatal("Error:", err)
+		}
+		testSyncManagers(t)
+		if attempts, err := testSocketRegradeQuizAttempts(quiz.ID); err != nil {
+			t.Fatal("Error:", err)
+		} else {
+			for i := range attempts.Attempts {
+				attempts.Attempts[i] = testClearQuizAttempt(attempts.Attempts[i])
+			}
+			testCheck(attempts)
+		}
+		testSyncManagers(t)
+	}
+}
+
+func testSocketRegradeQuizAttempts(quiz int64) (QuizAttempts, error) {
+	req := httptest.NewRequest(
+		http.MethodPost, fmt.Sprintf("/socket/v0/quizzes/%d/regrade", quiz),
+		nil,
+	)
+	var resp QuizAttempts
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
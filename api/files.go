@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/files"
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// maxFileSize contains maximal size of uploaded file in bytes.
+const maxFileSize = 32 << 20
+
+// File represents uploaded file.
+type File struct {
+	// ID contains file ID.
+	ID int64 `json:"id"`
+	// Name contains file name.
+	Name string `json:"name"`
+	// Size contains size of file in bytes.
+	Size int64 `json:"size"`
+	// ContentType contains MIME type of file.
+	ContentType string `json:"content_type"`
+	// SHA256 contains hex encoded SHA-256 hash of file content.
+	SHA256 string `json:"sha256"`
+	// CreateTime contains time of file upload.
+	CreateTime int64 `json:"create_time"`
+}
+
+// registerFileHandlers registers handlers for file management.
+func (v *View) registerFileHandlers(g *echo.Group) {
+	g.POST(
+		"/v0/files", v.createFile,
+		v.extractAuth(v.sessionAuth),
+		v.requirePermission(models.CreateFileRole),
+	)
+	g.GET(
+		"/v0/files/:file", v.observeFile,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractFile,
+		v.requirePermission(models.ObserveFileRole),
+	)
+	g.GET(
+		"/v0/files/:file/content", v.observeFileContent,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractFile,
+		v.requirePermission(models.ObserveFileRole),
+	)
+	g.DELETE(
+		"/v0/files/:file", v.deleteFile,
+		v.extractAuth(v.sessionAuth), v.extractFile,
+		v.requirePermission(models.DeleteFileRole),
+	)
+}
+
+// registerSocketFileHandlers registers socket handlers for file
+// management.
+func (v *View) registerSocketFileHandlers(g *echo.Group) {
+	g.POST("/v0/files", v.createFile)
+	g.GET("/v0/files/:file", v.observeFile, v.extractFile)
+	g.GET("/v0/files/:file/content", v.observeFileContent, v.extractFile)
+	g.DELETE("/v0/files/:file", v.deleteFile, v.extractFile)
+}
+
+func makeFile(file models.File) File {
+	return File{
+		ID:          file.ID,
+		Name:        file.Name,
+		Size:        file.Size,
+		ContentType: file.ContentType,
+		SHA256:      file.SHA256,
+		CreateTime:  file.CreateTime,
+	}
+}
+
+// createFile uploads file passed in "file" field of multipart form.
+//
+// Content is written to storage only if there are no other files
+// with the same content.
+func (v *View) createFile(c echo.Context) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	storage, err := v.getFileStorage()
+	if err != nil {
+		return err
+	}
+	c.Request().Body = http.MaxBytesReader(
+		c.Response(), c.Request().Body, maxFileSize+1<<20,
+	)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Logger().Warn(err)
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "unable to read file",
+		})
+	}
+	if header.Size > maxFileSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, errorResponse{
+			Message: fmt.Sprintf("file too large (>%d)", maxFileSize),
+		})
+	}
+	name := filepath.Base(header.Filename)
+	if len(name) == 0 || len(name) > 255 || name == "." || name == "/" {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "invalid file name",
+		})
+	}
+	content, err := header.Open()
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	defer func() {
+		_ = content.Close()
+	}()
+	hash, size, err := files.Hash(content)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	contentType, err := getFileContentType(
+		header.Header.Get(echo.HeaderContentType), content,
+	)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	file := models.File{
+		Name:        name,
+		Size:        size,
+		ContentType: contentType,
+		SHA256:      hash,
+		CreateTime:  time.Now().Unix(),
+	}
+	if account := accountCtx.Account; account != nil {
+		file.OwnerID = models.NInt64(account.ID)
+	}
+	if err := v.core.Files.CreateWithContent(
+		getContext(c), &file, func(ctx context.Context) error {
+			if _, err := content.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			return storage.Write(ctx, files.Key(hash), content, size)
+		},
+	); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusCreated, makeFile(file))
+}
+
+func (v *View) observeFile(c echo.Context) error {
+	file, ok := c.Get(fileKey).(models.File)
+	if !ok {
+		c.Logger().Error("file not extracted")
+		return fmt.Errorf("file not extracted")
+	}
+	return c.JSON(http.StatusOK, makeFile(file))
+}
+
+// observeFileContent responds with content of file.
+//
+// Content of file never changes, so hash of content is used as ETag.
+func (v *View) observeFileContent(c echo.Context) error {
+	file, ok := c.Get(fileKey).(models.File)
+	if !ok {
+		c.Logger().Error("file not extracted")
+		return fmt.Errorf("file not extracted")
+	}
+	storage, err := v.getFileStorage()
+	if err != nil {
+		return err
+	}
+	etag := strconv.Quote(file.SHA256)
+	c.Response().Header().Set("ETag", etag)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+	content, err := storage.Read(getContext(c), files.Key(file.SHA256))
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.Logger().Errorf("Content of file %d not found", file.ID)
+			return c.JSON(http.StatusNotFound, errorResponse{
+				Message: fmt.Sprintf("content of file %d not found", file.ID),
+			})
+		}
+		c.Logger().Error(err)
+		return err
+	}
+	defer func() {
+		_ = content.Close()
+	}()
+	c.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		mime.FormatMediaType("inline", map[string]string{
+			"filename": file.Name,
+		}),
+	)
+	c.Response().Header().Set(
+		echo.HeaderContentLength, strconv.FormatInt(file.Size, 10),
+	)
+	return c.Stream(http.StatusOK, file.ContentType, content)
+}
+
+// deleteFile deletes file.
+//
+// Content of file is removed from storage when there are no other
+// files with the same content.
+func (v *View) deleteFile(c echo.Context) error {
+	file, ok := c.Get(fileKey).(models.File)
+	if !ok {
+		c.Logger().Error("file not extracted")
+		return fmt.Errorf("file not extracted")
+	}
+	storage, err := v.getFileStorage()
+	if err != nil {
+		return err
+	}
+	if err := v.core.Files.DeleteWithContent(
+		getContext(c), file.ID, func(ctx context.Context) error {
+			// Unused content is harmless, so file is deleted even
+			// if content is not.
+			if err := storage.Delete(ctx, files.Key(file.SHA256)); err != nil {
+				c.Logger().Warn(err)
+			}
+			return nil
+		},
+	); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	if err := v.core.Files.Sync(getContext(c)); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, makeFile(file))
+}
+
+// getFileStorage returns configured file storage.
+func (v *View) getFileStorage() (files.Storage, error) {
+	if v.core.FileStorage == nil {
+		resp := errorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: "file storage is not configured",
+		}
+		return nil, resp
+	}
+	return v.core.FileStorage, nil
+}
+
+// getFileContentType returns normalized content type of file.
+//
+// If content type is not specified, it is detected from content.
+func getFileContentType(
+	contentType string, content io.ReadSeeker,
+) (string, error) {
+	if len(contentType) > 0 {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err == nil && mediaType != "application/octet-stream" {
+			return mime.FormatMediaType(mediaType, params), nil
+		}
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	var buffer [512]byte
+	n, err := io.ReadFull(content, buffer[:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(buffer[:n]), nil
+}
+
+func (v *View) extractFile(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("file"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid file ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+		if !ok {
+			c.Logger().Error("auth not extracted")
+			return fmt.Errorf("auth not extracted")
+		}
+		file, err := v.core.Files.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.Files.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			file, err = v.core.Files.Get(id)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("file %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		c.Set(fileKey, file)
+		c.Set(permissionCtxKey, v.getFilePermissions(accountCtx, file))
+		return next(c)
+	}
+}
+
+func (v *View) getFilePermissions(
+	ctx *managers.AccountContext, file models.File,
+) managers.PermissionSet {
+	permissions := ctx.Permissions.Clone()
+	if account := ctx.Account; account != nil &&
+		file.OwnerID != 0 && account.ID == int64(file.OwnerID) {
+		permissions[models.ObserveFileRole] = struct{}{}
+		permissions[models.DeleteFileRole] = struct{}{}
+	}
+	return permissions
+}
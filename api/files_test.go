@@ -0,0 +1,180 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/udovin/goquiz/config"
+	"github.com/udovin/goquiz/models"
+)
+
+func TestFileSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	testCreateUser(t, "owner", "qwerty123")
+	testCreateUser(t, "other", "qwerty123")
+	if err := testSocketCreateUserRoles(
+		"owner", models.CreateFileRole,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	owner := newTestClient(testSrv.URL + "/api")
+	if _, err := owner.Login("owner", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	other := newTestClient(testSrv.URL + "/api")
+	if _, err := other.Login("other", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	content := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	if _, err := other.CreateFile("image.gif", content); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	first, err := owner.CreateFile("image.gif", content)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(testClearFile(first))
+	second, err := owner.CreateFile("copy.gif", content)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(testClearFile(second))
+	if first.ID == second.ID || first.SHA256 != second.SHA256 {
+		t.Fatalf("Unexpected files: %v, %v", first, second)
+	}
+	if count := testCountStoredFiles(t); count != 1 {
+		t.Fatalf("Expected %d stored files, got %d", 1, count)
+	}
+	testSyncManagers(t)
+	if data, err := other.ObserveFileContent(second.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if !bytes.Equal(data, content) {
+		t.Fatalf("Expected %q, got %q", content, data)
+	}
+	if _, err := other.DeleteFile(first.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := owner.DeleteFile(first.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if count := testCountStoredFiles(t); count != 1 {
+		t.Fatalf("Expected %d stored files, got %d", 1, count)
+	}
+	if data, err := other.ObserveFileContent(second.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if !bytes.Equal(data, content) {
+		t.Fatalf("Expected %q, got %q", content, data)
+	}
+	if _, err := owner.DeleteFile(second.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if count := testCountStoredFiles(t); count != 0 {
+		t.Fatalf("Expected %d stored files, got %d", 0, count)
+	}
+	testSyncManagers(t)
+	if _, err := other.ObserveFileContent(second.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+}
+
+func testClearFile(file File) File {
+	file.CreateTime = 0
+	return file
+}
+
+func testCountStoredFiles(tb testing.TB) int {
+	options := testView.core.Config.Storage.Options.(config.LocalStorageOptions)
+	count := 0
+	if err := filepath.Walk(
+		options.Path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				count++
+			}
+			return nil
+		},
+	); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	return count
+}
+
+func (c *testClient) CreateFile(name string, content []byte) (File, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		return File{}, err
+	}
+	if _, err := part.Write(content); err != nil {
+		return File{}, err
+	}
+	if err := writer.Close(); err != nil {
+		return File{}, err
+	}
+	req, err := http.NewRequest(
+		http.MethodPost, c.getURL("/v0/files"), &body,
+	)
+	if err != nil {
+		return File{}, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	var resp File
+	err = c.doRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func (c *testClient) ObserveFileContent(file int64) ([]byte, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/files/%d/content", file), nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range c.cookies {
+		req.AddCookie(cookie)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		var respData errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+			return nil, err
+		}
+		return nil, &respData
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (c *testClient) DeleteFile(file int64) (File, error) {
+	req, err := http.NewRequest(
+		http.MethodDelete, c.getURL("/v0/files/%d", file), nil,
+	)
+	if err != nil {
+		return File{}, err
+	}
+	var resp File
+	err = c.doRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
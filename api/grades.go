@@ -0,0 +1,603 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/grading"
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// QuizGrade represents manual grading of answer.
+type QuizGrade struct {
+	// ID contains grade ID.
+	ID int64 `json:"id"`
+	// QuizID contains quiz ID.
+	QuizID int64 `json:"quiz_id"`
+	// AttemptID contains ID of quiz attempt.
+	AttemptID int64 `json:"attempt_id"`
+	// ProblemID contains ID of problem.
+	ProblemID int64 `json:"problem_id"`
+	// Status contains status of grading.
+	Status models.QuizGradeStatus `json:"status"`
+	// GraderID contains ID of account that claimed or graded answer.
+	GraderID int64 `json:"grader_id,omitempty"`
+	// Score contains amount of points for graded answer.
+	Score *float64 `json:"score,omitempty"`
+	// Comment contains comment of grader.
+	Comment string `json:"comment,omitempty"`
+	// Problem contains problem of attempt with given answer.
+	Problem *QuizAttemptProblem `json:"problem,omitempty"`
+}
+
+type quizGradeSorter []QuizGrade
+
+func (v quizGradeSorter) Len() int {
+	return len(v)
+}
+
+// Less orders grades by ID, so answers are graded in order of
+// their arrival.
+func (v quizGradeSorter) Less(i, j int) bool {
+	return v[i].ID < v[j].ID
+}
+
+func (v quizGradeSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// QuizGrades represents quiz grades response.
+type QuizGrades struct {
+	Grades []QuizGrade `json:"grades"`
+}
+
+// QuizGradeEvent represents change of grade.
+type QuizGradeEvent struct {
+	// ID contains ID of grade event.
+	ID int64 `json:"id"`
+	// Kind contains kind of grade event.
+	Kind string `json:"kind"`
+	// Time contains time of grade event.
+	Time int64 `json:"time"`
+	// AccountID contains ID of account that changed grade.
+	AccountID int64 `json:"account_id,omitempty"`
+	// Grade contains grade after change.
+	Grade QuizGrade `json:"grade"`
+}
+
+// QuizGradeEvents represents quiz grade history response.
+type QuizGradeEvents struct {
+	Events []QuizGradeEvent `json:"events"`
+}
+
+// registerQuizGradeHandlers registers handlers for manual grading.
+func (v *View) registerQuizGradeHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/grades", v.observeQuizGrades,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.GradeQuizAttemptsRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/grades/:grade", v.observeQuizGrade,
+		v.extractAuth(v.sessionAuth), v.extractQuiz, v.extractQuizGrade,
+		v.requirePermission(models.GradeQuizAttemptsRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/grades/:grade/history",
+		v.observeQuizGradeHistory,
+		v.extractAuth(v.sessionAuth), v.extractQuiz, v.extractQuizGrade,
+		v.requirePermission(models.GradeQuizAttemptsRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/grades/:grade/claim", v.claimQuizGrade,
+		v.extractAuth(v.sessionAuth), v.extractQuiz, v.extractQuizGrade,
+		v.requirePermission(models.GradeQuizAttemptsRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/grades/:grade/release", v.releaseQuizGrade,
+		v.extractAuth(v.sessionAuth), v.extractQuiz, v.extractQuizGrade,
+		v.requirePermission(models.GradeQuizAttemptsRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/grades/:grade/score", v.scoreQuizGrade,
+		v.extractAuth(v.sessionAuth), v.extractQuiz, v.extractQuizGrade,
+		v.requirePermission(models.GradeQuizAttemptsRole),
+	)
+}
+
+// registerSocketQuizGradeHandlers registers socket handlers for
+// manual grading.
+func (v *View) registerSocketQuizGradeHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/grades", v.observeQuizGrades,
+		v.extractQuiz,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/grades/:grade", v.observeQuizGrade,
+		v.extractQuiz, v.extractQuizGrade,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/grades/:grade/history",
+		v.observeQuizGradeHistory,
+		v.extractQuiz, v.extractQuizGrade,
+	)
+}
+
+func makeQuizGrade(grade models.QuizGrade) (QuizGrade, error) {
+	resp := QuizGrade{
+		ID:        grade.ID,
+		QuizID:    grade.QuizID,
+		AttemptID: grade.AttemptID,
+		ProblemID: grade.ProblemID,
+		Status:    grade.Status,
+		GraderID:  int64(grade.GraderID),
+	}
+	if len(grade.Result) > 0 {
+		var result models.QuizGradeResult
+		if err := grade.ScanResult(&result); err != nil {
+			return QuizGrade{}, err
+		}
+		resp.Score = &result.Score
+		resp.Comment = result.Comment
+	}
+	return resp, nil
+}
+
+// observeQuizGrades returns grading queue of quiz.
+//
+// Grades can be filtered by status using "status" query parameter.
+func (v *View) observeQuizGrades(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	var status models.QuizGradeStatus
+	if param := c.QueryParam("status"); len(param) > 0 {
+		if err := status.UnmarshalText([]byte(param)); err != nil {
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: "invalid grade status",
+			})
+		}
+	}
+	grades, err := v.core.QuizGrades.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizGrades{Grades: []QuizGrade{}}
+	for _, grade := range grades {
+		if status != 0 && grade.Status != status {
+			continue
+		}
+		gradeResp, err := makeQuizGrade(grade)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		resp.Grades = append(resp.Grades, gradeResp)
+	}
+	sort.Sort(quizGradeSorter(resp.Grades))
+	return c.JSON(http.StatusOK, resp)
+}
+
+// observeQuizGrade returns grade with answer that should be graded.
+func (v *View) observeQuizGrade(c echo.Context) error {
+	grade, ok := c.Get(quizGradeKey).(models.QuizGrade)
+	if !ok {
+		c.Logger().Error("grade not extracted")
+		return fmt.Errorf("grade not extracted")
+	}
+	resp, err := makeQuizGrade(grade)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	attempt, err := v.core.QuizAttempts.Get(grade.AttemptID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	var state models.QuizAttemptState
+	if err := attempt.ScanState(&state); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	for _, attemptProblem := range state.Problems {
+		if attemptProblem.ProblemID != grade.ProblemID {
+			continue
+		}
+		problem, err := v.getQuizAttemptProblem(getContext(c), attemptProblem)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		problemResp, err := makeQuizAttemptProblem(
+			attempt, attemptProblem, problem,
+		)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		resp.Problem = &problemResp
+		break
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// observeQuizGradeHistory returns all changes of grade, so it can be
+// observed who graded answer and when.
+func (v *View) observeQuizGradeHistory(c echo.Context) error {
+	grade, ok := c.Get(quizGradeKey).(models.QuizGrade)
+	if !ok {
+		c.Logger().Error("grade not extracted")
+		return fmt.Errorf("grade not extracted")
+	}
+	events, err := v.core.QuizGrades.FindObjectEvents(
+		getContext(c), grade.ID,
+	)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizGradeEvents{Events: []QuizGradeEvent{}}
+	for _, event := range events {
+		gradeResp, err := makeQuizGrade(event.Object())
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		resp.Events = append(resp.Events, QuizGradeEvent{
+			ID:        event.EventID(),
+			Kind:      event.EventKind().String(),
+			Time:      event.EventTime().Unix(),
+			AccountID: int64(event.EventAccountID),
+			Grade:     gradeResp,
+		})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// claimQuizGrade claims answer for grading by current account.
+//
+// Graded answers can be claimed again for regrading.
+func (v *View) claimQuizGrade(c echo.Context) error {
+	grade, ok := c.Get(quizGradeKey).(models.QuizGrade)
+	if !ok {
+		c.Logger().Error("grade not extracted")
+		return fmt.Errorf("grade not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	accountID := accountCtx.Account.ID
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		return v.updateQuizGrade(
+			ctx, &grade, func(grade *models.QuizGrade) error {
+				if grade.Status == models.ClaimedGrade &&
+					int64(grade.GraderID) != accountID {
+					return errorResponse{
+						Code: http.StatusBadRequest,
+						Message: fmt.Sprintf(
+							"grade %d is already claimed", grade.ID,
+						),
+					}
+				}
+				grade.Status = models.ClaimedGrade
+				grade.GraderID = models.NInt64(accountID)
+				return nil
+			},
+		)
+	}, sqlRepeatableRead); err != nil {
+		if _, ok := err.(errorResponse); !ok {
+			c.Logger().Error(err)
+		}
+		return err
+	}
+	resp, err := makeQuizGrade(grade)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// releaseQuizGrade releases claimed answer without grading.
+//
+// Claims of other graders can be released only by quiz managers.
+func (v *View) releaseQuizGrade(c echo.Context) error {
+	grade, ok := c.Get(quizGradeKey).(models.QuizGrade)
+	if !ok {
+		c.Logger().Error("grade not extracted")
+		return fmt.Errorf("grade not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	accountID := accountCtx.Account.ID
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		return v.updateQuizGrade(
+			ctx, &grade, func(grade *models.QuizGrade) error {
+				if grade.Status != models.ClaimedGrade {
+					return errorResponse{
+						Code: http.StatusBadRequest,
+						Message: fmt.Sprintf(
+							"grade %d is not claimed", grade.ID,
+						),
+					}
+				}
+				if int64(grade.GraderID) != accountID &&
+					!permissions.HasPermission(models.UpdateQuizRole) {
+					return errorResponse{
+						Code: http.StatusForbidden,
+						Message: fmt.Sprintf(
+							"grade %d is claimed by another grader", grade.ID,
+						),
+					}
+				}
+				// Answer that was graded before keeps its result.
+				if len(grade.Result) > 0 {
+					var result models.QuizGradeResult
+					if err := grade.ScanResult(&result); err != nil {
+						return err
+					}
+					grade.Status = models.GradedGrade
+					grade.GraderID = models.NInt64(result.GraderID)
+				} else {
+					grade.Status = models.PendingGrade
+					grade.GraderID = 0
+				}
+				return nil
+			},
+		)
+	}, sqlRepeatableRead); err != nil {
+		if _, ok := err.(errorResponse); !ok {
+			c.Logger().Error(err)
+		}
+		return err
+	}
+	resp, err := makeQuizGrade(grade)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// scoreQuizGradeForm represents form for grading answer.
+type scoreQuizGradeForm struct {
+	Score   *float64 `json:"score"`
+	Comment string   `json:"comment"`
+}
+
+func (f scoreQuizGradeForm) Update(
+	result *models.QuizGradeResult, points int64, graderID int64,
+) *errorResponse {
+	errors := errorFields{}
+	if f.Score == nil {
+		errors["score"] = errorField{Message: "score should be specified"}
+	} else if math.IsNaN(*f.Score) || *f.Score < 0 ||
+		*f.Score > float64(points) {
+		errors["score"] = errorField{
+			Message: fmt.Sprintf("score should be between 0 and %d", points),
+		}
+	}
+	if len(f.Comment) > 4096 {
+		errors["comment"] = errorField{Message: "comment too long (>4096)"}
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	*result = models.QuizGradeResult{
+		Score:    *f.Score,
+		Comment:  f.Comment,
+		GraderID: graderID,
+	}
+	return nil
+}
+
+// scoreQuizGrade assigns score and comment to claimed answer.
+//
+// Score is saved to attempt of answer, so total score of attempt is
+// updated together with grade.
+func (v *View) scoreQuizGrade(c echo.Context) error {
+	grade, ok := c.Get(quizGradeKey).(models.QuizGrade)
+	if !ok {
+		c.Logger().Error("grade not extracted")
+		return fmt.Errorf("grade not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	var form scoreQuizGradeForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	attempt, err := v.core.QuizAttempts.Get(grade.AttemptID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	accountID := accountCtx.Account.ID
+	if err := v.updateQuizAttempt(
+		getContext(c), &attempt,
+		func(ctx context.Context, attempt *models.QuizAttempt) error {
+			var state models.QuizAttemptState
+			if err := attempt.ScanState(&state); err != nil {
+				return err
+			}
+			var points int64
+			for _, attemptProblem := range state.Problems {
+				if attemptProblem.ProblemID == grade.ProblemID {
+					points = attemptProblem.Points
+				}
+			}
+			var result models.QuizGradeResult
+			if resp := form.Update(&result, points, accountID); resp != nil {
+				resp.Code = http.StatusBadRequest
+				return *resp
+			}
+			if err := v.updateQuizGrade(
+				ctx, &grade, func(grade *models.QuizGrade) error {
+					if grade.Status != models.ClaimedGrade ||
+						int64(grade.GraderID) != accountID {
+						return errorResponse{
+							Code: http.StatusBadRequest,
+							Message: fmt.Sprintf(
+								"grade %d should be claimed before grading",
+								grade.ID,
+							),
+						}
+					}
+					grade.Status = models.GradedGrade
+					return grade.SetResult(result)
+				},
+			); err != nil {
+				return err
+			}
+			if err := grading.SetManualScore(
+				&state, grade.ProblemID, result.Score, result.Comment,
+			); err != nil {
+				return err
+			}
+			return attempt.SetState(state)
+		},
+	); err != nil {
+		if _, ok := err.(errorResponse); !ok {
+			c.Logger().Error(err)
+		}
+		return err
+	}
+	resp, err := makeQuizGrade(grade)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// updateQuizGrade applies update to actual version of grade.
+//
+// Grades are claimed concurrently by graders, so update should be
+// called inside transaction.
+func (v *View) updateQuizGrade(
+	ctx context.Context, grade *models.QuizGrade,
+	update func(*models.QuizGrade) error,
+) error {
+	if err := v.core.QuizGrades.Sync(ctx); err != nil {
+		return err
+	}
+	actual, err := v.core.QuizGrades.Get(grade.ID)
+	if err != nil {
+		return err
+	}
+	if err := update(&actual); err != nil {
+		return err
+	}
+	if err := v.core.QuizGrades.Update(ctx, actual); err != nil {
+		return err
+	}
+	*grade = actual
+	return nil
+}
+
+// createQuizGrades adds answers of attempt that should be graded
+// manually to grading queue of quiz.
+//
+// Answers that are already in queue are skipped.
+func (v *View) createQuizGrades(
+	ctx context.Context, attempt models.QuizAttempt,
+	state models.QuizAttemptState,
+) error {
+	if err := v.core.QuizGrades.Sync(ctx); err != nil {
+		return err
+	}
+	grades, err := v.core.QuizGrades.FindByAttempt(attempt.ID)
+	if err != nil {
+		return err
+	}
+	queued := map[int64]struct{}{}
+	for _, grade := range grades {
+		queued[grade.ProblemID] = struct{}{}
+	}
+	for _, attemptProblem := range state.Problems {
+		if attemptProblem.Verdict != models.PendingVerdict {
+			continue
+		}
+		if _, ok := queued[attemptProblem.ProblemID]; ok {
+			continue
+		}
+		grade := models.QuizGrade{
+			QuizID:    attempt.QuizID,
+			AttemptID: attempt.ID,
+			ProblemID: attemptProblem.ProblemID,
+			Status:    models.PendingGrade,
+		}
+		if err := v.core.QuizGrades.Create(ctx, &grade); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *View) extractQuizGrade(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("grade"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid grade ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		quiz, ok := c.Get(quizKey).(models.Quiz)
+		if !ok {
+			c.Logger().Error("quiz not extracted")
+			return fmt.Errorf("quiz not extracted")
+		}
+		grade, err := v.core.QuizGrades.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.QuizGrades.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			grade, err = v.core.QuizGrades.Get(id)
+		}
+		if err == nil && grade.QuizID != quiz.ID {
+			err = sql.ErrNoRows
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("grade %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		c.Set(quizGradeKey, grade)
+		return next(c)
+	}
+}
@@ -0,0 +1,326 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func TestQuizGradeSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	if _, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("essay"),
+		Title:     getPtr("Essay"),
+		Statement: getPtr("Tell about yourself"),
+		Answer:    &ProblemAnswer{Texts: []string{"test"}},
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	essay, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("essay"),
+		Title:     getPtr("Essay"),
+		Statement: getPtr("Tell about yourself"),
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	numeric, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title: getPtr("Quiz"),
+		Sections: &[]QuizSection{
+			{Points: 1, Problems: []int64{numeric.ID}},
+			{Points: 4, Problems: []int64{essay.ID}},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	clients := map[string]*testClient{}
+	for _, login := range []string{"student", "grader", "assistant"} {
+		testCreateUser(t, login, "qwerty123")
+		client := newTestClient(testSrv.URL + "/api")
+		if _, err := client.Login(login, "qwerty123"); err != nil {
+			t.Fatal("Error:", err)
+		}
+		clients[login] = client
+	}
+	if err := testSocketCreateUserRoles(
+		"grader", models.GradeQuizAttemptsRole,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := testSocketCreateQuizParticipants(
+		quiz.ID, createQuizParticipantsForm{
+			Login: "assistant",
+			Role:  "grader",
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	attempt, err := clients["student"].CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := clients["student"].UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, numeric.ID,
+		updateQuizAttemptAnswerForm{Value: getPtr(4.0)},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := clients["student"].UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, essay.ID,
+		updateQuizAttemptAnswerForm{Text: "I like quizzes"},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if attempt, err := clients["student"].FinishQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizAttempt(attempt))
+	}
+	testSyncManagers(t)
+	if _, err := clients["student"].ObserveQuizGrades(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	grades, err := clients["grader"].ObserveQuizGrades(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(grades)
+	if len(grades.Grades) != 1 {
+		t.Fatalf("Expected 1 grade, got %d", len(grades.Grades))
+	}
+	grade := grades.Grades[0]
+	if grade, err := clients["grader"].ObserveQuizGrade(
+		quiz.ID, grade.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(grade)
+	}
+	if _, err := clients["grader"].ScoreQuizGrade(
+		quiz.ID, grade.ID, scoreQuizGradeForm{Score: getPtr(3.0)},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if grade, err := clients["grader"].ClaimQuizGrade(
+		quiz.ID, grade.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(grade)
+	}
+	if _, err := clients["assistant"].ClaimQuizGrade(
+		quiz.ID, grade.ID,
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := clients["assistant"].ReleaseQuizGrade(
+		quiz.ID, grade.ID,
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := clients["grader"].ScoreQuizGrade(
+		quiz.ID, grade.ID, scoreQuizGradeForm{Score: getPtr(5.0)},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if grade, err := clients["grader"].ScoreQuizGrade(
+		quiz.ID, grade.ID, scoreQuizGradeForm{
+			Score:   getPtr(3.0),
+			Comment: "Too short",
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(grade)
+	}
+	testSyncManagers(t)
+	if attempt, err := clients["student"].ObserveQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if attempt.Score == nil || *attempt.Score != 4 {
+		t.Fatalf("Expected score 4, got %v", attempt.Score)
+	}
+	if problems, err := clients["student"].ObserveQuizAttemptProblems(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(problems)
+	}
+	// Graded answer can be claimed again for regrading.
+	if _, err := clients["assistant"].ClaimQuizGrade(
+		quiz.ID, grade.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if grade, err := clients["assistant"].ReleaseQuizGrade(
+		quiz.ID, grade.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(grade)
+	}
+	testSyncManagers(t)
+	// Regrading should not reset manual scores.
+	if attempts, err := testSocketRegradeQuizAttempts(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(attempts.Attempts) != 1 ||
+		*attempts.Attempts[0].Score != 4 {
+		t.Fatalf("Unexpected attempts: %v", attempts)
+	}
+	testSyncManagers(t)
+	if grades, err := testSocketObserveQuizGrades(
+		quiz.ID, "graded",
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(grades.Grades) != 1 {
+		t.Fatalf("Expected 1 grade, got %d", len(grades.Grades))
+	}
+	if history, err := testSocketObserveQuizGradeHistory(
+		quiz.ID, grade.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizGradeEvents(history))
+	}
+}
+
+// testClearQuizGradeEvents clears timestamps of grade events.
+//
+// Canonical tests does not support current timestamps.
+func testClearQuizGradeEvents(events QuizGradeEvents) QuizGradeEvents {
+	for i := range events.Events {
+		events.Events[i].Time = 0
+	}
+	return events
+}
+
+func (c *testClient) ObserveQuizGrades(quiz int64) (QuizGrades, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/grades", quiz), nil,
+	)
+	if err != nil {
+		return QuizGrades{}, err
+	}
+	var respData QuizGrades
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ObserveQuizGrade(quiz, grade int64) (QuizGrade, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/grades/%d", quiz, grade), nil,
+	)
+	if err != nil {
+		return QuizGrade{}, err
+	}
+	var respData QuizGrade
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ClaimQuizGrade(quiz, grade int64) (QuizGrade, error) {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		c.getURL("/v0/quizzes/%d/grades/%d/claim", quiz, grade), nil,
+	)
+	if err != nil {
+		return QuizGrade{}, err
+	}
+	var respData QuizGrade
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ReleaseQuizGrade(quiz, grade int64) (QuizGrade, error) {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		c.getURL("/v0/quizzes/%d/grades/%d/release", quiz, grade), nil,
+	)
+	if err != nil {
+		return QuizGrade{}, err
+	}
+	var respData QuizGrade
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ScoreQuizGrade(
+	quiz, grade int64, form scoreQuizGradeForm,
+) (QuizGrade, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return QuizGrade{}, err
+	}
+	req, err := http.NewRequest(
+		http.MethodPost,
+		c.getURL("/v0/quizzes/%d/grades/%d/score", quiz, grade),
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return QuizGrade{}, err
+	}
+	var respData QuizGrade
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func testSocketObserveQuizGrades(
+	quiz int64, status string,
+) (QuizGrades, error) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("/socket/v0/quizzes/%d/grades?status=%s", quiz, status),
+		nil,
+	)
+	var resp QuizGrades
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveQuizGradeHistory(
+	quiz, grade int64,
+) (QuizGradeEvents, error) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("/socket/v0/quizzes/%d/grades/%d/history", quiz, grade),
+		nil,
+	)
+	var resp QuizGradeEvents
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
@@ -0,0 +1,450 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/gift"
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+	"github.com/udovin/goquiz/qti"
+)
+
+// maxImportSize contains maximal size of imported file in bytes.
+const maxImportSize = 16 << 20
+
+// registerImportHandlers registers handlers for importing and exporting
+// problems.
+func (v *View) registerImportHandlers(g *echo.Group) {
+	g.POST(
+		"/v0/pools/:pool/import/gift", v.importPoolGIFT,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(
+			models.CreateProblemRole, models.CreatePoolProblemRole,
+		),
+	)
+	g.POST(
+		"/v0/pools/:pool/import/qti", v.importPoolQTI,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(
+			models.CreateProblemRole, models.CreatePoolProblemRole,
+		),
+	)
+	g.GET(
+		"/v0/pools/:pool/export/qti", v.exportPoolQTI,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(
+			models.UpdatePoolRole, models.ObservePoolProblemsRole,
+		),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/export/qti", v.exportQuizQTI,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.UpdateQuizRole),
+	)
+}
+
+// registerSocketImportHandlers registers socket handlers for importing
+// and exporting problems.
+func (v *View) registerSocketImportHandlers(g *echo.Group) {
+	g.POST(
+		"/v0/pools/:pool/import/gift", v.importPoolGIFT,
+		v.extractPool,
+	)
+	g.POST(
+		"/v0/pools/:pool/import/qti", v.importPoolQTI,
+		v.extractPool,
+	)
+	g.GET(
+		"/v0/pools/:pool/export/qti", v.exportPoolQTI,
+		v.extractPool,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/export/qti", v.exportQuizQTI,
+		v.extractQuiz,
+	)
+}
+
+// importedProblem represents problem read from imported file.
+type importedProblem struct {
+	// Field contains name of field for reporting errors.
+	Field string
+	// Problem contains imported problem.
+	Problem models.Problem
+}
+
+// importPoolGIFT imports problems from GIFT file passed in request
+// body to pool.
+//
+// Problems are imported only if all questions of file are supported.
+func (v *View) importPoolGIFT(c echo.Context) error {
+	data, err := readImportFile(c)
+	if err != nil {
+		return err
+	}
+	questions, err := gift.Parse(bytes.NewReader(data))
+	if err != nil {
+		errs, ok := err.(gift.Errors)
+		if !ok {
+			c.Logger().Warn(err)
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: "unable to read file",
+			})
+		}
+		resp := errorResponse{
+			Message:       "file contains unsupported questions",
+			InvalidFields: errorFields{},
+		}
+		for _, err := range errs {
+			resp.InvalidFields[getImportLineField(err.Line)] = errorField{
+				Message: err.Message,
+			}
+		}
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+	var problems []importedProblem
+	for _, question := range questions {
+		problems = append(problems, importedProblem{
+			Field:   getImportLineField(question.Line),
+			Problem: question.Problem,
+		})
+	}
+	return v.createImportedProblems(c, problems)
+}
+
+// importPoolQTI imports problems from IMS QTI 2.1 content package
+// passed in request body to pool.
+//
+// Problems are imported only if all items of package are supported.
+func (v *View) importPoolQTI(c echo.Context) error {
+	data, err := readImportFile(c)
+	if err != nil {
+		return err
+	}
+	items, err := qti.ReadBytes(data)
+	if err != nil {
+		errs, ok := err.(qti.Errors)
+		if !ok {
+			c.Logger().Warn(err)
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: "unable to read file",
+			})
+		}
+		resp := errorResponse{
+			Message:       "file contains unsupported questions",
+			InvalidFields: errorFields{},
+		}
+		for _, err := range errs {
+			resp.InvalidFields[err.Href] = errorField{Message: err.Message}
+		}
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+	var problems []importedProblem
+	for _, item := range items {
+		problems = append(problems, importedProblem{
+			Field:   item.Href,
+			Problem: item.Problem,
+		})
+	}
+	return v.createImportedProblems(c, problems)
+}
+
+// readImportFile reads imported file from request body.
+func readImportFile(c echo.Context) ([]byte, error) {
+	body := io.LimitReader(c.Request().Body, maxImportSize+1)
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.Logger().Warn(err)
+		resp := errorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "unable to read file",
+		}
+		return nil, resp
+	}
+	if len(data) > maxImportSize {
+		resp := errorResponse{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("file too large (>%d)", maxImportSize),
+		}
+		return nil, resp
+	}
+	return data, nil
+}
+
+// createImportedProblems validates imported problems and appends them
+// to extracted pool.
+func (v *View) createImportedProblems(
+	c echo.Context, imported []importedProblem,
+) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	var problems []models.Problem
+	resp := errorResponse{
+		Message:       "file contains invalid questions",
+		InvalidFields: errorFields{},
+	}
+	for _, item := range imported {
+		problem := item.Problem
+		if err := validateImportedProblem(problem); err != nil {
+			resp.InvalidFields[item.Field] = *err
+			continue
+		}
+		if account := accountCtx.Account; account != nil {
+			problem.OwnerID = models.NInt64(account.ID)
+		}
+		problems = append(problems, problem)
+	}
+	if len(resp.InvalidFields) > 0 {
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+	if len(problems) == 0 {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "file does not contain questions",
+		})
+	}
+	if err := v.importPoolProblems(
+		getContext(c), pool, problems,
+	); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	result := Problems{Problems: []Problem{}}
+	for _, problem := range problems {
+		problemResp, err := makeProblem(
+			problem, v.getProblemPermissions(accountCtx, problem),
+		)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		result.Problems = append(result.Problems, problemResp)
+	}
+	sort.Sort(problemSorter(result.Problems))
+	return c.JSON(http.StatusCreated, result)
+}
+
+// exportPoolQTI exports problems of pool in pool order as IMS QTI 2.1
+// content package.
+func (v *View) exportPoolQTI(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	poolProblems, err := v.core.PoolProblems.FindByPool(pool.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	var ids []int64
+	for _, poolProblem := range poolProblems {
+		ids = append(ids, poolProblem.ProblemID)
+	}
+	return v.writeExportedProblems(c, fmt.Sprintf("pool-%d.zip", pool.ID), ids)
+}
+
+// exportQuizQTI exports fixed problems of quiz sections and problems
+// of section pools as IMS QTI 2.1 content package.
+func (v *View) exportQuizQTI(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	sections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	var ids []int64
+	for _, section := range sections {
+		var config models.QuizSectionConfig
+		if err := section.ScanConfig(&config); err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		ids = append(ids, config.Problems...)
+		if section.PoolID == 0 {
+			continue
+		}
+		poolProblems, err := v.core.PoolProblems.FindByPool(
+			int64(section.PoolID),
+		)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		for _, poolProblem := range poolProblems {
+			ids = append(ids, poolProblem.ProblemID)
+		}
+	}
+	return v.writeExportedProblems(c, fmt.Sprintf("quiz-%d.zip", quiz.ID), ids)
+}
+
+// writeExportedProblems writes problems with specified IDs as IMS QTI
+// 2.1 content package.
+//
+// Every exported problem requires permission for updating it, since
+// exported package contains answers.
+func (v *View) writeExportedProblems(
+	c echo.Context, name string, ids []int64,
+) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	var problems []models.Problem
+	exported := map[int64]struct{}{}
+	for _, id := range ids {
+		if _, ok := exported[id]; ok {
+			continue
+		}
+		exported[id] = struct{}{}
+		problem, err := v.core.Problems.Get(id)
+		if err != nil {
+			c.Logger().Warnf("Problem %v not found", id)
+			continue
+		}
+		permissions := v.getProblemPermissions(accountCtx, problem)
+		if !permissions.HasPermission(models.UpdateProblemRole) {
+			return c.JSON(http.StatusForbidden, errorResponse{
+				Message: fmt.Sprintf(
+					"account missing permissions for problem %d", id,
+				),
+				MissingPermissions: []string{models.UpdateProblemRole},
+			})
+		}
+		var config models.ProblemConfig
+		if err := problem.ScanConfig(&config); err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		if len(config.Variables) > 0 {
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: fmt.Sprintf(
+					"problem %d is parameterized and can not be exported", id,
+				),
+			})
+		}
+		problems = append(problems, problem)
+	}
+	var buffer bytes.Buffer
+	if err := qti.Write(&buffer, problems); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	c.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", name),
+	)
+	return c.Blob(http.StatusOK, "application/zip", buffer.Bytes())
+}
+
+// importPoolProblems creates problems and appends them to pool in
+// one transaction.
+func (v *View) importPoolProblems(
+	ctx context.Context, pool models.Pool, problems []models.Problem,
+) error {
+	return v.core.WrapTx(ctx, func(ctx context.Context) error {
+		if err := v.core.PoolProblems.Sync(ctx); err != nil {
+			return err
+		}
+		poolProblems, err := v.core.PoolProblems.FindByPool(pool.ID)
+		if err != nil {
+			return err
+		}
+		position := int64(1)
+		for _, poolProblem := range poolProblems {
+			if poolProblem.Position >= position {
+				position = poolProblem.Position + 1
+			}
+		}
+		for i := range problems {
+			if err := v.core.Problems.Create(ctx, &problems[i]); err != nil {
+				return err
+			}
+			poolProblem := models.PoolProblem{
+				PoolID:    pool.ID,
+				ProblemID: problems[i].ID,
+				Position:  position,
+			}
+			if err := v.core.PoolProblems.Create(ctx, &poolProblem); err != nil {
+				return err
+			}
+			position++
+		}
+		return nil
+	}, sqlRepeatableRead)
+}
+
+// validateImportedProblem validates imported problem using the same
+// rules as for created problems.
+func validateImportedProblem(problem models.Problem) *errorField {
+	var config models.ProblemConfig
+	if err := problem.ScanConfig(&config); err != nil {
+		return &errorField{Message: "unable to parse problem config"}
+	}
+	var answer models.ProblemAnswer
+	if err := problem.ScanAnswer(&answer); err != nil {
+		return &errorField{Message: "unable to parse problem answer"}
+	}
+	form := updateProblemForm{
+		Kind:      getPtr(problem.Kind.String()),
+		Title:     &problem.Title,
+		Statement: &problem.Statement,
+		Options:   &[]ProblemOption{},
+		Prompts:   &[]ProblemOption{},
+		Variables: &[]ProblemVariable{},
+		Answer: &ProblemAnswer{
+			Options:   answer.Options,
+			Texts:     answer.Texts,
+			Value:     answer.Value,
+			Tolerance: answer.Tolerance,
+			Formula:   answer.Formula,
+		},
+	}
+	for _, option := range config.Options {
+		*form.Options = append(*form.Options, ProblemOption{Text: option.Text})
+	}
+	for _, prompt := range config.Prompts {
+		*form.Prompts = append(*form.Prompts, ProblemOption{Text: prompt.Text})
+	}
+	for _, variable := range config.Variables {
+		*form.Variables = append(*form.Variables, ProblemVariable{
+			Name: variable.Name,
+			Min:  variable.Min,
+			Max:  variable.Max,
+			Step: variable.Step,
+		})
+	}
+	var validated models.Problem
+	if resp := form.Update(&validated); resp != nil {
+		var messages []string
+		for name, field := range resp.InvalidFields {
+			messages = append(messages, name+": "+field.Message)
+		}
+		sort.Strings(messages)
+		return &errorField{Message: strings.Join(messages, "; ")}
+	}
+	return nil
+}
+
+func getImportLineField(line int) string {
+	return fmt.Sprintf("line %d", line)
+}
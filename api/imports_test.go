@@ -0,0 +1,224 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGIFTFile = `// Imported questions.
+::Capital::What is the capital of France? {=Paris ~Berlin ~Rome}
+
+The Sun is a star.{T}
+
+::Pi::Value of pi {#3.14:0.01}
+
+::Countries::Match countries with capitals {
+	=France -> Paris
+	=Italy -> Rome
+	= -> Berlin
+}
+`
+
+func TestImportGIFTScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	pool, err := testSocketCreatePool(updatePoolForm{
+		Name: getPtr("Imported"),
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketImportPoolGIFT(
+		pool.ID, "Essay {}\n\n::Long::Question {=%50%one =two}\n",
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketImportPoolGIFT(
+		pool.ID, "Only one option {=yes}\n\nMatch {=a -> b}\n",
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	problems, err := testSocketImportPoolGIFT(pool.ID, testGIFTFile)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(problems)
+	testSyncManagers(t)
+	if poolProblems, err := testSocketObservePoolProblems(
+		pool.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(poolProblems)
+	}
+	matching := problems.Problems[0]
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title: getPtr("Quiz"),
+		Sections: &[]QuizSection{
+			{Points: 2, Scoring: "proportional", Problems: []int64{matching.ID}},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCreateUser(t, "test", "qwerty123")
+	client := newTestClient(testSrv.URL + "/api")
+	if _, err := client.Login("test", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := client.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	attemptProblems, err := client.ObserveQuizAttemptProblems(
+		quiz.ID, attempt.ID,
+	)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	positions := map[string]int{}
+	for i, option := range attemptProblems.Problems[0].Options {
+		positions[option.Text] = i
+	}
+	if _, err := client.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, matching.ID,
+		updateQuizAttemptAnswerForm{Options: []int{positions["Paris"]}},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if answer, err := client.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, matching.ID,
+		updateQuizAttemptAnswerForm{Options: []int{positions["Paris"], -1}},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if options := answer.Answer.Options; len(options) != 2 ||
+		options[0] != positions["Paris"] || options[1] != -1 {
+		t.Fatalf("Unexpected answer: %v", options)
+	}
+	if finished, err := client.FinishQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizAttempt(finished))
+	}
+}
+
+func testSocketImportPoolGIFT(pool int64, file string) (Problems, error) {
+	req := httptest.NewRequest(
+		http.MethodPost,
+		fmt.Sprintf("/socket/v0/pools/%d/import/gift", pool),
+		strings.NewReader(file),
+	)
+	var resp Problems
+	err := doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func TestImportExportQTIScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	pool, err := testSocketCreatePool(updatePoolForm{
+		Name: getPtr("Source"),
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	problems, err := testSocketImportPoolGIFT(pool.ID, testGIFTFile)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	data, err := testSocketExportQTI(fmt.Sprintf("/pools/%d", pool.ID))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title: getPtr("Quiz"),
+		Sections: &[]QuizSection{
+			{Points: 1, PoolID: pool.ID, ProblemCount: 2},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if quizData, err := testSocketExportQTI(
+		fmt.Sprintf("/quizzes/%d", quiz.ID),
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if !bytes.Equal(data, quizData) {
+		t.Fatal("Expected quiz export to match pool export")
+	}
+	target, err := testSocketCreatePool(updatePoolForm{
+		Name: getPtr("Target"),
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketImportPoolQTI(
+		target.ID, []byte("not a zip"),
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	imported, err := testSocketImportPoolQTI(target.ID, data)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(imported)
+	if len(imported.Problems) != len(problems.Problems) {
+		t.Fatalf(
+			"Expected %d problems, got %d",
+			len(problems.Problems), len(imported.Problems),
+		)
+	}
+	for i, problem := range imported.Problems {
+		expected := problems.Problems[i]
+		expected.ID = problem.ID
+		if !reflect.DeepEqual(expected, problem) {
+			t.Fatalf("Expected problem %+v, got %+v", expected, problem)
+		}
+	}
+}
+
+func testSocketImportPoolQTI(pool int64, file []byte) (Problems, error) {
+	req := httptest.NewRequest(
+		http.MethodPost,
+		fmt.Sprintf("/socket/v0/pools/%d/import/qti", pool),
+		bytes.NewReader(file),
+	)
+	var resp Problems
+	err := doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketExportQTI(path string) ([]byte, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, "/socket/v0"+path+"/export/qti", nil,
+	)
+	rec := httptest.NewRecorder()
+	if err := testHandler(req, rec); err != nil {
+		return nil, err
+	}
+	if rec.Code != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", rec.Code)
+	}
+	return rec.Body.Bytes(), nil
+}
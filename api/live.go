@@ -0,0 +1,401 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+
+	"github.com/udovin/goquiz/live"
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+const (
+	// defaultLiveQuestionDuration contains default countdown of
+	// live question.
+	defaultLiveQuestionDuration = 30 * time.Second
+	// maxLiveQuestionDuration contains maximal countdown of live
+	// question.
+	maxLiveQuestionDuration = time.Hour
+	// liveMessagesBuffer contains amount of messages that can be
+	// queued for subscriber before it is disconnected.
+	liveMessagesBuffer = 64
+)
+
+// LiveSession represents live session of quiz.
+type LiveSession struct {
+	// Code contains join code of session.
+	Code string `json:"code"`
+	// QuizID contains ID of quiz.
+	QuizID int64 `json:"quiz_id"`
+	// Host contains flag that current account is host of session.
+	Host bool `json:"host,omitempty"`
+	// State contains state of session for current account.
+	State live.State `json:"state"`
+}
+
+// registerLiveHandlers registers handlers for live sessions.
+func (v *View) registerLiveHandlers(g *echo.Group) {
+	g.POST(
+		"/v0/quizzes/:quiz/live", v.createLiveSession,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.UpdateQuizRole),
+	)
+	g.GET(
+		"/v0/live/:code", v.observeLiveSession,
+		v.extractAuth(v.sessionAuth), v.extractLiveSession,
+		v.requirePermission(models.ObserveQuizRole),
+	)
+	g.GET(
+		"/v0/live/:code/ws", v.connectLiveSession,
+		v.extractAuth(v.sessionAuth), v.extractLiveSession,
+		v.requirePermission(models.ObserveQuizRole),
+	)
+}
+
+func (v *View) makeLiveSession(
+	session *live.Session, accountID int64,
+) LiveSession {
+	return LiveSession{
+		Code:   session.Code(),
+		QuizID: session.QuizID(),
+		Host:   session.IsHost(accountID),
+		State:  session.State(accountID),
+	}
+}
+
+// getLiveQuestions returns questions for live session of quiz.
+//
+// Live sessions support only fixed problems that can be graded
+// automatically, since all participants answer the same question.
+func (v *View) getLiveQuestions(quiz models.Quiz) ([]live.Question, error) {
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		return nil, err
+	}
+	sections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	var questions []live.Question
+	for _, section := range sections {
+		if section.PoolID != 0 {
+			return nil, errorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "live session does not support sections with pools",
+			}
+		}
+		var sectionConfig models.QuizSectionConfig
+		if err := section.ScanConfig(&sectionConfig); err != nil {
+			return nil, err
+		}
+		policy := sectionConfig.Scoring
+		if policy == 0 {
+			policy = config.Scoring
+		}
+		if policy == 0 {
+			policy = models.AllOrNothingScoring
+		}
+		for _, id := range sectionConfig.Problems {
+			problem, err := v.core.Problems.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			var problemConfig models.ProblemConfig
+			if err := problem.ScanConfig(&problemConfig); err != nil {
+				return nil, err
+			}
+			if problem.Kind.IsManual() || len(problemConfig.Variables) > 0 {
+				return nil, errorResponse{
+					Code: http.StatusBadRequest,
+					Message: fmt.Sprintf(
+						"problem %d can not be used in live session", id,
+					),
+				}
+			}
+			questions = append(questions, live.Question{
+				Problem: problem,
+				Points:  section.Points,
+				Policy:  policy,
+			})
+		}
+	}
+	if len(questions) == 0 {
+		return nil, errorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "quiz does not have problems",
+		}
+	}
+	return questions, nil
+}
+
+func (v *View) createLiveSession(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	questions, err := v.getLiveQuestions(quiz)
+	if err != nil {
+		if _, ok := err.(errorResponse); !ok {
+			c.Logger().Error(err)
+		}
+		return err
+	}
+	session, err := v.Live.Create(
+		quiz.ID, accountCtx.Account.ID, questions, time.Now(),
+	)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(
+		http.StatusCreated, v.makeLiveSession(session, accountCtx.Account.ID),
+	)
+}
+
+func (v *View) observeLiveSession(c echo.Context) error {
+	session, ok := c.Get(liveSessionKey).(*live.Session)
+	if !ok {
+		c.Logger().Error("live session not extracted")
+		return fmt.Errorf("live session not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	return c.JSON(
+		http.StatusOK, v.makeLiveSession(session, accountCtx.Account.ID),
+	)
+}
+
+// liveCommand represents command sent by client of live session.
+type liveCommand struct {
+	// Type contains type of command.
+	//
+	// Host can send "next", "close", "reveal", "leaderboard" and
+	// "finish" commands. Participants can send "answer" commands.
+	Type string `json:"type"`
+	// Duration contains countdown of question in seconds for "next".
+	Duration int64 `json:"duration"`
+	// Index contains index of answered question for "answer".
+	Index int `json:"index"`
+	// Answer contains answer for "answer".
+	Answer models.QuizAttemptAnswer `json:"answer"`
+}
+
+// liveSubscriber represents WebSocket connection subscribed to live
+// session.
+//
+// Messages are queued and written by separate goroutine, so slow
+// connections do not block session. Connections that can not keep
+// up with messages are closed.
+type liveSubscriber struct {
+	accountID int64
+	conn      *websocket.Conn
+	messages  chan live.Message
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// AccountID returns ID of subscribed account.
+func (s *liveSubscriber) AccountID() int64 {
+	return s.accountID
+}
+
+// Send queues message for writing to connection.
+func (s *liveSubscriber) Send(message live.Message) {
+	select {
+	case s.messages <- message:
+	default:
+		s.Close()
+	}
+}
+
+// Close closes connection.
+func (s *liveSubscriber) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.conn.Close()
+	})
+}
+
+func (s *liveSubscriber) writeLoop() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case message := <-s.messages:
+			if err := websocket.JSON.Send(s.conn, message); err != nil {
+				s.Close()
+				return
+			}
+		}
+	}
+}
+
+// connectLiveSession upgrades connection to WebSocket and subscribes
+// it to live session.
+//
+// Accounts that are not host of session join it as participants.
+func (v *View) connectLiveSession(c echo.Context) error {
+	session, ok := c.Get(liveSessionKey).(*live.Session)
+	if !ok {
+		c.Logger().Error("live session not extracted")
+		return fmt.Errorf("live session not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	accountID := accountCtx.Account.ID
+	host := session.IsHost(accountID)
+	if !host {
+		if !permissions.HasPermission(models.CreateQuizAttemptRole) {
+			return c.JSON(http.StatusForbidden, errorResponse{
+				Message:            "account missing permissions",
+				MissingPermissions: []string{models.CreateQuizAttemptRole},
+			})
+		}
+		name := ""
+		if user := accountCtx.User; user != nil {
+			name = user.Login
+		}
+		if err := session.Join(accountID, name); err != nil {
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: err.Error(),
+			})
+		}
+	}
+	server := websocket.Server{
+		Handshake: checkLiveOrigin,
+		Handler: func(conn *websocket.Conn) {
+			subscriber := liveSubscriber{
+				accountID: accountID,
+				conn:      conn,
+				messages:  make(chan live.Message, liveMessagesBuffer),
+				done:      make(chan struct{}),
+			}
+			go subscriber.writeLoop()
+			session.Subscribe(&subscriber)
+			defer session.Unsubscribe(&subscriber)
+			defer subscriber.Close()
+			for {
+				var command liveCommand
+				if err := websocket.JSON.Receive(conn, &command); err != nil {
+					return
+				}
+				if err := v.applyLiveCommand(
+					session, accountID, host, command,
+				); err != nil {
+					subscriber.Send(live.Message{
+						Type:    live.ErrorMessage,
+						Index:   command.Index,
+						Message: err.Error(),
+					})
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// checkLiveOrigin rejects WebSocket connections from other sites.
+//
+// Browsers send cookies with WebSocket handshakes from any site, so
+// origin should be checked to prevent cross-site hijacking.
+func checkLiveOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := url.Parse(req.Header.Get("Origin"))
+	if err != nil {
+		return err
+	}
+	if origin.Host != req.Host {
+		return fmt.Errorf("invalid origin: %q", origin)
+	}
+	config.Origin = origin
+	return nil
+}
+
+func (v *View) applyLiveCommand(
+	session *live.Session, accountID int64, host bool, command liveCommand,
+) error {
+	now := time.Now()
+	if !host {
+		if command.Type != "answer" {
+			return fmt.Errorf("unsupported command: %q", command.Type)
+		}
+		_, err := session.Answer(accountID, command.Index, command.Answer, now)
+		return err
+	}
+	switch command.Type {
+	case "next":
+		duration := defaultLiveQuestionDuration
+		if command.Duration != 0 {
+			duration = time.Duration(command.Duration) * time.Second
+		}
+		if command.Duration < 0 {
+			return fmt.Errorf("duration should not be negative")
+		}
+		if duration > maxLiveQuestionDuration {
+			return fmt.Errorf("duration is too long")
+		}
+		return session.Next(duration, now)
+	case "close":
+		return session.Close(now)
+	case "reveal":
+		return session.Reveal(now)
+	case "leaderboard":
+		session.ShowLeaderboard()
+		return nil
+	case "finish":
+		session.Finish(now)
+		return nil
+	default:
+		return fmt.Errorf("unsupported command: %q", command.Type)
+	}
+}
+
+func (v *View) extractLiveSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		code := c.Param("code")
+		accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+		if !ok {
+			c.Logger().Error("auth not extracted")
+			return fmt.Errorf("auth not extracted")
+		}
+		session, ok := v.Live.Get(code)
+		if !ok {
+			resp := errorResponse{
+				Message: fmt.Sprintf("live session %q not found", code),
+			}
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		quiz, err := v.core.Quizes.Get(session.QuizID())
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		c.Set(liveSessionKey, session)
+		c.Set(quizKey, quiz)
+		c.Set(permissionCtxKey, v.getQuizPermissions(accountCtx, quiz))
+		return next(c)
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/udovin/goquiz/live"
+	"github.com/udovin/goquiz/models"
+)
+
+func TestLiveSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title: getPtr("Quiz"),
+		Sections: &[]QuizSection{
+			{Points: 2, Problems: []int64{problem.ID}},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	clients := map[string]*testClient{}
+	for _, login := range []string{"host", "student"} {
+		testCreateUser(t, login, "qwerty123")
+		client := newTestClient(testSrv.URL + "/api")
+		if _, err := client.Login(login, "qwerty123"); err != nil {
+			t.Fatal("Error:", err)
+		}
+		clients[login] = client
+	}
+	if _, err := testSocketCreateQuizParticipants(
+		quiz.ID, createQuizParticipantsForm{
+			Login: "host",
+			Role:  "manager",
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := clients["student"].CreateLiveSession(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	session, err := clients["host"].CreateLiveSession(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := clients["student"].ObserveLiveSession("UNKNOWN"); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	host, err := clients["host"].ConnectLiveSession(session.Code)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer host.Close()
+	testCheck(testClearLiveMessage(testLiveReceive(t, host, live.StateMessage)))
+	student, err := clients["student"].ConnectLiveSession(session.Code)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer student.Close()
+	testLiveReceive(t, student, live.StateMessage)
+	// Participants can not control session.
+	testLiveSend(t, student, liveCommand{Type: "next"})
+	testCheck(testLiveReceive(t, student, live.ErrorMessage))
+	testLiveSend(t, host, liveCommand{Type: "next", Duration: -60})
+	testCheck(testLiveReceive(t, host, live.ErrorMessage))
+	testLiveSend(t, host, liveCommand{Type: "next", Duration: 60})
+	testCheck(testClearLiveMessage(testLiveReceive(t, student, live.QuestionMessage)))
+	testLiveSend(t, student, liveCommand{
+		Type:   "answer",
+		Answer: models.QuizAttemptAnswer{Value: getPtr(4.0)},
+	})
+	testCheck(testLiveReceive(t, student, live.ResultMessage))
+	// Host page reload restores current state of session.
+	if err := host.Close(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	host, err = clients["host"].ConnectLiveSession(session.Code)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer host.Close()
+	testCheck(testClearLiveMessage(testLiveReceive(t, host, live.StateMessage)))
+	testLiveSend(t, host, liveCommand{Type: "close"})
+	testLiveReceive(t, student, live.CloseMessage)
+	testLiveSend(t, host, liveCommand{Type: "reveal"})
+	testCheck(testLiveReceive(t, student, live.RevealMessage))
+	testLiveSend(t, host, liveCommand{Type: "finish"})
+	testCheck(testLiveReceive(t, student, live.FinishMessage))
+	if state, err := clients["student"].ObserveLiveSession(
+		session.Code,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearLiveSession(state))
+	}
+}
+
+// testClearLiveSession clears join code and deadline of live session.
+//
+// Canonical tests does not support random codes and current timestamps.
+func testClearLiveSession(session LiveSession) LiveSession {
+	session.Code = ""
+	session.State.Code = ""
+	session.State.Deadline = 0
+	return session
+}
+
+// testClearLiveMessage clears join code and deadline of live message.
+//
+// Canonical tests does not support random codes and current timestamps.
+func testClearLiveMessage(message live.Message) live.Message {
+	message.Deadline = 0
+	if message.State != nil {
+		message.State.Code = ""
+		message.State.Deadline = 0
+	}
+	return message
+}
+
+func testLiveSend(tb testing.TB, conn *websocket.Conn, command liveCommand) {
+	if err := websocket.JSON.Send(conn, command); err != nil {
+		tb.Fatal("Error:", err)
+	}
+}
+
+// testLiveReceive receives messages until message of specified type.
+func testLiveReceive(
+	tb testing.TB, conn *websocket.Conn, kind string,
+) live.Message {
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	for {
+		var message live.Message
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			tb.Fatal("Error:", err)
+		}
+		if message.Type == kind {
+			return message
+		}
+	}
+}
+
+func (c *testClient) CreateLiveSession(quiz int64) (LiveSession, error) {
+	req, err := http.NewRequest(
+		http.MethodPost, c.getURL("/v0/quizzes/%d/live", quiz), nil,
+	)
+	if err != nil {
+		return LiveSession{}, err
+	}
+	var respData LiveSession
+	err = c.doRequest(req, http.StatusCreated, &respData)
+	return respData, err
+}
+
+func (c *testClient) ObserveLiveSession(code string) (LiveSession, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/live/%s", code), nil,
+	)
+	if err != nil {
+		return LiveSession{}, err
+	}
+	var respData LiveSession
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ConnectLiveSession(code string) (*websocket.Conn, error) {
+	location := strings.Replace(
+		c.getURL("/v0/live/%s/ws", code), "http://", "ws://", 1,
+	)
+	config, err := websocket.NewConfig(location, testSrv.URL)
+	if err != nil {
+		return nil, err
+	}
+	var cookies []string
+	for _, cookie := range c.cookies {
+		cookies = append(cookies, fmt.Sprintf("%s=%s", cookie.Name, cookie.Value))
+	}
+	config.Header.Set("Cookie", strings.Join(cookies, "; "))
+	return websocket.DialConfig(config)
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/models"
+)
+
+// QuizOverride represents schedule override of quiz for participant.
+type QuizOverride struct {
+	// ID contains override ID.
+	ID int64 `json:"id"`
+	// QuizID contains quiz ID.
+	QuizID int64 `json:"quiz_id"`
+	// AccountID contains ID of participant account.
+	AccountID int64 `json:"account_id"`
+	// OpenTime contains overridden open time of quiz.
+	OpenTime int64 `json:"open_time,omitempty"`
+	// CloseTime contains overridden close time of quiz.
+	CloseTime int64 `json:"close_time,omitempty"`
+	// Duration contains overridden time limit of attempt in seconds.
+	Duration int64 `json:"duration,omitempty"`
+}
+
+type quizOverrideSorter []QuizOverride
+
+func (v quizOverrideSorter) Len() int {
+	return len(v)
+}
+
+func (v quizOverrideSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v quizOverrideSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// QuizOverrides represents quiz overrides response.
+type QuizOverrides struct {
+	Overrides []QuizOverride `json:"overrides"`
+}
+
+// registerQuizOverrideHandlers registers handlers for quiz overrides.
+func (v *View) registerQuizOverrideHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/overrides", v.observeQuizOverrides,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizOverridesRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/overrides", v.createQuizOverride,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.CreateQuizOverrideRole),
+	)
+	g.PATCH(
+		"/v0/quizzes/:quiz/overrides/:override", v.updateQuizOverride,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.extractQuizOverride,
+		v.requirePermission(models.UpdateQuizOverrideRole),
+	)
+	g.DELETE(
+		"/v0/quizzes/:quiz/overrides/:override", v.deleteQuizOverride,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.extractQuizOverride,
+		v.requirePermission(models.DeleteQuizOverrideRole),
+	)
+}
+
+// registerSocketQuizOverrideHandlers registers socket handlers for
+// quiz overrides.
+func (v *View) registerSocketQuizOverrideHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/overrides", v.observeQuizOverrides,
+		v.extractQuiz,
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/overrides", v.createQuizOverride,
+		v.extractQuiz,
+	)
+	g.PATCH(
+		"/v0/quizzes/:quiz/overrides/:override", v.updateQuizOverride,
+		v.extractQuiz, v.extractQuizOverride,
+	)
+	g.DELETE(
+		"/v0/quizzes/:quiz/overrides/:override", v.deleteQuizOverride,
+		v.extractQuiz, v.extractQuizOverride,
+	)
+}
+
+func makeQuizOverride(override models.QuizOverride) QuizOverride {
+	return QuizOverride{
+		ID:        override.ID,
+		QuizID:    override.QuizID,
+		AccountID: override.AccountID,
+		OpenTime:  int64(override.OpenTime),
+		CloseTime: int64(override.CloseTime),
+		Duration:  int64(override.Duration),
+	}
+}
+
+func (v *View) observeQuizOverrides(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	overrides, err := v.core.QuizOverrides.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizOverrides{Overrides: []QuizOverride{}}
+	for _, override := range overrides {
+		resp.Overrides = append(resp.Overrides, makeQuizOverride(override))
+	}
+	sort.Sort(quizOverrideSorter(resp.Overrides))
+	return c.JSON(http.StatusOK, resp)
+}
+
+// updateQuizOverrideForm represents form for updating quiz override.
+//
+// Zero value of field resets it to the value from quiz.
+type updateQuizOverrideForm struct {
+	OpenTime  *int64 `json:"open_time"`
+	CloseTime *int64 `json:"close_time"`
+	Duration  *int64 `json:"duration"`
+}
+
+func (f updateQuizOverrideForm) Update(
+	override *models.QuizOverride, config models.QuizConfig,
+) *errorResponse {
+	if f.OpenTime != nil {
+		override.OpenTime = models.NInt64(*f.OpenTime)
+	}
+	if f.CloseTime != nil {
+		override.CloseTime = models.NInt64(*f.CloseTime)
+	}
+	if f.Duration != nil {
+		override.Duration = models.NInt64(*f.Duration)
+	}
+	errors := errorFields{}
+	if override.Duration < 0 {
+		errors["duration"] = errorField{
+			Message: "duration should not be negative",
+		}
+	}
+	schedule := models.MakeQuizSchedule(config, override)
+	validateQuizWindow(errors, schedule.OpenTime, schedule.CloseTime)
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	return nil
+}
+
+// createQuizOverrideForm represents form for creating quiz override.
+type createQuizOverrideForm struct {
+	updateQuizOverrideForm
+	// Login contains login of participant.
+	Login string `json:"login"`
+}
+
+func (v *View) createQuizOverride(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	var form createQuizOverrideForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	user, err := v.core.Users.GetByLogin(form.Login)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, errorResponse{
+				Message: fmt.Sprintf("user %q not found", form.Login),
+			})
+		}
+		c.Logger().Error(err)
+		return err
+	}
+	if _, err := v.core.QuizOverrides.GetByQuizAccount(
+		quiz.ID, user.AccountID,
+	); err == nil {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: fmt.Sprintf(
+				"user %q already has override", form.Login,
+			),
+		})
+	} else if err != sql.ErrNoRows {
+		c.Logger().Error(err)
+		return err
+	}
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	override := models.QuizOverride{
+		QuizID:    quiz.ID,
+		AccountID: user.AccountID,
+	}
+	if err := form.Update(&override, config); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if err := v.core.QuizOverrides.Create(
+		getContext(c), &override,
+	); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusCreated, makeQuizOverride(override))
+}
+
+func (v *View) updateQuizOverride(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	override, ok := c.Get(quizOverrideKey).(models.QuizOverride)
+	if !ok {
+		c.Logger().Error("override not extracted")
+		return fmt.Errorf("override not extracted")
+	}
+	var form updateQuizOverrideForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	if err := form.Update(&override, config); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if err := v.core.QuizOverrides.Update(
+		getContext(c), override,
+	); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, makeQuizOverride(override))
+}
+
+func (v *View) deleteQuizOverride(c echo.Context) error {
+	override, ok := c.Get(quizOverrideKey).(models.QuizOverride)
+	if !ok {
+		c.Logger().Error("override not extracted")
+		return fmt.Errorf("override not extracted")
+	}
+	if err := v.core.QuizOverrides.Delete(
+		getContext(c), override.ID,
+	); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, makeQuizOverride(override))
+}
+
+func (v *View) extractQuizOverride(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("override"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid override ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		quiz, ok := c.Get(quizKey).(models.Quiz)
+		if !ok {
+			c.Logger().Error("quiz not extracted")
+			return fmt.Errorf("quiz not extracted")
+		}
+		override, err := v.core.QuizOverrides.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.QuizOverrides.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			override, err = v.core.QuizOverrides.Get(id)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("override %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		if override.QuizID != quiz.ID {
+			resp := errorResponse{
+				Message: fmt.Sprintf("override %d not found", id),
+			}
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		c.Set(quizOverrideKey, override)
+		return next(c)
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuizOverrideSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	now := time.Now().Unix()
+	if _, err := testSocketCreateQuiz(updateQuizForm{
+		Title:     getPtr("Quiz"),
+		OpenTime:  getPtr(now),
+		CloseTime: getPtr(now - 60),
+		Sections:  &[]QuizSection{{Points: 1, Problems: []int64{problem.ID}}},
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:     getPtr("Quiz"),
+		Duration:  getPtr[int64](3600),
+		OpenTime:  getPtr(now + 3600),
+		CloseTime: getPtr(now + 7200),
+		Sections:  &[]QuizSection{{Points: 1, Problems: []int64{problem.ID}}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCreateUser(t, "first", "qwerty123")
+	testCreateUser(t, "second", "qwerty123")
+	first := newTestClient(testSrv.URL + "/api")
+	if _, err := first.Login("first", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	second := newTestClient(testSrv.URL + "/api")
+	if _, err := second.Login("second", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := first.CreateQuizAttempt(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketCreateQuizOverride(quiz.ID, createQuizOverrideForm{
+		Login: "unknown",
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	override, err := testSocketCreateQuizOverride(quiz.ID, createQuizOverrideForm{
+		Login: "first",
+		updateQuizOverrideForm: updateQuizOverrideForm{
+			OpenTime: getPtr(now - 60),
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(testClearQuizOverride(override))
+	testSyncManagers(t)
+	if _, err := testSocketCreateQuizOverride(quiz.ID, createQuizOverrideForm{
+		Login: "first",
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketUpdateQuizOverride(
+		quiz.ID, override.ID,
+		updateQuizOverrideForm{CloseTime: getPtr(now - 120)},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	// Extended time should be cut off by close time of quiz.
+	if updated, err := testSocketUpdateQuizOverride(
+		quiz.ID, override.ID,
+		updateQuizOverrideForm{Duration: getPtr[int64](36000)},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizOverride(updated))
+	}
+	testSyncManagers(t)
+	attempt, err := first.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if attempt.Deadline != now+7200 {
+		t.Fatalf("Expected deadline %d, got %d", now+7200, attempt.Deadline)
+	}
+	if _, err := second.CreateQuizAttempt(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if overrides, err := testSocketObserveQuizOverrides(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		for i := range overrides.Overrides {
+			overrides.Overrides[i] = testClearQuizOverride(overrides.Overrides[i])
+		}
+		testCheck(overrides)
+	}
+	if _, err := testSocketDeleteQuizOverride(quiz.ID, override.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketDeleteQuizOverride(quiz.ID, override.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketUpdateQuiz(quiz.ID, updateQuizForm{
+		OpenTime:  getPtr(now - 7200),
+		CloseTime: getPtr(now - 3600),
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := second.CreateQuizAttempt(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+}
+
+// testClearQuizOverride clears timestamps of override.
+//
+// Canonical tests does not support current timestamps.
+func testClearQuizOverride(override QuizOverride) QuizOverride {
+	override.OpenTime = 0
+	override.CloseTime = 0
+	return override
+}
+
+func testSocketObserveQuizOverrides(quiz int64) (QuizOverrides, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/quizzes/%d/overrides", quiz),
+		nil,
+	)
+	var resp QuizOverrides
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketCreateQuizOverride(
+	quiz int64, form createQuizOverrideForm,
+) (QuizOverride, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return QuizOverride{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPost, fmt.Sprintf("/socket/v0/quizzes/%d/overrides", quiz),
+		bytes.NewReader(data),
+	)
+	var resp QuizOverride
+	err = doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketUpdateQuizOverride(
+	quiz, override int64, form updateQuizOverrideForm,
+) (QuizOverride, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return QuizOverride{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPatch,
+		fmt.Sprintf("/socket/v0/quizzes/%d/overrides/%d", quiz, override),
+		bytes.NewReader(data),
+	)
+	var resp QuizOverride
+	err = doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketDeleteQuizOverride(
+	quiz, override int64,
+) (QuizOverride, error) {
+	req := httptest.NewRequest(
+		http.MethodDelete,
+		fmt.Sprintf("/socket/v0/quizzes/%d/overrides/%d", quiz, override),
+		nil,
+	)
+	var resp QuizOverride
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
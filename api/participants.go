@@ -0,0 +1,337 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// QuizParticipant represents participant of quiz.
+type QuizParticipant struct {
+	// ID contains participant ID.
+	ID int64 `json:"id"`
+	// QuizID contains quiz ID.
+	QuizID int64 `json:"quiz_id"`
+	// AccountID contains ID of participant account.
+	AccountID int64 `json:"account_id"`
+	// User contains participant user.
+	User *User `json:"user,omitempty"`
+	// Role contains role of participant.
+	Role models.QuizParticipantRole `json:"role"`
+}
+
+type quizParticipantSorter []QuizParticipant
+
+func (v quizParticipantSorter) Len() int {
+	return len(v)
+}
+
+func (v quizParticipantSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v quizParticipantSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// QuizParticipants represents quiz participants response.
+type QuizParticipants struct {
+	Participants []QuizParticipant `json:"participants"`
+}
+
+// registerQuizParticipantHandlers registers handlers for quiz
+// participants.
+func (v *View) registerQuizParticipantHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/participants", v.observeQuizParticipants,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizParticipantsRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/participants", v.createQuizParticipants,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.CreateQuizParticipantRole),
+	)
+	g.DELETE(
+		"/v0/quizzes/:quiz/participants/:participant",
+		v.deleteQuizParticipant,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.extractQuizParticipant,
+		v.requirePermission(models.DeleteQuizParticipantRole),
+	)
+}
+
+// registerSocketQuizParticipantHandlers registers socket handlers for
+// quiz participants.
+func (v *View) registerSocketQuizParticipantHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/participants", v.observeQuizParticipants,
+		v.extractQuiz,
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/participants", v.createQuizParticipants,
+		v.extractQuiz,
+	)
+	g.DELETE(
+		"/v0/quizzes/:quiz/participants/:participant",
+		v.deleteQuizParticipant,
+		v.extractQuiz, v.extractQuizParticipant,
+	)
+}
+
+func (v *View) makeQuizParticipant(
+	participant models.QuizParticipant, permissions managers.Permissions,
+) QuizParticipant {
+	resp := QuizParticipant{
+		ID:        participant.ID,
+		QuizID:    participant.QuizID,
+		AccountID: participant.AccountID,
+		Role:      participant.Role,
+	}
+	if user, err := v.core.Users.GetByAccount(
+		participant.AccountID,
+	); err == nil {
+		userResp := makeUser(user, permissions)
+		resp.User = &userResp
+	}
+	return resp
+}
+
+func (v *View) observeQuizParticipants(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	participants, err := v.core.QuizParticipants.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizParticipants{Participants: []QuizParticipant{}}
+	for _, participant := range participants {
+		resp.Participants = append(
+			resp.Participants,
+			v.makeQuizParticipant(participant, permissions),
+		)
+	}
+	sort.Sort(quizParticipantSorter(resp.Participants))
+	return c.JSON(http.StatusOK, resp)
+}
+
+// createQuizParticipantsForm represents form for inviting participants.
+//
+// Single participant can be invited with Login, several participants
+// can be invited at once with Logins.
+type createQuizParticipantsForm struct {
+	Login  string   `json:"login"`
+	Logins []string `json:"logins"`
+	Role   string   `json:"role"`
+}
+
+// GetLogins returns all logins from form.
+func (f createQuizParticipantsForm) GetLogins() []string {
+	if len(f.Login) > 0 {
+		return append([]string{f.Login}, f.Logins...)
+	}
+	return f.Logins
+}
+
+func (f createQuizParticipantsForm) Update(
+	role *models.QuizParticipantRole,
+) *errorResponse {
+	errors := errorFields{}
+	*role = models.RegularParticipant
+	if len(f.Role) > 0 {
+		if err := role.UnmarshalText([]byte(f.Role)); err != nil {
+			errors["role"] = errorField{Message: "role is not supported"}
+		}
+	}
+	if logins := f.GetLogins(); len(logins) == 0 {
+		errors["logins"] = errorField{Message: "logins should not be empty"}
+	} else if len(logins) > 1000 {
+		errors["logins"] = errorField{Message: "too many logins (>1000)"}
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	return nil
+}
+
+// createQuizParticipants invites participants to quiz.
+//
+// Accounts that already participate in quiz are skipped, so bulk
+// invitation can be safely repeated.
+func (v *View) createQuizParticipants(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	var form createQuizParticipantsForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	var role models.QuizParticipantRole
+	if err := form.Update(&role); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	var users []models.User
+	var missing []string
+	for _, login := range form.GetLogins() {
+		user, err := v.core.Users.GetByLogin(login)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				c.Logger().Error(err)
+				return err
+			}
+			missing = append(missing, login)
+			continue
+		}
+		users = append(users, user)
+	}
+	if len(missing) > 0 {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "passed invalid fields to form",
+			InvalidFields: errorFields{
+				"logins": errorField{
+					Message: fmt.Sprintf(
+						"users not found: %s", strings.Join(missing, ", "),
+					),
+				},
+			},
+		})
+	}
+	var participants []models.QuizParticipant
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		if err := v.core.QuizParticipants.Sync(ctx); err != nil {
+			return err
+		}
+		used := map[int64]struct{}{}
+		for _, user := range users {
+			if _, ok := used[user.AccountID]; ok {
+				continue
+			}
+			used[user.AccountID] = struct{}{}
+			if _, err := v.core.QuizParticipants.GetByQuizAccount(
+				quiz.ID, user.AccountID,
+			); err != sql.ErrNoRows {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			participant := models.QuizParticipant{
+				QuizID:    quiz.ID,
+				AccountID: user.AccountID,
+				Role:      role,
+			}
+			if err := v.core.QuizParticipants.Create(
+				ctx, &participant,
+			); err != nil {
+				return err
+			}
+			participants = append(participants, participant)
+		}
+		return nil
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizParticipants{Participants: []QuizParticipant{}}
+	for _, participant := range participants {
+		resp.Participants = append(
+			resp.Participants,
+			v.makeQuizParticipant(participant, permissions),
+		)
+	}
+	sort.Sort(quizParticipantSorter(resp.Participants))
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (v *View) deleteQuizParticipant(c echo.Context) error {
+	participant, ok := c.Get(quizParticipantKey).(models.QuizParticipant)
+	if !ok {
+		c.Logger().Error("participant not extracted")
+		return fmt.Errorf("participant not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	if err := v.core.QuizParticipants.Delete(
+		getContext(c), participant.ID,
+	); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(
+		http.StatusOK, v.makeQuizParticipant(participant, permissions),
+	)
+}
+
+func (v *View) extractQuizParticipant(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("participant"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid participant ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		quiz, ok := c.Get(quizKey).(models.Quiz)
+		if !ok {
+			c.Logger().Error("quiz not extracted")
+			return fmt.Errorf("quiz not extracted")
+		}
+		participant, err := v.core.QuizParticipants.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.QuizParticipants.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			participant, err = v.core.QuizParticipants.Get(id)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("participant %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		if participant.QuizID != quiz.ID {
+			resp := errorResponse{
+				Message: fmt.Sprintf("participant %d not found", id),
+			}
+			return c.JSON(http.StatusNotFound, resp)
+		}
+		c.Set(quizParticipantKey, participant)
+		return next(c)
+	}
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuizParticipantSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketCreateQuiz(updateQuizForm{
+		Title:    getPtr("Quiz"),
+		Access:   getPtr("unknown"),
+		Sections: &[]QuizSection{{Points: 1, Problems: []int64{problem.ID}}},
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:    getPtr("Quiz"),
+		Access:   getPtr("invite"),
+		Sections: &[]QuizSection{{Points: 1, Problems: []int64{problem.ID}}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(quiz)
+	clients := map[string]*testClient{}
+	for _, login := range []string{"first", "second", "third"} {
+		testCreateUser(t, login, "qwerty123")
+		client := newTestClient(testSrv.URL + "/api")
+		if _, err := client.Login(login, "qwerty123"); err != nil {
+			t.Fatal("Error:", err)
+		}
+		clients[login] = client
+	}
+	guest := newTestClient(testSrv.URL + "/api")
+	if _, err := clients["first"].ObserveQuiz(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := clients["first"].CreateQuizAttempt(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketCreateQuizParticipants(
+		quiz.ID, createQuizParticipantsForm{
+			Logins: []string{"first", "unknown"},
+		},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if participants, err := testSocketCreateQuizParticipants(
+		quiz.ID, createQuizParticipantsForm{
+			Logins: []string{"first", "second", "first"},
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(participants)
+	}
+	testSyncManagers(t)
+	// Existing participants should be skipped.
+	if participants, err := testSocketCreateQuizParticipants(
+		quiz.ID, createQuizParticipantsForm{
+			Login:  "third",
+			Logins: []string{"first"},
+			Role:   "observer",
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(participants)
+	}
+	testSyncManagers(t)
+	if _, err := clients["first"].ObserveQuiz(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := clients["first"].CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := clients["third"].CreateQuizAttempt(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := clients["third"].ObserveQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := clients["first"].ObserveQuizParticipants(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	participants, err := clients["third"].ObserveQuizParticipants(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(participants)
+	for _, participant := range participants.Participants {
+		if participant.User == nil || participant.User.Login != "second" {
+			continue
+		}
+		if _, err := testSocketDeleteQuizParticipant(
+			quiz.ID, participant.ID,
+		); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	testSyncManagers(t)
+	if _, err := clients["second"].CreateQuizAttempt(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketUpdateQuiz(quiz.ID, updateQuizForm{
+		Access: getPtr("registered"),
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := guest.ObserveQuiz(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := clients["second"].CreateQuizAttempt(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+}
+
+func (c *testClient) ObserveQuiz(quiz int64) (Quiz, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d", quiz), nil,
+	)
+	if err != nil {
+		return Quiz{}, err
+	}
+	var respData Quiz
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ObserveQuizParticipants(
+	quiz int64,
+) (QuizParticipants, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/participants", quiz), nil,
+	)
+	if err != nil {
+		return QuizParticipants{}, err
+	}
+	var respData QuizParticipants
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func testSocketCreateQuizParticipants(
+	quiz int64, form createQuizParticipantsForm,
+) (QuizParticipants, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return QuizParticipants{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPost,
+		fmt.Sprintf("/socket/v0/quizzes/%d/participants", quiz),
+		bytes.NewReader(data),
+	)
+	var resp QuizParticipants
+	err = doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketDeleteQuizParticipant(
+	quiz, participant int64,
+) (QuizParticipant, error) {
+	req := httptest.NewRequest(
+		http.MethodDelete,
+		fmt.Sprintf("/socket/v0/quizzes/%d/participants/%d", quiz, participant),
+		nil,
+	)
+	var resp QuizParticipant
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
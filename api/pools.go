@@ -0,0 +1,544 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// Pool represents pool of problems.
+type Pool struct {
+	// ID contains pool ID.
+	ID int64 `json:"id"`
+	// Name contains pool name.
+	Name string `json:"name"`
+	// Description contains pool description.
+	Description string `json:"description,omitempty"`
+}
+
+type poolSorter []Pool
+
+func (v poolSorter) Len() int {
+	return len(v)
+}
+
+func (v poolSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v poolSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// Pools represents pools response.
+type Pools struct {
+	Pools []Pool `json:"pools"`
+}
+
+// registerPoolHandlers registers handlers for pool management.
+func (v *View) registerPoolHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/pools", v.observePools,
+		v.extractAuth(v.sessionAuth, v.guestAuth),
+		v.requirePermission(models.ObservePoolsRole),
+	)
+	g.POST(
+		"/v0/pools", v.createPool,
+		v.extractAuth(v.sessionAuth),
+		v.requirePermission(models.CreatePoolRole),
+	)
+	g.GET(
+		"/v0/pools/:pool", v.observePool,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractPool,
+		v.requirePermission(models.ObservePoolRole),
+	)
+	g.PATCH(
+		"/v0/pools/:pool", v.updatePool,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(models.UpdatePoolRole),
+	)
+	g.DELETE(
+		"/v0/pools/:pool", v.deletePool,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(models.DeletePoolRole),
+	)
+	g.GET(
+		"/v0/pools/:pool/problems", v.observePoolProblems,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractPool,
+		v.requirePermission(models.ObservePoolProblemsRole),
+	)
+	g.PATCH(
+		"/v0/pools/:pool/problems", v.updatePoolProblems,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(models.UpdatePoolProblemsRole),
+	)
+	g.POST(
+		"/v0/pools/:pool/problems/:problem", v.createPoolProblem,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(models.CreatePoolProblemRole),
+	)
+	g.DELETE(
+		"/v0/pools/:pool/problems/:problem", v.deletePoolProblem,
+		v.extractAuth(v.sessionAuth), v.extractPool,
+		v.requirePermission(models.DeletePoolProblemRole),
+	)
+}
+
+// registerSocketPoolHandlers registers socket handlers for pool
+// management.
+func (v *View) registerSocketPoolHandlers(g *echo.Group) {
+	g.GET("/v0/pools", v.observePools)
+	g.POST("/v0/pools", v.createPool)
+	g.GET("/v0/pools/:pool", v.observePool, v.extractPool)
+	g.PATCH("/v0/pools/:pool", v.updatePool, v.extractPool)
+	g.DELETE("/v0/pools/:pool", v.deletePool, v.extractPool)
+	g.GET(
+		"/v0/pools/:pool/problems", v.observePoolProblems,
+		v.extractPool,
+	)
+	g.PATCH(
+		"/v0/pools/:pool/problems", v.updatePoolProblems,
+		v.extractPool,
+	)
+	g.POST(
+		"/v0/pools/:pool/problems/:problem", v.createPoolProblem,
+		v.extractPool,
+	)
+	g.DELETE(
+		"/v0/pools/:pool/problems/:problem", v.deletePoolProblem,
+		v.extractPool,
+	)
+}
+
+func makePool(pool models.Pool) Pool {
+	return Pool{
+		ID:          pool.ID,
+		Name:        pool.Name,
+		Description: pool.Description,
+	}
+}
+
+func (v *View) observePools(c echo.Context) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	pools, err := v.core.Pools.All()
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := Pools{Pools: []Pool{}}
+	for _, pool := range pools {
+		permissions := v.getPoolPermissions(accountCtx, pool)
+		if permissions.HasPermission(models.ObservePoolRole) {
+			resp.Pools = append(resp.Pools, makePool(pool))
+		}
+	}
+	sort.Sort(poolSorter(resp.Pools))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observePool(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	return c.JSON(http.StatusOK, makePool(pool))
+}
+
+// updatePoolForm represents form for creating and updating pool.
+type updatePoolForm struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+func (f updatePoolForm) Update(pool *models.Pool) *errorResponse {
+	if f.Name != nil {
+		pool.Name = *f.Name
+	}
+	if f.Description != nil {
+		pool.Description = *f.Description
+	}
+	errors := errorFields{}
+	if len(pool.Name) == 0 {
+		errors["name"] = errorField{Message: "name should not be empty"}
+	} else if len(pool.Name) > 128 {
+		errors["name"] = errorField{Message: "name too long (>128)"}
+	}
+	if len(pool.Description) > 4096 {
+		errors["description"] = errorField{
+			Message: "description too long (>4096)",
+		}
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	return nil
+}
+
+func (v *View) createPool(c echo.Context) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	var form updatePoolForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	var pool models.Pool
+	if err := form.Update(&pool); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if account := accountCtx.Account; account != nil {
+		pool.OwnerID = models.NInt64(account.ID)
+	}
+	if err := v.core.Pools.Create(getContext(c), &pool); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusCreated, makePool(pool))
+}
+
+func (v *View) updatePool(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	var form updatePoolForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	if err := form.Update(&pool); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if err := v.core.Pools.Update(getContext(c), pool); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, makePool(pool))
+}
+
+func (v *View) deletePool(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	problems, err := v.core.PoolProblems.FindByPool(pool.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		for _, problem := range problems {
+			if err := v.core.PoolProblems.Delete(ctx, problem.ID); err != nil {
+				return err
+			}
+		}
+		return v.core.Pools.Delete(ctx, pool.ID)
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, makePool(pool))
+}
+
+// makePoolProblems returns problems of pool in pool order.
+func (v *View) makePoolProblems(
+	c echo.Context, poolProblems []models.PoolProblem,
+) Problems {
+	resp := Problems{Problems: []Problem{}}
+	for _, poolProblem := range poolProblems {
+		problem, err := v.core.Problems.Get(poolProblem.ProblemID)
+		if err != nil {
+			c.Logger().Warnf("Problem %v not found", poolProblem.ProblemID)
+			continue
+		}
+		resp.Problems = append(resp.Problems, Problem{
+			ID:    problem.ID,
+			Kind:  problem.Kind,
+			Title: problem.Title,
+		})
+	}
+	return resp
+}
+
+func (v *View) observePoolProblems(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	problems, err := v.core.PoolProblems.FindByPool(pool.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, v.makePoolProblems(c, problems))
+}
+
+// updatePoolProblemsForm represents form for reordering pool problems.
+type updatePoolProblemsForm struct {
+	// Problems contains IDs of all pool problems in new order.
+	Problems []int64 `json:"problems"`
+}
+
+func (v *View) updatePoolProblems(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	var form updatePoolProblemsForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	problems, err := v.core.PoolProblems.FindByPool(pool.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	byProblem := map[int64]models.PoolProblem{}
+	for _, problem := range problems {
+		byProblem[problem.ProblemID] = problem
+	}
+	if len(form.Problems) != len(problems) {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "problems should contain all pool problems",
+		})
+	}
+	var updated []models.PoolProblem
+	for i, id := range form.Problems {
+		problem, ok := byProblem[id]
+		if !ok {
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: fmt.Sprintf(
+					"pool %d does not have problem %d", pool.ID, id,
+				),
+			})
+		}
+		delete(byProblem, id)
+		problem.Position = int64(i + 1)
+		updated = append(updated, problem)
+	}
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		for i, problem := range updated {
+			if problem.Position == problems[i].Position &&
+				problem.ID == problems[i].ID {
+				continue
+			}
+			if err := v.core.PoolProblems.Update(ctx, problem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, v.makePoolProblems(c, updated))
+}
+
+func (v *View) createPoolProblem(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	problem, err := v.getProblemByParam(c)
+	if err != nil {
+		return err
+	}
+	// Problem with answer is copied to quizzes using pool, so only
+	// problems visible to account can be added.
+	permissions := v.getProblemPermissions(accountCtx, problem)
+	if !permissions.HasPermission(models.ObserveProblemRole) {
+		return c.JSON(http.StatusForbidden, errorResponse{
+			Message: fmt.Sprintf(
+				"account missing permissions for problem %d", problem.ID,
+			),
+			MissingPermissions: []string{models.ObserveProblemRole},
+		})
+	}
+	problems, err := v.core.PoolProblems.FindByPool(pool.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	position := int64(1)
+	for _, poolProblem := range problems {
+		if poolProblem.ProblemID == problem.ID {
+			return c.JSON(http.StatusBadRequest, errorResponse{
+				Message: fmt.Sprintf(
+					"pool %d already has problem %d", pool.ID, problem.ID,
+				),
+			})
+		}
+		if poolProblem.Position >= position {
+			position = poolProblem.Position + 1
+		}
+	}
+	poolProblem := models.PoolProblem{
+		PoolID:    pool.ID,
+		ProblemID: problem.ID,
+		Position:  position,
+	}
+	if err := v.core.PoolProblems.Create(getContext(c), &poolProblem); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	problems = append(problems, poolProblem)
+	return c.JSON(http.StatusCreated, v.makePoolProblems(c, problems))
+}
+
+func (v *View) deletePoolProblem(c echo.Context) error {
+	pool, ok := c.Get(poolKey).(models.Pool)
+	if !ok {
+		c.Logger().Error("pool not extracted")
+		return fmt.Errorf("pool not extracted")
+	}
+	id, err := strconv.ParseInt(c.Param("problem"), 10, 64)
+	if err != nil {
+		c.Logger().Warn(err)
+		resp := errorResponse{Message: "invalid problem ID"}
+		return c.JSON(http.StatusBadRequest, resp)
+	}
+	problems, err := v.core.PoolProblems.FindByPool(pool.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	pos := -1
+	for i, problem := range problems {
+		if problem.ProblemID == id {
+			pos = i
+			break
+		}
+	}
+	if pos == -1 {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: fmt.Sprintf(
+				"pool %d does not have problem %d", pool.ID, id,
+			),
+		})
+	}
+	if err := v.core.PoolProblems.Delete(getContext(c), problems[pos].ID); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	problems = append(problems[:pos], problems[pos+1:]...)
+	return c.JSON(http.StatusOK, v.makePoolProblems(c, problems))
+}
+
+// getProblemByParam returns problem specified by "problem" parameter.
+//
+// If problem can not be returned then error response is written.
+func (v *View) getProblemByParam(c echo.Context) (models.Problem, error) {
+	id, err := strconv.ParseInt(c.Param("problem"), 10, 64)
+	if err != nil {
+		c.Logger().Warn(err)
+		resp := errorResponse{
+			Code:    http.StatusBadRequest,
+			Message: "invalid problem ID",
+		}
+		return models.Problem{}, resp
+	}
+	problem, err := v.core.Problems.Get(id)
+	if err == sql.ErrNoRows {
+		if err := v.core.Problems.Sync(getContext(c)); err != nil {
+			c.Logger().Error(err)
+			return models.Problem{}, err
+		}
+		problem, err = v.core.Problems.Get(id)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			resp := errorResponse{
+				Code:    http.StatusNotFound,
+				Message: fmt.Sprintf("problem %d not found", id),
+			}
+			return models.Problem{}, resp
+		}
+		c.Logger().Error(err)
+		return models.Problem{}, err
+	}
+	return problem, nil
+}
+
+func (v *View) extractPool(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("pool"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid pool ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+		if !ok {
+			c.Logger().Error("auth not extracted")
+			return fmt.Errorf("auth not extracted")
+		}
+		pool, err := v.core.Pools.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.Pools.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			pool, err = v.core.Pools.Get(id)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("pool %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		c.Set(poolKey, pool)
+		c.Set(permissionCtxKey, v.getPoolPermissions(accountCtx, pool))
+		return next(c)
+	}
+}
+
+func (v *View) getPoolPermissions(
+	ctx *managers.AccountContext, pool models.Pool,
+) managers.PermissionSet {
+	permissions := ctx.Permissions.Clone()
+	if account := ctx.Account; account != nil &&
+		pool.OwnerID != 0 && account.ID == int64(pool.OwnerID) {
+		permissions[models.ObservePoolRole] = struct{}{}
+		permissions[models.UpdatePoolRole] = struct{}{}
+		permissions[models.DeletePoolRole] = struct{}{}
+		permissions[models.ObservePoolProblemsRole] = struct{}{}
+		permissions[models.CreatePoolProblemRole] = struct{}{}
+		permissions[models.UpdatePoolProblemsRole] = struct{}{}
+		permissions[models.DeletePoolProblemRole] = struct{}{}
+	}
+	return permissions
+}
@@ -0,0 +1,307 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func TestPoolSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	pool, err := testSocketCreatePool(updatePoolForm{
+		Name:        getPtr("Geography"),
+		Description: getPtr("Questions about capitals"),
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(pool)
+	if _, err := testSocketCreatePool(updatePoolForm{
+		Name: getPtr(""),
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	var problems []Problem
+	for _, title := range []string{"France", "Italy", "Spain"} {
+		problem, err := testSocketCreateProblem(updateProblemForm{
+			Kind:      getPtr("text"),
+			Title:     getPtr(title),
+			Statement: getPtr("What is the capital of " + title + "?"),
+			Answer:    &ProblemAnswer{Texts: []string{"Capital"}},
+		})
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		problems = append(problems, problem)
+	}
+	for _, problem := range problems {
+		if _, err := testSocketCreatePoolProblem(
+			pool.ID, problem.ID,
+		); err != nil {
+			t.Fatal("Error:", err)
+		}
+		testSyncManagers(t)
+	}
+	if _, err := testSocketCreatePoolProblem(
+		pool.ID, problems[0].ID,
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketCreatePoolProblem(pool.ID, 100); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	testSyncManagers(t)
+	if resp, err := testSocketObservePoolProblems(pool.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	if _, err := testSocketUpdatePoolProblems(
+		pool.ID, updatePoolProblemsForm{
+			Problems: []int64{problems[0].ID, problems[1].ID},
+		},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if resp, err := testSocketUpdatePoolProblems(
+		pool.ID, updatePoolProblemsForm{
+			Problems: []int64{
+				problems[2].ID, problems[0].ID, problems[1].ID,
+			},
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	testSyncManagers(t)
+	if resp, err := testSocketDeletePoolProblem(
+		pool.ID, problems[0].ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	testSyncManagers(t)
+	if resp, err := testSocketObservePoolProblems(pool.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	if updated, err := testSocketUpdatePool(pool.ID, updatePoolForm{
+		Name: getPtr("Capitals"),
+	}); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(updated)
+	}
+	testSyncManagers(t)
+	if pools, err := testSocketObservePools(); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(pools)
+	}
+	if deleted, err := testSocketDeletePool(pool.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(deleted)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketObservePool(pool.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+}
+
+func TestPoolProblemPermissions(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	pool, err := testSocketCreatePool(updatePoolForm{
+		Name: getPtr("Geography"),
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("text"),
+		Title:     getPtr("France"),
+		Statement: getPtr("What is the capital of France?"),
+		Answer:    &ProblemAnswer{Texts: []string{"Paris"}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCreateUser(t, "author", "qwerty123")
+	if err := testSocketCreateUserRoles(
+		"author", models.CreatePoolProblemRole,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	client := newTestClient(testSrv.URL + "/api")
+	if _, err := client.Login("author", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	// Problems that are not visible to account can not be added.
+	if _, err := client.CreatePoolProblem(pool.ID, problem.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if err := testSocketCreateUserRoles(
+		"author", models.ObserveProblemRole,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if resp, err := client.CreatePoolProblem(pool.ID, problem.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	testSyncManagers(t)
+	// Deleted problems are removed from pools.
+	if _, err := testSocketDeleteProblem(problem.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if resp, err := testSocketObservePoolProblems(pool.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(resp.Problems) != 0 {
+		t.Fatalf("Expected no problems, got %v", resp.Problems)
+	}
+	if poolProblems, err := testView.core.PoolProblems.FindByProblem(
+		problem.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(poolProblems) != 0 {
+		t.Fatalf("Expected no pool problems, got %v", poolProblems)
+	}
+}
+
+func (c *testClient) CreatePoolProblem(id, problemID int64) (Problems, error) {
+	req, err := http.NewRequest(
+		http.MethodPost, c.getURL("/v0/pools/%d/problems/%d", id, problemID),
+		nil,
+	)
+	if err != nil {
+		return Problems{}, err
+	}
+	var respData Problems
+	err = c.doRequest(req, http.StatusCreated, &respData)
+	return respData, err
+}
+
+func testSocketCreatePool(form updatePoolForm) (Pool, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Pool{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPost, "/socket/v0/pools", bytes.NewReader(data),
+	)
+	var resp Pool
+	err = doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketObservePools() (Pools, error) {
+	req := httptest.NewRequest(http.MethodGet, "/socket/v0/pools", nil)
+	var resp Pools
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObservePool(id int64) (Pool, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/pools/%d", id), nil,
+	)
+	var resp Pool
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketUpdatePool(id int64, form updatePoolForm) (Pool, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Pool{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPatch, fmt.Sprintf("/socket/v0/pools/%d", id),
+		bytes.NewReader(data),
+	)
+	var resp Pool
+	err = doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketDeletePool(id int64) (Pool, error) {
+	req := httptest.NewRequest(
+		http.MethodDelete, fmt.Sprintf("/socket/v0/pools/%d", id), nil,
+	)
+	var resp Pool
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObservePoolProblems(id int64) (Problems, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/pools/%d/problems", id), nil,
+	)
+	var resp Problems
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketUpdatePoolProblems(
+	id int64, form updatePoolProblemsForm,
+) (Problems, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Problems{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPatch, fmt.Sprintf("/socket/v0/pools/%d/problems", id),
+		bytes.NewReader(data),
+	)
+	var resp Problems
+	err = doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketCreatePoolProblem(id, problemID int64) (Problems, error) {
+	req := httptest.NewRequest(
+		http.MethodPost,
+		fmt.Sprintf("/socket/v0/pools/%d/problems/%d", id, problemID),
+		nil,
+	)
+	var resp Problems
+	err := doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketDeletePoolProblem(id, problemID int64) (Problems, error) {
+	req := httptest.NewRequest(
+		http.MethodDelete,
+		fmt.Sprintf("/socket/v0/pools/%d/problems/%d", id, problemID),
+		nil,
+	)
+	var resp Problems
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
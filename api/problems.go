@@ -0,0 +1,800 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/expr"
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// ProblemOption represents option of choice problem.
+type ProblemOption struct {
+	// Text contains option text.
+	Text string `json:"text"`
+}
+
+// ProblemAnswer represents answer key of problem.
+type ProblemAnswer struct {
+	// Options contains indexes of correct options.
+	Options []int `json:"options,omitempty"`
+	// Texts contains accepted text answers.
+	Texts []string `json:"texts,omitempty"`
+	// Value contains correct numeric answer.
+	Value float64 `json:"value,omitempty"`
+	// Tolerance contains allowed absolute error of numeric answer.
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// Formula contains formula of numeric answer over variables.
+	Formula string `json:"formula,omitempty"`
+}
+
+// ProblemVariable represents variable of parameterized problem.
+type ProblemVariable struct {
+	// Name contains variable name.
+	Name string `json:"name"`
+	// Min contains minimal value of variable.
+	Min float64 `json:"min"`
+	// Max contains maximal value of variable.
+	Max float64 `json:"max"`
+	// Step contains difference between adjacent values.
+	Step float64 `json:"step,omitempty"`
+}
+
+// Problem represents problem.
+type Problem struct {
+	// ID contains problem ID.
+	ID int64 `json:"id"`
+	// Kind contains problem kind.
+	Kind models.ProblemKind `json:"kind"`
+	// Title contains problem title.
+	Title string `json:"title"`
+	// Statement contains problem statement.
+	Statement string `json:"statement,omitempty"`
+	// Options contains options of choice problem.
+	Options []ProblemOption `json:"options,omitempty"`
+	// Prompts contains prompts of matching problem.
+	Prompts []ProblemOption `json:"prompts,omitempty"`
+	// Variables contains variables of parameterized problem.
+	Variables []ProblemVariable `json:"variables,omitempty"`
+	// Answer contains answer key of problem.
+	Answer *ProblemAnswer `json:"answer,omitempty"`
+}
+
+type problemSorter []Problem
+
+func (v problemSorter) Len() int {
+	return len(v)
+}
+
+func (v problemSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v problemSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// Problems represents problems response.
+type Problems struct {
+	Problems []Problem `json:"problems"`
+}
+
+// registerProblemHandlers registers handlers for problem management.
+func (v *View) registerProblemHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/problems", v.observeProblems,
+		v.extractAuth(v.sessionAuth, v.guestAuth),
+		v.requirePermission(models.ObserveProblemsRole),
+	)
+	g.POST(
+		"/v0/problems", v.createProblem,
+		v.extractAuth(v.sessionAuth),
+		v.requirePermission(models.CreateProblemRole),
+	)
+	g.GET(
+		"/v0/problems/:problem", v.observeProblem,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractProblem,
+		v.requirePermission(models.ObserveProblemRole),
+	)
+	g.PATCH(
+		"/v0/problems/:problem", v.updateProblem,
+		v.extractAuth(v.sessionAuth), v.extractProblem,
+		v.requirePermission(models.UpdateProblemRole),
+	)
+	g.DELETE(
+		"/v0/problems/:problem", v.deleteProblem,
+		v.extractAuth(v.sessionAuth), v.extractProblem,
+		v.requirePermission(models.DeleteProblemRole),
+	)
+}
+
+// registerSocketProblemHandlers registers socket handlers for problem
+// management.
+func (v *View) registerSocketProblemHandlers(g *echo.Group) {
+	g.GET("/v0/problems", v.observeProblems)
+	g.POST("/v0/problems", v.createProblem)
+	g.GET(
+		"/v0/problems/:problem", v.observeProblem,
+		v.extractProblem,
+	)
+	g.PATCH(
+		"/v0/problems/:problem", v.updateProblem,
+		v.extractProblem,
+	)
+	g.DELETE(
+		"/v0/problems/:problem", v.deleteProblem,
+		v.extractProblem,
+	)
+}
+
+func makeProblem(
+	problem models.Problem, permissions managers.Permissions,
+) (Problem, error) {
+	resp := Problem{
+		ID:        problem.ID,
+		Kind:      problem.Kind,
+		Title:     problem.Title,
+		Statement: problem.Statement,
+	}
+	var config models.ProblemConfig
+	if err := problem.ScanConfig(&config); err != nil {
+		return Problem{}, err
+	}
+	for _, option := range config.Options {
+		resp.Options = append(resp.Options, ProblemOption{
+			Text: option.Text,
+		})
+	}
+	for _, prompt := range config.Prompts {
+		resp.Prompts = append(resp.Prompts, ProblemOption{
+			Text: prompt.Text,
+		})
+	}
+	for _, variable := range config.Variables {
+		resp.Variables = append(resp.Variables, ProblemVariable{
+			Name: variable.Name,
+			Min:  variable.Min,
+			Max:  variable.Max,
+			Step: variable.Step,
+		})
+	}
+	if permissions.HasPermission(models.UpdateProblemRole) {
+		var answer models.ProblemAnswer
+		if err := problem.ScanAnswer(&answer); err != nil {
+			return Problem{}, err
+		}
+		resp.Answer = &ProblemAnswer{
+			Options:   answer.Options,
+			Texts:     answer.Texts,
+			Value:     answer.Value,
+			Tolerance: answer.Tolerance,
+			Formula:   answer.Formula,
+		}
+	}
+	return resp, nil
+}
+
+type problemFilter struct {
+	Query string `query:"q"`
+}
+
+func (f problemFilter) Filter(problem models.Problem) bool {
+	if len(f.Query) > 0 {
+		switch {
+		case strings.HasPrefix(fmt.Sprint(problem.ID), f.Query):
+		case strings.Contains(problem.Title, f.Query):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (v *View) observeProblems(c echo.Context) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	var filter problemFilter
+	if err := c.Bind(&filter); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	problems, err := v.core.Problems.All()
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := Problems{Problems: []Problem{}}
+	for _, problem := range problems {
+		if !filter.Filter(problem) {
+			continue
+		}
+		permissions := v.getProblemPermissions(accountCtx, problem)
+		if !permissions.HasPermission(models.ObserveProblemRole) {
+			continue
+		}
+		problemResp, err := makeProblem(problem, permissions)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		// Statements are too heavy for list of problems.
+		problemResp.Statement = ""
+		problemResp.Options = nil
+		problemResp.Answer = nil
+		resp.Problems = append(resp.Problems, problemResp)
+	}
+	sort.Sort(problemSorter(resp.Problems))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observeProblem(c echo.Context) error {
+	problem, ok := c.Get(problemKey).(models.Problem)
+	if !ok {
+		c.Logger().Error("problem not extracted")
+		return fmt.Errorf("problem not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	resp, err := makeProblem(problem, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// updateProblemForm represents form for creating and updating problem.
+type updateProblemForm struct {
+	Kind      *string            `json:"kind"`
+	Title     *string            `json:"title"`
+	Statement *string            `json:"statement"`
+	Options   *[]ProblemOption   `json:"options"`
+	Prompts   *[]ProblemOption   `json:"prompts"`
+	Variables *[]ProblemVariable `json:"variables"`
+	Answer    *ProblemAnswer     `json:"answer"`
+}
+
+func validateProblemTitle(errors errorFields, title string) {
+	if len(title) == 0 {
+		errors["title"] = errorField{Message: "title should not be empty"}
+	} else if len(title) > 128 {
+		errors["title"] = errorField{Message: "title too long (>128)"}
+	}
+}
+
+func validateProblemStatement(errors errorFields, statement string) {
+	if len(statement) == 0 {
+		errors["statement"] = errorField{Message: "statement should not be empty"}
+	} else if len(statement) > 65536 {
+		errors["statement"] = errorField{Message: "statement too long (>65536)"}
+	}
+}
+
+func validateProblemOptions(
+	errors errorFields, kind models.ProblemKind,
+	options []models.ProblemOption,
+) {
+	if !kind.HasOptions() {
+		if len(options) > 0 {
+			errors["options"] = errorField{
+				Message: fmt.Sprintf("%s problem should not have options", kind),
+			}
+		}
+		return
+	}
+	if len(options) < 2 {
+		errors["options"] = errorField{Message: "too few options (<2)"}
+		return
+	} else if len(options) > 32 {
+		errors["options"] = errorField{Message: "too many options (>32)"}
+		return
+	}
+	for i, option := range options {
+		if len(option.Text) == 0 {
+			errors["options"] = errorField{
+				Message: fmt.Sprintf("option %d should not be empty", i),
+			}
+			return
+		} else if len(option.Text) > 4096 {
+			errors["options"] = errorField{
+				Message: fmt.Sprintf("option %d too long (>4096)", i),
+			}
+			return
+		}
+	}
+}
+
+func validateProblemPrompts(
+	errors errorFields, kind models.ProblemKind,
+	prompts []models.ProblemOption,
+) {
+	if kind != models.MatchingProblem {
+		if len(prompts) > 0 {
+			errors["prompts"] = errorField{
+				Message: fmt.Sprintf("%s problem should not have prompts", kind),
+			}
+		}
+		return
+	}
+	if len(prompts) == 0 {
+		errors["prompts"] = errorField{Message: "too few prompts (<1)"}
+		return
+	} else if len(prompts) > 32 {
+		errors["prompts"] = errorField{Message: "too many prompts (>32)"}
+		return
+	}
+	for i, prompt := range prompts {
+		if len(prompt.Text) == 0 {
+			errors["prompts"] = errorField{
+				Message: fmt.Sprintf("prompt %d should not be empty", i),
+			}
+			return
+		} else if len(prompt.Text) > 4096 {
+			errors["prompts"] = errorField{
+				Message: fmt.Sprintf("prompt %d too long (>4096)", i),
+			}
+			return
+		}
+	}
+}
+
+// maxProblemVariables contains maximal amount of problem variables.
+const maxProblemVariables = 16
+
+func validateProblemVariables(
+	errors errorFields, problem models.Problem, config models.ProblemConfig,
+) {
+	if len(config.Variables) > maxProblemVariables {
+		errors["variables"] = errorField{
+			Message: fmt.Sprintf("too many variables (>%d)", maxProblemVariables),
+		}
+		return
+	}
+	names := map[string]struct{}{}
+	for _, variable := range config.Variables {
+		if !expr.IsIdent(variable.Name) || expr.IsReserved(variable.Name) {
+			errors["variables"] = errorField{
+				Message: fmt.Sprintf("invalid variable name %q", variable.Name),
+			}
+			return
+		}
+		if _, ok := names[variable.Name]; ok {
+			errors["variables"] = errorField{
+				Message: fmt.Sprintf("variable %q is duplicated", variable.Name),
+			}
+			return
+		}
+		names[variable.Name] = struct{}{}
+		if !isFinite(variable.Min) || !isFinite(variable.Max) ||
+			!isFinite(variable.Step) || variable.Step < 0 {
+			errors["variables"] = errorField{
+				Message: fmt.Sprintf("variable %q has invalid range", variable.Name),
+			}
+			return
+		}
+		if count := variable.Count(); count < 1 || count > math.MaxInt32 {
+			errors["variables"] = errorField{
+				Message: fmt.Sprintf("variable %q has invalid range", variable.Name),
+			}
+			return
+		}
+	}
+	// Placeholders are checked only for parameterized problems, so
+	// regular problems can contain text like {{name}}.
+	if len(config.Variables) == 0 {
+		return
+	}
+	texts := map[string][]string{
+		"title":     {problem.Title},
+		"statement": {problem.Statement},
+	}
+	for _, option := range config.Options {
+		texts["options"] = append(texts["options"], option.Text)
+	}
+	for _, prompt := range config.Prompts {
+		texts["prompts"] = append(texts["prompts"], prompt.Text)
+	}
+	for field, values := range texts {
+		for _, text := range values {
+			for _, name := range models.GetProblemPlaceholders(text) {
+				if _, ok := names[name]; !ok {
+					errors[field] = errorField{
+						Message: fmt.Sprintf("variable %q is not declared", name),
+					}
+				}
+			}
+		}
+	}
+}
+
+// validateProblemFormula checks that formula uses only declared
+// variables and can be evaluated for bounds of variables.
+func validateProblemFormula(
+	errors errorFields, variables []models.ProblemVariable,
+	answer models.ProblemAnswer,
+) {
+	formula, err := expr.Parse(answer.Formula)
+	if err != nil {
+		errors["answer"] = errorField{
+			Message: fmt.Sprintf("invalid formula: %v", err),
+		}
+		return
+	}
+	names := map[string]struct{}{}
+	for _, variable := range variables {
+		names[variable.Name] = struct{}{}
+	}
+	for _, name := range formula.Variables() {
+		if _, ok := names[name]; !ok {
+			errors["answer"] = errorField{
+				Message: fmt.Sprintf("variable %q is not declared", name),
+			}
+			return
+		}
+	}
+	minValues, maxValues := map[string]float64{}, map[string]float64{}
+	for _, variable := range variables {
+		minValues[variable.Name] = variable.Value(0)
+		maxValues[variable.Name] = variable.Value(variable.Count() - 1)
+	}
+	for _, values := range []map[string]float64{minValues, maxValues} {
+		if _, err := formula.Eval(values); err != nil {
+			errors["answer"] = errorField{
+				Message: fmt.Sprintf("unable to evaluate formula: %v", err),
+			}
+			return
+		}
+	}
+	if answer.Value != 0 {
+		errors["answer"] = errorField{
+			Message: "answer should not contain both value and formula",
+		}
+	} else if math.IsNaN(answer.Tolerance) || answer.Tolerance < 0 {
+		errors["answer"] = errorField{
+			Message: "answer tolerance should not be negative",
+		}
+	}
+}
+
+func isFinite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+func validateProblemAnswer(
+	errors errorFields, kind models.ProblemKind,
+	config models.ProblemConfig, answer models.ProblemAnswer,
+) {
+	if len(answer.Formula) > 0 && kind != models.NumericProblem {
+		errors["answer"] = errorField{
+			Message: fmt.Sprintf("%s problem should not have formula", kind),
+		}
+		return
+	}
+	options := config.Options
+	if kind.HasOptions() {
+		seen := map[int]struct{}{}
+		for _, option := range answer.Options {
+			if option < 0 || option >= len(options) {
+				errors["answer"] = errorField{
+					Message: fmt.Sprintf("option %d does not exist", option),
+				}
+				return
+			}
+			// Several prompts can be matched with the same option.
+			if _, ok := seen[option]; ok && kind != models.MatchingProblem {
+				errors["answer"] = errorField{
+					Message: fmt.Sprintf("option %d is duplicated", option),
+				}
+				return
+			}
+			seen[option] = struct{}{}
+		}
+	}
+	switch kind {
+	case models.SingleChoiceProblem:
+		if len(answer.Options) != 1 {
+			errors["answer"] = errorField{
+				Message: "answer should contain exactly one option",
+			}
+		}
+	case models.MultipleChoiceProblem:
+		if len(answer.Options) == 0 {
+			errors["answer"] = errorField{
+				Message: "answer should contain at least one option",
+			}
+		}
+	case models.MatchingProblem:
+		if len(answer.Options) != len(config.Prompts) {
+			errors["answer"] = errorField{
+				Message: "answer should contain option for every prompt",
+			}
+		}
+	case models.TextProblem:
+		if len(answer.Texts) == 0 {
+			errors["answer"] = errorField{
+				Message: "answer should contain at least one text",
+			}
+		}
+		for _, text := range answer.Texts {
+			if len(strings.TrimSpace(text)) == 0 {
+				errors["answer"] = errorField{
+					Message: "answer text should not be empty",
+				}
+			}
+		}
+	case models.EssayProblem:
+		// Essays are graded manually, so they do not have answer key.
+		if len(answer.Options) > 0 || len(answer.Texts) > 0 ||
+			answer.Value != 0 || answer.Tolerance != 0 {
+			errors["answer"] = errorField{
+				Message: "essay problem should not have answer",
+			}
+		}
+	case models.NumericProblem:
+		if len(answer.Formula) > 0 {
+			validateProblemFormula(errors, config.Variables, answer)
+		} else if math.IsNaN(answer.Value) || math.IsInf(answer.Value, 0) {
+			errors["answer"] = errorField{Message: "answer value is invalid"}
+		} else if math.IsNaN(answer.Tolerance) || answer.Tolerance < 0 {
+			errors["answer"] = errorField{
+				Message: "answer tolerance should not be negative",
+			}
+		}
+	}
+}
+
+func (f updateProblemForm) Update(problem *models.Problem) *errorResponse {
+	errors := errorFields{}
+	if f.Kind != nil {
+		if err := problem.Kind.UnmarshalText([]byte(*f.Kind)); err != nil {
+			errors["kind"] = errorField{Message: "kind is not supported"}
+		}
+	} else if problem.Kind == 0 {
+		errors["kind"] = errorField{Message: "kind should be specified"}
+	}
+	if f.Title != nil {
+		problem.Title = *f.Title
+	}
+	if f.Statement != nil {
+		problem.Statement = *f.Statement
+	}
+	var config models.ProblemConfig
+	if err := problem.ScanConfig(&config); err != nil {
+		return &errorResponse{Message: "unable to parse problem config"}
+	}
+	if f.Options != nil {
+		config.Options = nil
+		for _, option := range *f.Options {
+			config.Options = append(config.Options, models.ProblemOption{
+				Text: option.Text,
+			})
+		}
+	}
+	if f.Prompts != nil {
+		config.Prompts = nil
+		for _, prompt := range *f.Prompts {
+			config.Prompts = append(config.Prompts, models.ProblemOption{
+				Text: prompt.Text,
+			})
+		}
+	}
+	if f.Variables != nil {
+		config.Variables = nil
+		for _, variable := range *f.Variables {
+			config.Variables = append(config.Variables, models.ProblemVariable{
+				Name: variable.Name,
+				Min:  variable.Min,
+				Max:  variable.Max,
+				Step: variable.Step,
+			})
+		}
+	}
+	var answer models.ProblemAnswer
+	if err := problem.ScanAnswer(&answer); err != nil {
+		return &errorResponse{Message: "unable to parse problem answer"}
+	}
+	if f.Answer != nil {
+		answer = models.ProblemAnswer{
+			Options:   f.Answer.Options,
+			Texts:     f.Answer.Texts,
+			Value:     f.Answer.Value,
+			Tolerance: f.Answer.Tolerance,
+			Formula:   f.Answer.Formula,
+		}
+	}
+	validateProblemTitle(errors, problem.Title)
+	validateProblemStatement(errors, problem.Statement)
+	validateProblemVariables(errors, *problem, config)
+	if _, ok := errors["kind"]; !ok {
+		validateProblemOptions(errors, problem.Kind, config.Options)
+		validateProblemPrompts(errors, problem.Kind, config.Prompts)
+		_, invalidOptions := errors["options"]
+		_, invalidPrompts := errors["prompts"]
+		_, invalidVariables := errors["variables"]
+		if !invalidOptions && !invalidPrompts && !invalidVariables {
+			validateProblemAnswer(errors, problem.Kind, config, answer)
+		}
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	if err := problem.SetConfig(config); err != nil {
+		return &errorResponse{Message: "unable to save problem config"}
+	}
+	if err := problem.SetAnswer(answer); err != nil {
+		return &errorResponse{Message: "unable to save problem answer"}
+	}
+	return nil
+}
+
+func (v *View) createProblem(c echo.Context) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	var form updateProblemForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	var problem models.Problem
+	if err := form.Update(&problem); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if account := accountCtx.Account; account != nil {
+		problem.OwnerID = models.NInt64(account.ID)
+	}
+	if err := v.core.Problems.Create(getContext(c), &problem); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeProblem(problem, v.getProblemPermissions(accountCtx, problem))
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (v *View) updateProblem(c echo.Context) error {
+	problem, ok := c.Get(problemKey).(models.Problem)
+	if !ok {
+		c.Logger().Error("problem not extracted")
+		return fmt.Errorf("problem not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	var form updateProblemForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	if err := form.Update(&problem); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if err := v.core.Problems.Update(getContext(c), problem); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeProblem(problem, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) deleteProblem(c echo.Context) error {
+	problem, ok := c.Get(problemKey).(models.Problem)
+	if !ok {
+		c.Logger().Error("problem not extracted")
+		return fmt.Errorf("problem not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	// Problem is removed from pools, so pools do not refer to problem
+	// that does not exist.
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		if err := v.core.PoolProblems.Sync(ctx); err != nil {
+			return err
+		}
+		poolProblems, err := v.core.PoolProblems.FindByProblem(problem.ID)
+		if err != nil {
+			return err
+		}
+		for _, poolProblem := range poolProblems {
+			if err := v.core.PoolProblems.Delete(
+				ctx, poolProblem.ID,
+			); err != nil {
+				return err
+			}
+		}
+		return v.core.Problems.Delete(ctx, problem.ID)
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeProblem(problem, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) extractProblem(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("problem"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid problem ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+		if !ok {
+			c.Logger().Error("auth not extracted")
+			return fmt.Errorf("auth not extracted")
+		}
+		problem, err := v.core.Problems.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.Problems.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			problem, err = v.core.Problems.Get(id)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("problem %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		c.Set(problemKey, problem)
+		c.Set(permissionCtxKey, v.getProblemPermissions(accountCtx, problem))
+		return next(c)
+	}
+}
+
+func (v *View) getProblemPermissions(
+	ctx *managers.AccountContext, problem models.Problem,
+) managers.PermissionSet {
+	permissions := ctx.Permissions.Clone()
+	if account := ctx.Account; account != nil &&
+		problem.OwnerID != 0 && account.ID == int64(problem.OwnerID) {
+		permissions[models.ObserveProblemRole] = struct{}{}
+		permissions[models.UpdateProblemRole] = struct{}{}
+		permissions[models.DeleteProblemRole] = struct{}{}
+		permissions[models.ObserveProblemRevisionsRole] = struct{}{}
+		permissions[models.ObserveProblemStatisticsRole] = struct{}{}
+	}
+	return permissions
+}
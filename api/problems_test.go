@@ -0,0 +1,262 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func TestProblemSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	form := updateProblemForm{
+		Kind:      getPtr("single_choice"),
+		Title:     getPtr("Capital"),
+		Statement: getPtr("What is the capital of France?"),
+		Options: &[]ProblemOption{
+			{Text: "Berlin"}, {Text: "Paris"}, {Text: "Rome"},
+		},
+		Answer: &ProblemAnswer{Options: []int{1}},
+	}
+	problem, err := testSocketCreateProblem(form)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(problem)
+	if _, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("multiple_choice"),
+		Title:     getPtr(""),
+		Statement: getPtr("Empty"),
+		Options:   &[]ProblemOption{{Text: "One"}},
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("single_choice"),
+		Title:     getPtr("Invalid answer"),
+		Statement: getPtr("Statement"),
+		Options:   &[]ProblemOption{{Text: "One"}, {Text: "Two"}},
+		Answer:    &ProblemAnswer{Options: []int{0, 1}},
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("unknown"),
+		Title:     getPtr("Unknown"),
+		Statement: getPtr("Statement"),
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if observed, err := testSocketObserveProblem(problem.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(observed)
+	}
+	updated, err := testSocketUpdateProblem(problem.ID, updateProblemForm{
+		Kind:    getPtr("numeric"),
+		Title:   getPtr("Square root"),
+		Options: &[]ProblemOption{},
+		Answer:  &ProblemAnswer{Value: 1.4142, Tolerance: 0.001},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(updated)
+	testSyncManagers(t)
+	if problems, err := testSocketObserveProblems(); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(problems)
+	}
+	if deleted, err := testSocketDeleteProblem(problem.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(deleted)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketObserveProblem(problem.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+}
+
+func TestParameterizedProblemScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	variables := []ProblemVariable{
+		{Name: "a", Min: 2, Max: 9},
+		{Name: "b", Min: 0.5, Max: 2, Step: 0.5},
+	}
+	for _, form := range []updateProblemForm{
+		{
+			Statement: getPtr("Compute {{a}} * {{c}}"),
+			Variables: &variables,
+			Answer:    &ProblemAnswer{Formula: "a * b"},
+		},
+		{
+			Statement: getPtr("Compute {{a}} * {{b}}"),
+			Variables: &variables,
+			Answer:    &ProblemAnswer{Formula: "a * c"},
+		},
+		{
+			Statement: getPtr("Compute {{a}} / ({{b}} - 0.5)"),
+			Variables: &variables,
+			Answer:    &ProblemAnswer{Formula: "a / (b - 0.5)"},
+		},
+		{
+			Statement: getPtr("Compute {{a}} * {{b}}"),
+			Variables: &[]ProblemVariable{{Name: "sqrt", Min: 1, Max: 2}},
+			Answer:    &ProblemAnswer{Formula: "a * b"},
+		},
+		{
+			Statement: getPtr("Compute {{a}} * {{b}}"),
+			Variables: &variables,
+			Answer:    &ProblemAnswer{Formula: "a *"},
+		},
+	} {
+		form.Kind = getPtr("numeric")
+		form.Title = getPtr("Product")
+		if _, err := testSocketCreateProblem(form); err == nil {
+			t.Fatal("Expected error")
+		} else {
+			testCheck(err)
+		}
+	}
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Product"),
+		Statement: getPtr("Compute {{a}} * {{ b }}"),
+		Variables: &variables,
+		Answer:    &ProblemAnswer{Formula: "a * b", Tolerance: 0.001},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(problem)
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:    getPtr("Quiz"),
+		Sections: &[]QuizSection{{Points: 1, Problems: []int64{problem.ID}}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCreateUser(t, "test", "qwerty123")
+	client := newTestClient(testSrv.URL + "/api")
+	if _, err := client.Login("test", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := client.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	stored, err := testView.core.QuizAttempts.Get(attempt.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	var state models.QuizAttemptState
+	if err := stored.ScanState(&state); err != nil {
+		t.Fatal("Error:", err)
+	}
+	values := state.Problems[0].Values
+	if len(values) != 2 {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+	problems, err := client.ObserveQuizAttemptProblems(quiz.ID, attempt.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	statement := fmt.Sprintf(
+		"Compute %s * %s",
+		strconv.FormatFloat(values["a"], 'f', -1, 64),
+		strconv.FormatFloat(values["b"], 'f', -1, 64),
+	)
+	if problems.Problems[0].Statement != statement {
+		t.Fatalf(
+			"Expected statement %q, got %q",
+			statement, problems.Problems[0].Statement,
+		)
+	}
+	product := values["a"] * values["b"]
+	if _, err := client.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, problem.ID,
+		updateQuizAttemptAnswerForm{Value: &product},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if finished, err := client.FinishQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizAttempt(finished))
+	}
+}
+
+func testSocketCreateProblem(form updateProblemForm) (Problem, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Problem{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPost, "/socket/v0/problems", bytes.NewReader(data),
+	)
+	var resp Problem
+	err = doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketObserveProblems() (Problems, error) {
+	req := httptest.NewRequest(http.MethodGet, "/socket/v0/problems", nil)
+	var resp Problems
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveProblem(id int64) (Problem, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/problems/%d", id), nil,
+	)
+	var resp Problem
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketUpdateProblem(
+	id int64, form updateProblemForm,
+) (Problem, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Problem{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPatch, fmt.Sprintf("/socket/v0/problems/%d", id),
+		bytes.NewReader(data),
+	)
+	var resp Problem
+	err = doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketDeleteProblem(id int64) (Problem, error) {
+	req := httptest.NewRequest(
+		http.MethodDelete, fmt.Sprintf("/socket/v0/problems/%d", id), nil,
+	)
+	var resp Problem
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
@@ -0,0 +1,379 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+const (
+	// proctoringEventsBurst contains maximal amount of events that can
+	// be reported for attempt at once.
+	proctoringEventsBurst = 100
+	// proctoringEventsRate contains amount of events per second that
+	// can be reported for attempt after burst is exhausted.
+	proctoringEventsRate = 1.0
+	// maxProctoringEvents contains maximal amount of events in request.
+	maxProctoringEvents = 50
+	// maxProctoringEventData contains maximal size of event data.
+	maxProctoringEventData = 1024
+)
+
+// ProctoringEvent represents integrity event of attempt.
+type ProctoringEvent struct {
+	// ID contains ID of event.
+	ID int64 `json:"id"`
+	// Kind contains kind of event.
+	Kind models.ProctoringEventKind `json:"kind"`
+	// Time contains time when event was received by server.
+	Time int64 `json:"time"`
+	// ClientTime contains time when event occurred on client.
+	ClientTime int64 `json:"client_time,omitempty"`
+	// Data contains additional details of event.
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// ProctoringEvents represents proctoring events response.
+type ProctoringEvents struct {
+	Events []ProctoringEvent `json:"events"`
+}
+
+// ProctoringTimeline represents timeline of integrity events of
+// attempt.
+type ProctoringTimeline struct {
+	// AttemptID contains ID of attempt.
+	AttemptID int64 `json:"attempt_id"`
+	// AccountID contains ID of participant account.
+	AccountID int64 `json:"account_id"`
+	// User contains participant user.
+	User *User `json:"user,omitempty"`
+	// Counters contains amount of events of every kind.
+	Counters map[string]int `json:"counters"`
+	// Suspicious contains amount of events that indicate possible
+	// violation of exam rules.
+	//
+	// Reconnects are not counted, since they usually are caused by
+	// network problems.
+	Suspicious int `json:"suspicious"`
+	// Events contains events ordered by time of receiving.
+	Events []ProctoringEvent `json:"events"`
+}
+
+// ProctoringTimelines represents proctoring timelines response.
+type ProctoringTimelines struct {
+	Timelines []ProctoringTimeline `json:"timelines"`
+}
+
+// registerProctoringHandlers registers handlers for proctoring events.
+func (v *View) registerProctoringHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/proctoring", v.observeQuizProctoring,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizProctoringRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/attempts/:attempt/proctoring",
+		v.observeQuizAttemptProctoring,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.extractQuizAttempt,
+		v.requirePermission(models.ObserveQuizProctoringRole),
+	)
+	g.POST(
+		"/v0/quizzes/:quiz/attempts/:attempt/proctoring",
+		v.createProctoringEvents,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.extractQuizAttempt,
+		v.requirePermission(models.UpdateQuizAttemptRole),
+	)
+}
+
+// registerSocketProctoringHandlers registers socket handlers for
+// proctoring events.
+func (v *View) registerSocketProctoringHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/proctoring", v.observeQuizProctoring,
+		v.extractQuiz,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/attempts/:attempt/proctoring",
+		v.observeQuizAttemptProctoring,
+		v.extractQuiz, v.extractQuizAttempt,
+	)
+}
+
+func makeProctoringEvent(event models.ProctoringEvent) ProctoringEvent {
+	resp := ProctoringEvent{
+		ID:         event.ID,
+		Kind:       event.Kind,
+		Time:       event.Time,
+		ClientTime: event.ClientTime,
+	}
+	if len(event.Data) > 0 {
+		resp.Data = json.RawMessage(event.Data)
+	}
+	return resp
+}
+
+func (v *View) makeProctoringTimeline(
+	attempt models.QuizAttempt, events []models.ProctoringEvent,
+	permissions managers.Permissions,
+) ProctoringTimeline {
+	resp := ProctoringTimeline{
+		AttemptID: attempt.ID,
+		AccountID: attempt.AccountID,
+		Counters:  map[string]int{},
+		Events:    []ProctoringEvent{},
+	}
+	if user, err := v.core.Users.GetByAccount(attempt.AccountID); err == nil {
+		userResp := makeUser(user, permissions)
+		resp.User = &userResp
+	}
+	for _, event := range events {
+		resp.Counters[event.Kind.String()]++
+		if event.Kind != models.ReconnectEvent {
+			resp.Suspicious++
+		}
+		resp.Events = append(resp.Events, makeProctoringEvent(event))
+	}
+	return resp
+}
+
+func (v *View) observeQuizProctoring(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	attempts, err := v.core.QuizAttempts.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].ID < attempts[j].ID
+	})
+	events, err := v.core.ProctoringEvents.FindByQuiz(getContext(c), quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	attemptEvents := map[int64][]models.ProctoringEvent{}
+	for _, event := range events {
+		attemptEvents[event.AttemptID] = append(
+			attemptEvents[event.AttemptID], event,
+		)
+	}
+	resp := ProctoringTimelines{Timelines: []ProctoringTimeline{}}
+	for _, attempt := range attempts {
+		resp.Timelines = append(resp.Timelines, v.makeProctoringTimeline(
+			attempt, attemptEvents[attempt.ID], permissions,
+		))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observeQuizAttemptProctoring(c echo.Context) error {
+	attempt, ok := c.Get(quizAttemptKey).(models.QuizAttempt)
+	if !ok {
+		c.Logger().Error("attempt not extracted")
+		return fmt.Errorf("attempt not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	events, err := v.core.ProctoringEvents.FindByAttempt(
+		getContext(c), attempt.ID,
+	)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(
+		http.StatusOK, v.makeProctoringTimeline(attempt, events, permissions),
+	)
+}
+
+type createProctoringEventForm struct {
+	Kind       string          `json:"kind"`
+	ClientTime int64           `json:"client_time"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type createProctoringEventsForm struct {
+	Events []createProctoringEventForm `json:"events"`
+}
+
+func (f createProctoringEventsForm) Update(
+	events *[]models.ProctoringEvent, attempt models.QuizAttempt,
+	now time.Time,
+) *errorResponse {
+	errors := errorFields{}
+	if len(f.Events) == 0 {
+		errors["events"] = errorField{Message: "events are empty"}
+	} else if len(f.Events) > maxProctoringEvents {
+		errors["events"] = errorField{
+			Message: fmt.Sprintf(
+				"too many events: %d > %d", len(f.Events), maxProctoringEvents,
+			),
+		}
+	}
+	for i, form := range f.Events {
+		event := models.ProctoringEvent{
+			Time:       now.Unix(),
+			ClientTime: form.ClientTime,
+			QuizID:     attempt.QuizID,
+			AttemptID:  attempt.ID,
+			AccountID:  attempt.AccountID,
+		}
+		if err := event.Kind.UnmarshalText([]byte(form.Kind)); err != nil {
+			errors[fmt.Sprintf("events[%d].kind", i)] = errorField{
+				Message: "invalid kind",
+			}
+		}
+		if len(form.Data) > maxProctoringEventData {
+			errors[fmt.Sprintf("events[%d].data", i)] = errorField{
+				Message: "data is too long",
+			}
+		} else if len(form.Data) > 0 && string(form.Data) != "null" {
+			event.Data = models.JSON(form.Data)
+		}
+		*events = append(*events, event)
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	return nil
+}
+
+// createProctoringEvents saves integrity events reported by client
+// of attempt.
+//
+// Amount of events is limited per attempt, so broken or malicious
+// client can not flood event store.
+func (v *View) createProctoringEvents(c echo.Context) error {
+	attempt, ok := c.Get(quizAttemptKey).(models.QuizAttempt)
+	if !ok {
+		c.Logger().Error("attempt not extracted")
+		return fmt.Errorf("attempt not extracted")
+	}
+	var form createProctoringEventsForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	now := time.Now()
+	if !attempt.IsActive(now) {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: fmt.Sprintf("attempt %d is already finished", attempt.ID),
+		})
+	}
+	var events []models.ProctoringEvent
+	if err := form.Update(&events, attempt, now); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if !v.proctoringLimiter.Allow(attempt.ID, len(events), now) {
+		return c.JSON(http.StatusTooManyRequests, errorResponse{
+			Message: "too many events",
+		})
+	}
+	// Events are stored in single transaction, so batch is either
+	// stored completely or not stored at all.
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		for i := range events {
+			if err := v.core.ProctoringEvents.Create(
+				ctx, &events[i],
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := ProctoringEvents{}
+	for _, event := range events {
+		resp.Events = append(resp.Events, makeProctoringEvent(event))
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// rateLimiter limits amount of actions per key using token bucket.
+type rateLimiter struct {
+	burst   float64
+	rate    float64
+	mutex   sync.Mutex
+	buckets map[int64]rateBucket
+}
+
+type rateBucket struct {
+	tokens float64
+	time   time.Time
+}
+
+// Allow reports whether count actions can be performed for key and
+// consumes tokens if they can.
+func (l *rateLimiter) Allow(key int64, count int, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = rateBucket{tokens: l.burst, time: now}
+	}
+	if elapsed := now.Sub(bucket.time).Seconds(); elapsed > 0 {
+		bucket.tokens += elapsed * l.rate
+		if bucket.tokens > l.burst {
+			bucket.tokens = l.burst
+		}
+		bucket.time = now
+	}
+	if bucket.tokens < float64(count) {
+		l.buckets[key] = bucket
+		return false
+	}
+	bucket.tokens -= float64(count)
+	l.buckets[key] = bucket
+	l.cleanup(now)
+	return true
+}
+
+// cleanup removes buckets that are full, since they are equivalent
+// to missing buckets.
+func (l *rateLimiter) cleanup(now time.Time) {
+	if len(l.buckets) < 1024 {
+		return
+	}
+	for key, bucket := range l.buckets {
+		elapsed := now.Sub(bucket.time).Seconds()
+		if bucket.tokens+elapsed*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func newRateLimiter(burst int, rate float64) *rateLimiter {
+	return &rateLimiter{
+		burst:   float64(burst),
+		rate:    rate,
+		buckets: map[int64]rateBucket{},
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProctoringSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title: getPtr("Quiz"),
+		Sections: &[]QuizSection{
+			{Points: 1, Problems: []int64{problem.ID}},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCreateUser(t, "student", "qwerty123")
+	client := newTestClient(testSrv.URL + "/api")
+	if _, err := client.Login("student", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := client.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	for _, form := range []createProctoringEventsForm{
+		{},
+		{Events: []createProctoringEventForm{{Kind: "unknown"}}},
+	} {
+		if _, err := client.CreateProctoringEvents(
+			quiz.ID, attempt.ID, form,
+		); err == nil {
+			t.Fatal("Expected error")
+		} else {
+			testCheck(err)
+		}
+	}
+	if events, err := client.CreateProctoringEvents(
+		quiz.ID, attempt.ID, createProctoringEventsForm{
+			Events: []createProctoringEventForm{
+				{Kind: "tab_hidden", ClientTime: 100},
+				{Kind: "paste", ClientTime: 101, Data: json.RawMessage(`{"length":42}`)},
+				{Kind: "reconnect", ClientTime: 102},
+			},
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearProctoringEvents(events.Events))
+	}
+	if _, err := client.ObserveQuizProctoring(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if timelines, err := testSocketObserveQuizProctoring(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(timelines.Timelines) != 1 {
+		t.Fatalf("Expected 1 timeline, got %d", len(timelines.Timelines))
+	} else {
+		testCheck(testClearProctoringTimeline(timelines.Timelines[0]))
+	}
+	if timeline, err := testSocketObserveQuizAttemptProctoring(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if timeline.Suspicious != 2 || len(timeline.Events) != 3 {
+		t.Fatalf("Unexpected timeline: %v", timeline)
+	}
+	// Burst of events is exhausted after 100 events.
+	var blurs []createProctoringEventForm
+	for i := 0; i < maxProctoringEvents; i++ {
+		blurs = append(blurs, createProctoringEventForm{Kind: "window_blur"})
+	}
+	if _, err := client.CreateProctoringEvents(
+		quiz.ID, attempt.ID, createProctoringEventsForm{Events: blurs},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := client.CreateProctoringEvents(
+		quiz.ID, attempt.ID, createProctoringEventsForm{Events: blurs},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := client.FinishQuizAttempt(quiz.ID, attempt.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := client.CreateProctoringEvents(
+		quiz.ID, attempt.ID, createProctoringEventsForm{
+			Events: []createProctoringEventForm{{Kind: "copy"}},
+		},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(2, 1)
+	now := time.Unix(1000, 0)
+	if !limiter.Allow(1, 2, now) {
+		t.Fatal("Expected allowed")
+	}
+	if limiter.Allow(1, 1, now) {
+		t.Fatal("Expected not allowed")
+	}
+	if !limiter.Allow(2, 1, now) {
+		t.Fatal("Expected allowed")
+	}
+	if !limiter.Allow(1, 1, now.Add(time.Second)) {
+		t.Fatal("Expected allowed")
+	}
+	if limiter.Allow(1, 3, now.Add(time.Hour)) {
+		t.Fatal("Expected not allowed")
+	}
+}
+
+// testClearProctoringEvents clears timestamps of proctoring events.
+//
+// Canonical tests does not support current timestamps.
+func testClearProctoringEvents(events []ProctoringEvent) []ProctoringEvent {
+	for i := range events {
+		events[i].Time = 0
+	}
+	return events
+}
+
+// testClearProctoringTimeline clears timestamps of proctoring timeline.
+//
+// Canonical tests does not support current timestamps.
+func testClearProctoringTimeline(
+	timeline ProctoringTimeline,
+) ProctoringTimeline {
+	timeline.Events = testClearProctoringEvents(timeline.Events)
+	return timeline
+}
+
+func (c *testClient) CreateProctoringEvents(
+	quiz, attempt int64, form createProctoringEventsForm,
+) (ProctoringEvents, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return ProctoringEvents{}, err
+	}
+	req, err := http.NewRequest(
+		http.MethodPost,
+		c.getURL("/v0/quizzes/%d/attempts/%d/proctoring", quiz, attempt),
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return ProctoringEvents{}, err
+	}
+	var respData ProctoringEvents
+	err = c.doRequest(req, http.StatusCreated, &respData)
+	return respData, err
+}
+
+func (c *testClient) ObserveQuizProctoring(
+	quiz int64,
+) (ProctoringTimelines, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/proctoring", quiz), nil,
+	)
+	if err != nil {
+		return ProctoringTimelines{}, err
+	}
+	var respData ProctoringTimelines
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func testSocketObserveQuizProctoring(
+	quiz int64,
+) (ProctoringTimelines, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/quizzes/%d/proctoring", quiz),
+		nil,
+	)
+	var resp ProctoringTimelines
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveQuizAttemptProctoring(
+	quiz, attempt int64,
+) (ProctoringTimeline, error) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("/socket/v0/quizzes/%d/attempts/%d/proctoring", quiz, attempt),
+		nil,
+	)
+	var resp ProctoringTimeline
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
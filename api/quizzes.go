@@ -0,0 +1,772 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// QuizSection represents section of quiz.
+type QuizSection struct {
+	// Title contains section title.
+	Title string `json:"title,omitempty"`
+	// Points contains amount of points for every problem of section.
+	Points int64 `json:"points"`
+	// Problems contains IDs of fixed problems of section.
+	Problems []int64 `json:"problems,omitempty"`
+	// PoolID contains ID of pool for drawing problems.
+	PoolID int64 `json:"pool_id,omitempty"`
+	// ProblemCount contains amount of problems drawn from pool.
+	ProblemCount int64 `json:"problem_count,omitempty"`
+	// Scoring contains scoring policy of section.
+	Scoring string `json:"scoring,omitempty"`
+}
+
+// Quiz represents quiz.
+type Quiz struct {
+	// ID contains quiz ID.
+	ID int64 `json:"id"`
+	// Title contains quiz title.
+	Title string `json:"title"`
+	// Description contains quiz description.
+	Description string `json:"description,omitempty"`
+	// Duration contains time limit of attempt in seconds.
+	Duration int64 `json:"duration,omitempty"`
+	// OpenTime contains time when quiz opens for new attempts.
+	OpenTime int64 `json:"open_time,omitempty"`
+	// CloseTime contains time when quiz closes.
+	CloseTime int64 `json:"close_time,omitempty"`
+	// Scoring contains default scoring policy of quiz.
+	Scoring string `json:"scoring,omitempty"`
+	// Leaderboard contains visibility of public leaderboard.
+	Leaderboard string `json:"leaderboard,omitempty"`
+	// Access contains who can take quiz.
+	Access string `json:"access,omitempty"`
+	// Sections contains quiz sections.
+	Sections []QuizSection `json:"sections,omitempty"`
+}
+
+type quizSorter []Quiz
+
+func (v quizSorter) Len() int {
+	return len(v)
+}
+
+func (v quizSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v quizSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// Quizzes represents quizzes response.
+type Quizzes struct {
+	Quizzes []Quiz `json:"quizzes"`
+}
+
+// registerQuizHandlers registers handlers for quiz management.
+func (v *View) registerQuizHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes", v.observeQuizzes,
+		v.extractAuth(v.sessionAuth, v.guestAuth),
+		v.requirePermission(models.ObserveQuizzesRole),
+	)
+	g.POST(
+		"/v0/quizzes", v.createQuiz,
+		v.extractAuth(v.sessionAuth),
+		v.requirePermission(models.CreateQuizRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz", v.observeQuiz,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizRole),
+	)
+	g.PATCH(
+		"/v0/quizzes/:quiz", v.updateQuiz,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.UpdateQuizRole),
+	)
+	g.DELETE(
+		"/v0/quizzes/:quiz", v.deleteQuiz,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.DeleteQuizRole),
+	)
+}
+
+// registerSocketQuizHandlers registers socket handlers for quiz
+// management.
+func (v *View) registerSocketQuizHandlers(g *echo.Group) {
+	g.GET("/v0/quizzes", v.observeQuizzes)
+	g.POST("/v0/quizzes", v.createQuiz)
+	g.GET("/v0/quizzes/:quiz", v.observeQuiz, v.extractQuiz)
+	g.PATCH("/v0/quizzes/:quiz", v.updateQuiz, v.extractQuiz)
+	g.DELETE("/v0/quizzes/:quiz", v.deleteQuiz, v.extractQuiz)
+}
+
+func makeQuiz(
+	quiz models.Quiz, sections []models.QuizSection,
+	permissions managers.Permissions,
+) (Quiz, error) {
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		return Quiz{}, err
+	}
+	resp := Quiz{
+		ID:          quiz.ID,
+		Title:       quiz.Title,
+		Description: quiz.Description,
+		Duration:    config.Duration,
+		OpenTime:    config.OpenTime,
+		CloseTime:   config.CloseTime,
+	}
+	if config.Scoring != 0 {
+		resp.Scoring = config.Scoring.String()
+	}
+	if config.Leaderboard != 0 {
+		resp.Leaderboard = config.Leaderboard.String()
+	}
+	if config.Access != 0 {
+		resp.Access = config.Access.String()
+	}
+	if permissions.HasPermission(models.ObserveQuizSectionsRole) {
+		for _, section := range sections {
+			var config models.QuizSectionConfig
+			if err := section.ScanConfig(&config); err != nil {
+				return Quiz{}, err
+			}
+			sectionResp := QuizSection{
+				Title:        section.Title,
+				Points:       section.Points,
+				Problems:     config.Problems,
+				PoolID:       int64(section.PoolID),
+				ProblemCount: section.ProblemCount,
+			}
+			if config.Scoring != 0 {
+				sectionResp.Scoring = config.Scoring.String()
+			}
+			resp.Sections = append(resp.Sections, sectionResp)
+		}
+	}
+	return resp, nil
+}
+
+func (v *View) observeQuizzes(c echo.Context) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	quizzes, err := v.core.Quizes.All()
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := Quizzes{Quizzes: []Quiz{}}
+	for _, quiz := range quizzes {
+		permissions := v.getQuizPermissions(accountCtx, quiz)
+		if permissions.HasPermission(models.ObserveQuizRole) {
+			resp.Quizzes = append(resp.Quizzes, Quiz{
+				ID:          quiz.ID,
+				Title:       quiz.Title,
+				Description: quiz.Description,
+			})
+		}
+	}
+	sort.Sort(quizSorter(resp.Quizzes))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observeQuiz(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	sections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeQuiz(quiz, sections, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// updateQuizForm represents form for creating and updating quiz.
+type updateQuizForm struct {
+	Title       *string        `json:"title"`
+	Description *string        `json:"description"`
+	Duration    *int64         `json:"duration"`
+	OpenTime    *int64         `json:"open_time"`
+	CloseTime   *int64         `json:"close_time"`
+	Scoring     *string        `json:"scoring"`
+	Leaderboard *string        `json:"leaderboard"`
+	Access      *string        `json:"access"`
+	Sections    *[]QuizSection `json:"sections"`
+}
+
+func (f updateQuizForm) Update(
+	quiz *models.Quiz, sections *[]models.QuizSection,
+) *errorResponse {
+	if f.Title != nil {
+		quiz.Title = *f.Title
+	}
+	if f.Description != nil {
+		quiz.Description = *f.Description
+	}
+	errors := errorFields{}
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		errors["config"] = errorField{Message: err.Error()}
+	}
+	if f.Duration != nil {
+		config.Duration = *f.Duration
+	}
+	if config.Duration < 0 {
+		errors["duration"] = errorField{
+			Message: "duration should not be negative",
+		}
+	}
+	if f.OpenTime != nil {
+		config.OpenTime = *f.OpenTime
+	}
+	if f.CloseTime != nil {
+		config.CloseTime = *f.CloseTime
+	}
+	validateQuizWindow(errors, config.OpenTime, config.CloseTime)
+	if f.Scoring != nil {
+		config.Scoring = 0
+		if len(*f.Scoring) > 0 {
+			if err := config.Scoring.UnmarshalText(
+				[]byte(*f.Scoring),
+			); err != nil {
+				errors["scoring"] = errorField{
+					Message: "scoring is not supported",
+				}
+			}
+		}
+	}
+	if f.Leaderboard != nil {
+		config.Leaderboard = 0
+		if len(*f.Leaderboard) > 0 {
+			if err := config.Leaderboard.UnmarshalText(
+				[]byte(*f.Leaderboard),
+			); err != nil {
+				errors["leaderboard"] = errorField{
+					Message: "leaderboard is not supported",
+				}
+			}
+		}
+	}
+	if f.Access != nil {
+		config.Access = 0
+		if len(*f.Access) > 0 {
+			if err := config.Access.UnmarshalText(
+				[]byte(*f.Access),
+			); err != nil {
+				errors["access"] = errorField{
+					Message: "access is not supported",
+				}
+			}
+		}
+	}
+	if err := quiz.SetConfig(config); err != nil {
+		errors["config"] = errorField{Message: err.Error()}
+	}
+	if len(quiz.Title) == 0 {
+		errors["title"] = errorField{Message: "title should not be empty"}
+	} else if len(quiz.Title) > 128 {
+		errors["title"] = errorField{Message: "title too long (>128)"}
+	}
+	if len(quiz.Description) > 65536 {
+		errors["description"] = errorField{
+			Message: "description too long (>65536)",
+		}
+	}
+	if f.Sections != nil {
+		if len(*f.Sections) > 64 {
+			errors["sections"] = errorField{
+				Message: "too many sections (>64)",
+			}
+		}
+		var newSections []models.QuizSection
+		for i, section := range *f.Sections {
+			if err := validateQuizSection(section); err != nil {
+				errors["sections"] = errorField{
+					Message: fmt.Sprintf("section %d: %s", i+1, err),
+				}
+				break
+			}
+			newSection := models.QuizSection{
+				QuizID:       quiz.ID,
+				Position:     int64(i + 1),
+				Title:        section.Title,
+				Points:       section.Points,
+				PoolID:       models.NInt64(section.PoolID),
+				ProblemCount: section.ProblemCount,
+			}
+			sectionConfig := models.QuizSectionConfig{
+				Problems: section.Problems,
+			}
+			if len(section.Scoring) > 0 {
+				if err := sectionConfig.Scoring.UnmarshalText(
+					[]byte(section.Scoring),
+				); err != nil {
+					errors["sections"] = errorField{
+						Message: fmt.Sprintf(
+							"section %d: scoring is not supported", i+1,
+						),
+					}
+					break
+				}
+			}
+			if err := newSection.SetConfig(sectionConfig); err != nil {
+				errors["sections"] = errorField{Message: err.Error()}
+				break
+			}
+			newSections = append(newSections, newSection)
+		}
+		*sections = newSections
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	return nil
+}
+
+func validateQuizWindow(errors errorFields, openTime, closeTime int64) {
+	if openTime < 0 {
+		errors["open_time"] = errorField{
+			Message: "open time should not be negative",
+		}
+	}
+	if closeTime < 0 {
+		errors["close_time"] = errorField{
+			Message: "close time should not be negative",
+		}
+	} else if closeTime != 0 && closeTime <= openTime {
+		errors["close_time"] = errorField{
+			Message: "close time should be after open time",
+		}
+	}
+}
+
+func validateQuizSection(section QuizSection) error {
+	if len(section.Title) > 128 {
+		return fmt.Errorf("title too long (>128)")
+	}
+	if section.Points < 0 {
+		return fmt.Errorf("points should not be negative")
+	}
+	if section.PoolID != 0 {
+		if len(section.Problems) > 0 {
+			return fmt.Errorf("section should not have both pool and problems")
+		}
+		if section.ProblemCount <= 0 {
+			return fmt.Errorf("problem count should be positive")
+		}
+		return nil
+	}
+	if section.ProblemCount != 0 {
+		return fmt.Errorf("problem count should be used with pool")
+	}
+	if len(section.Problems) == 0 {
+		return fmt.Errorf("section should have either pool or problems")
+	}
+	if len(section.Problems) > 256 {
+		return fmt.Errorf("too many problems (>256)")
+	}
+	problems := map[int64]struct{}{}
+	for _, id := range section.Problems {
+		if _, ok := problems[id]; ok {
+			return fmt.Errorf("problem %d is duplicated", id)
+		}
+		problems[id] = struct{}{}
+	}
+	return nil
+}
+
+// checkQuizSections checks that sections refer to existing problems
+// and pools.
+func (v *View) checkQuizSections(
+	ctx context.Context, sections []models.QuizSection,
+) error {
+	synced := false
+	for i, section := range sections {
+		invalid := func(format string, args ...any) error {
+			return errorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "passed invalid fields to form",
+				InvalidFields: errorFields{
+					"sections": errorField{
+						Message: fmt.Sprintf(
+							"section %d: %s", i+1,
+							fmt.Sprintf(format, args...),
+						),
+					},
+				},
+			}
+		}
+		if section.PoolID != 0 {
+			poolID := int64(section.PoolID)
+			if _, err := v.core.Pools.Get(poolID); err == sql.ErrNoRows {
+				if err := v.core.Pools.Sync(ctx); err != nil {
+					return err
+				}
+				if err := v.core.PoolProblems.Sync(ctx); err != nil {
+					return err
+				}
+				if _, err := v.core.Pools.Get(poolID); err == sql.ErrNoRows {
+					return invalid("pool %d not found", poolID)
+				} else if err != nil {
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
+			problems, err := v.core.PoolProblems.FindByPool(poolID)
+			if err != nil {
+				return err
+			}
+			if int64(len(problems)) < section.ProblemCount {
+				return invalid(
+					"pool %d has only %d problems",
+					poolID, len(problems),
+				)
+			}
+			continue
+		}
+		var config models.QuizSectionConfig
+		if err := section.ScanConfig(&config); err != nil {
+			return err
+		}
+		for _, id := range config.Problems {
+			_, err := v.core.Problems.Get(id)
+			if err == sql.ErrNoRows && !synced {
+				if err := v.core.Problems.Sync(ctx); err != nil {
+					return err
+				}
+				synced = true
+				_, err = v.core.Problems.Get(id)
+			}
+			if err == sql.ErrNoRows {
+				return invalid("problem %d not found", id)
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *View) createQuiz(c echo.Context) error {
+	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+	if !ok {
+		c.Logger().Error("auth not extracted")
+		return fmt.Errorf("auth not extracted")
+	}
+	var form updateQuizForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	var quiz models.Quiz
+	var sections []models.QuizSection
+	if err := form.Update(&quiz, &sections); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if err := v.checkQuizSections(getContext(c), sections); err != nil {
+		return err
+	}
+	if account := accountCtx.Account; account != nil {
+		quiz.OwnerID = models.NInt64(account.ID)
+	}
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		if err := v.core.Quizes.Create(ctx, &quiz); err != nil {
+			return err
+		}
+		for i := range sections {
+			sections[i].QuizID = quiz.ID
+			if err := v.core.QuizSections.Create(ctx, &sections[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeQuiz(
+		quiz, sections, v.getQuizPermissions(accountCtx, quiz),
+	)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (v *View) updateQuiz(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	var form updateQuizForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	oldSections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	sections := oldSections
+	if err := form.Update(&quiz, &sections); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if form.Sections != nil {
+		if err := v.checkQuizSections(getContext(c), sections); err != nil {
+			return err
+		}
+	}
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		if err := v.core.Quizes.Update(ctx, quiz); err != nil {
+			return err
+		}
+		if form.Sections == nil {
+			return nil
+		}
+		// Sections are updated in place to keep references from
+		// existing attempts valid, so they can be regraded.
+		for i := range sections {
+			if i < len(oldSections) {
+				sections[i].ID = oldSections[i].ID
+				if err := v.core.QuizSections.Update(ctx, sections[i]); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := v.core.QuizSections.Create(ctx, &sections[i]); err != nil {
+				return err
+			}
+		}
+		for i := len(sections); i < len(oldSections); i++ {
+			if err := v.core.QuizSections.Delete(ctx, oldSections[i].ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeQuiz(quiz, sections, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) deleteQuiz(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	sections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	if err := v.core.WrapTx(getContext(c), func(ctx context.Context) error {
+		for _, section := range sections {
+			if err := v.core.QuizSections.Delete(ctx, section.ID); err != nil {
+				return err
+			}
+		}
+		return v.core.Quizes.Delete(ctx, quiz.ID)
+	}, sqlRepeatableRead); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp, err := makeQuiz(quiz, sections, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) extractQuiz(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("quiz"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid quiz ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
+		if !ok {
+			c.Logger().Error("auth not extracted")
+			return fmt.Errorf("auth not extracted")
+		}
+		quiz, err := v.core.Quizes.Get(id)
+		if err == sql.ErrNoRows {
+			if err := v.core.Quizes.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			if err := v.core.QuizSections.Sync(getContext(c)); err != nil {
+				c.Logger().Error(err)
+				return err
+			}
+			quiz, err = v.core.Quizes.Get(id)
+		}
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("quiz %d not found", id),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		c.Set(quizKey, quiz)
+		c.Set(permissionCtxKey, v.getQuizPermissions(accountCtx, quiz))
+		return next(c)
+	}
+}
+
+// quizManagerPermissions contains permissions of quiz managers.
+var quizManagerPermissions = []string{
+	models.ObserveQuizRole,
+	models.ObserveQuizSectionsRole,
+	models.UpdateQuizRole,
+	models.ObserveQuizAttemptsRole,
+	models.ObserveQuizResultsRole,
+	models.ObserveQuizLeaderboardRole,
+	models.ObserveQuizOverridesRole,
+	models.CreateQuizOverrideRole,
+	models.UpdateQuizOverrideRole,
+	models.DeleteQuizOverrideRole,
+	models.ObserveQuizParticipantsRole,
+	models.CreateQuizParticipantRole,
+	models.DeleteQuizParticipantRole,
+	models.GradeQuizAttemptsRole,
+	models.ObserveQuizStatisticsRole,
+	models.ObserveQuizProctoringRole,
+}
+
+// quizObserverPermissions contains permissions of quiz observers.
+var quizObserverPermissions = []string{
+	models.ObserveQuizRole,
+	models.ObserveQuizSectionsRole,
+	models.ObserveQuizAttemptsRole,
+	models.ObserveQuizResultsRole,
+	models.ObserveQuizLeaderboardRole,
+	models.ObserveQuizParticipantsRole,
+	models.ObserveQuizStatisticsRole,
+	models.ObserveQuizProctoringRole,
+}
+
+// quizGraderPermissions contains permissions of quiz graders.
+var quizGraderPermissions = []string{
+	models.ObserveQuizRole,
+	models.GradeQuizAttemptsRole,
+}
+
+// quizParticipantPermissions contains permissions of regular
+// quiz participants.
+var quizParticipantPermissions = []string{
+	models.ObserveQuizRole,
+	models.ObserveQuizLeaderboardRole,
+	models.CreateQuizAttemptRole,
+}
+
+func (v *View) getQuizPermissions(
+	ctx *managers.AccountContext, quiz models.Quiz,
+) managers.PermissionSet {
+	permissions := ctx.Permissions.Clone()
+	grant := func(roles []string) {
+		for _, role := range roles {
+			permissions[role] = struct{}{}
+		}
+	}
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		config.Access = models.InviteQuizAccess
+	}
+	// Global quiz managers are not restricted by quiz access.
+	if !permissions.HasPermission(models.UpdateQuizRole) {
+		restricted := false
+		switch config.Access {
+		case models.RegisteredQuizAccess:
+			restricted = ctx.User == nil
+		case models.InviteQuizAccess:
+			restricted = true
+		}
+		if restricted {
+			for _, role := range quizParticipantPermissions {
+				delete(permissions, role)
+			}
+		}
+	}
+	account := ctx.Account
+	if account == nil {
+		return permissions
+	}
+	if quiz.OwnerID != 0 && account.ID == int64(quiz.OwnerID) {
+		grant(quizManagerPermissions)
+		permissions[models.DeleteQuizRole] = struct{}{}
+	}
+	participant, err := v.core.QuizParticipants.GetByQuizAccount(
+		quiz.ID, account.ID,
+	)
+	if err == nil {
+		switch participant.Role {
+		case models.RegularParticipant:
+			grant(quizParticipantPermissions)
+		case models.ObserverParticipant:
+			grant(quizObserverPermissions)
+		case models.ManagerParticipant:
+			grant(quizManagerPermissions)
+		case models.GraderParticipant:
+			grant(quizGraderPermissions)
+		}
+	}
+	return permissions
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuizSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	var problems []Problem
+	for _, title := range []string{"First", "Second", "Third"} {
+		problem, err := testSocketCreateProblem(updateProblemForm{
+			Kind:      getPtr("numeric"),
+			Title:     getPtr(title),
+			Statement: getPtr("Statement"),
+			Answer:    &ProblemAnswer{Value: 1},
+		})
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		problems = append(problems, problem)
+	}
+	pool, err := testSocketCreatePool(updatePoolForm{Name: getPtr("Pool")})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	for _, problem := range problems[1:] {
+		if _, err := testSocketCreatePoolProblem(
+			pool.ID, problem.ID,
+		); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:       getPtr("Test quiz"),
+		Description: getPtr("Test description"),
+		Sections: &[]QuizSection{
+			{Title: "Fixed", Points: 1, Problems: []int64{problems[0].ID}},
+			{Title: "Random", Points: 2, PoolID: pool.ID, ProblemCount: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(quiz)
+	for _, form := range []updateQuizForm{
+		{Title: getPtr("")},
+		{
+			Title:    getPtr("Both"),
+			Sections: &[]QuizSection{{Problems: []int64{1}, PoolID: 1}},
+		},
+		{
+			Title:    getPtr("Empty section"),
+			Sections: &[]QuizSection{{Points: 1}},
+		},
+		{
+			Title:    getPtr("Duplicate"),
+			Sections: &[]QuizSection{{Problems: []int64{1, 1}}},
+		},
+		{
+			Title:    getPtr("Unknown problem"),
+			Sections: &[]QuizSection{{Problems: []int64{100}}},
+		},
+		{
+			Title: getPtr("Large count"),
+			Sections: &[]QuizSection{
+				{PoolID: pool.ID, ProblemCount: 3},
+			},
+		},
+	} {
+		if _, err := testSocketCreateQuiz(form); err == nil {
+			t.Fatal("Expected error")
+		} else {
+			testCheck(err)
+		}
+	}
+	if observed, err := testSocketObserveQuiz(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(observed)
+	}
+	updated, err := testSocketUpdateQuiz(quiz.ID, updateQuizForm{
+		Title: getPtr("Updated quiz"),
+		Sections: &[]QuizSection{
+			{Title: "Random", Points: 3, PoolID: pool.ID, ProblemCount: 2},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(updated)
+	testSyncManagers(t)
+	if quizzes, err := testSocketObserveQuizzes(); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(quizzes)
+	}
+	if observed, err := testSocketObserveQuiz(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(observed)
+	}
+	if deleted, err := testSocketDeleteQuiz(quiz.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(deleted)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketObserveQuiz(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+}
+
+func testSocketCreateQuiz(form updateQuizForm) (Quiz, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Quiz{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPost, "/socket/v0/quizzes", bytes.NewReader(data),
+	)
+	var resp Quiz
+	err = doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketObserveQuizzes() (Quizzes, error) {
+	req := httptest.NewRequest(http.MethodGet, "/socket/v0/quizzes", nil)
+	var resp Quizzes
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveQuiz(id int64) (Quiz, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/quizzes/%d", id), nil,
+	)
+	var resp Quiz
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketUpdateQuiz(id int64, form updateQuizForm) (Quiz, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Quiz{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPatch, fmt.Sprintf("/socket/v0/quizzes/%d", id),
+		bytes.NewReader(data),
+	)
+	var resp Quiz
+	err = doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketDeleteQuiz(id int64) (Quiz, error) {
+	req := httptest.NewRequest(
+		http.MethodDelete, fmt.Sprintf("/socket/v0/quizzes/%d", id), nil,
+	)
+	var resp Quiz
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
@@ -0,0 +1,492 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+	"github.com/udovin/goquiz/xlsx"
+)
+
+// QuizSectionResult represents result of participant for quiz section.
+type QuizSectionResult struct {
+	// SectionID contains section ID.
+	SectionID int64 `json:"section_id"`
+	// Score contains score for section.
+	Score float64 `json:"score"`
+}
+
+// QuizResult represents result of participant.
+type QuizResult struct {
+	// Rank contains position of participant in results.
+	Rank int `json:"rank"`
+	// AccountID contains ID of participant account.
+	AccountID int64 `json:"account_id"`
+	// User contains participant user.
+	User *User `json:"user,omitempty"`
+	// AttemptID contains ID of ranked attempt.
+	AttemptID int64 `json:"attempt_id"`
+	// Score contains total score.
+	Score float64 `json:"score"`
+	// Sections contains scores for quiz sections.
+	Sections []QuizSectionResult `json:"sections"`
+	// StartTime contains time when attempt was started.
+	StartTime int64 `json:"start_time"`
+	// FinishTime contains time when attempt was finished.
+	FinishTime int64 `json:"finish_time"`
+	// Duration contains time taken in seconds.
+	Duration int64 `json:"duration"`
+}
+
+// QuizResults represents quiz results response.
+type QuizResults struct {
+	Results []QuizResult `json:"results"`
+}
+
+// QuizLeaderboardEntry represents entry of public leaderboard.
+type QuizLeaderboardEntry struct {
+	// Rank contains position of participant in leaderboard.
+	Rank int `json:"rank"`
+	// User contains participant user.
+	User *User `json:"user,omitempty"`
+	// Score contains total score.
+	Score float64 `json:"score"`
+}
+
+// QuizLeaderboard represents quiz leaderboard response.
+type QuizLeaderboard struct {
+	Entries []QuizLeaderboardEntry `json:"entries"`
+}
+
+// registerQuizResultHandlers registers handlers for quiz results.
+func (v *View) registerQuizResultHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/results", v.observeQuizResults,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizResultsRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/results/export", v.exportQuizResults,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizResultsRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/leaderboard", v.observeQuizLeaderboard,
+		v.extractAuth(v.sessionAuth, v.guestAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizLeaderboardRole),
+	)
+}
+
+// registerSocketQuizResultHandlers registers socket handlers for
+// quiz results.
+func (v *View) registerSocketQuizResultHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/quizzes/:quiz/results", v.observeQuizResults,
+		v.extractQuiz,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/results/export", v.exportQuizResults,
+		v.extractQuiz,
+	)
+}
+
+// buildQuizResults ranks participants of quiz.
+//
+// Best finished attempt of every participant is ranked. Participants
+// with higher score are ranked first and ties are broken by earlier
+// finish time.
+func (v *View) buildQuizResults(
+	quiz models.Quiz, permissions managers.Permissions,
+) ([]QuizResult, error) {
+	sections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := v.core.QuizAttempts.FindByQuiz(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+	best := map[int64]QuizResult{}
+	for _, attempt := range attempts {
+		if attempt.Status != models.FinishedAttempt {
+			continue
+		}
+		var state models.QuizAttemptState
+		if err := attempt.ScanState(&state); err != nil {
+			return nil, err
+		}
+		sectionScores := map[int64]float64{}
+		for _, problem := range state.Problems {
+			sectionScores[problem.SectionID] += problem.Score
+		}
+		result := QuizResult{
+			AccountID:  attempt.AccountID,
+			AttemptID:  attempt.ID,
+			Score:      state.Score,
+			Sections:   []QuizSectionResult{},
+			StartTime:  attempt.StartTime,
+			FinishTime: int64(attempt.FinishTime),
+			Duration:   int64(attempt.FinishTime) - attempt.StartTime,
+		}
+		for _, section := range sections {
+			result.Sections = append(result.Sections, QuizSectionResult{
+				SectionID: section.ID,
+				Score:     sectionScores[section.ID],
+			})
+		}
+		prev, ok := best[attempt.AccountID]
+		if ok && !quizResultLess(result, prev) {
+			continue
+		}
+		best[attempt.AccountID] = result
+	}
+	results := make([]QuizResult, 0, len(best))
+	for _, result := range best {
+		user, err := v.core.Users.GetByAccount(result.AccountID)
+		if err == nil {
+			userResp := makeUser(user, permissions)
+			result.User = &userResp
+		} else if err != sql.ErrNoRows {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return quizResultLess(results[i], results[j])
+	})
+	for i := range results {
+		results[i].Rank = i + 1
+	}
+	return results, nil
+}
+
+// quizResultLess reports whether lhs result should be ranked before rhs.
+func quizResultLess(lhs, rhs QuizResult) bool {
+	if lhs.Score != rhs.Score {
+		return lhs.Score > rhs.Score
+	}
+	if lhs.FinishTime != rhs.FinishTime {
+		return lhs.FinishTime < rhs.FinishTime
+	}
+	return lhs.AttemptID < rhs.AttemptID
+}
+
+func (v *View) observeQuizResults(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	results, err := v.buildQuizResults(quiz, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, QuizResults{Results: results})
+}
+
+func (v *View) observeQuizLeaderboard(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	var config models.QuizConfig
+	if err := quiz.ScanConfig(&config); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	visibility := config.Leaderboard
+	// Accounts that can observe full results always see names.
+	if permissions.HasPermission(models.ObserveQuizResultsRole) {
+		visibility = models.NamesLeaderboard
+	}
+	if visibility != models.ScoresLeaderboard &&
+		visibility != models.NamesLeaderboard {
+		resp := errorResponse{Message: "leaderboard is hidden"}
+		return c.JSON(http.StatusForbidden, resp)
+	}
+	results, err := v.buildQuizResults(quiz, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := QuizLeaderboard{Entries: []QuizLeaderboardEntry{}}
+	for _, result := range results {
+		entry := QuizLeaderboardEntry{
+			Rank:  result.Rank,
+			Score: result.Score,
+		}
+		if visibility == models.NamesLeaderboard {
+			entry.User = result.User
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+const (
+	// wideResultsLayout represents layout with one row per participant.
+	wideResultsLayout = "wide"
+	// longResultsLayout represents layout with one row per answer.
+	longResultsLayout = "long"
+)
+
+// resultsWriter represents writer of exported results.
+type resultsWriter interface {
+	Write(record []string) error
+}
+
+// exportQuizResults exports quiz results as CSV or XLSX file.
+//
+// Query parameter format selects file format ("csv" or "xlsx") and
+// layout selects either one row per participant ("wide") or one row
+// per answer ("long"). Names of participants are exported according
+// to permissions of observer.
+func (v *View) exportQuizResults(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	format := c.QueryParam("format")
+	if len(format) == 0 {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "unsupported format",
+		})
+	}
+	layout := c.QueryParam("layout")
+	if len(layout) == 0 {
+		layout = wideResultsLayout
+	}
+	if layout != wideResultsLayout && layout != longResultsLayout {
+		return c.JSON(http.StatusBadRequest, errorResponse{
+			Message: "unsupported layout",
+		})
+	}
+	sections, err := v.core.QuizSections.FindByQuiz(quiz.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	results, err := v.buildQuizResults(quiz, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	states := map[int64]models.QuizAttemptState{}
+	for _, result := range results {
+		attempt, err := v.core.QuizAttempts.Get(result.AttemptID)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		var state models.QuizAttemptState
+		if err := attempt.ScanState(&state); err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		states[result.AttemptID] = state
+	}
+	name := fmt.Sprintf("quiz-%d-results.%s", quiz.ID, format)
+	c.Response().Header().Set(
+		echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", name),
+	)
+	var writer resultsWriter
+	var closeWriter func() error
+	switch format {
+	case "xlsx":
+		c.Response().Header().Set(echo.HeaderContentType, xlsx.ContentType)
+		c.Response().WriteHeader(http.StatusOK)
+		xlsxWriter, err := xlsx.NewWriter(c.Response(), "Results")
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		writer, closeWriter = xlsxWriter, xlsxWriter.Close
+	default:
+		c.Response().Header().Set(
+			echo.HeaderContentType, "text/csv; charset=utf-8",
+		)
+		c.Response().WriteHeader(http.StatusOK)
+		csvWriter := csv.NewWriter(c.Response())
+		writer, closeWriter = csvWriter, func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	}
+	if layout == longResultsLayout {
+		err = v.writeLongQuizResults(writer, results, states)
+	} else {
+		err = v.writeWideQuizResults(writer, sections, results, states)
+	}
+	if err == nil {
+		err = closeWriter()
+	}
+	if err != nil {
+		// Response is already started, so error can only be logged.
+		c.Logger().Error(err)
+	}
+	return nil
+}
+
+// resultUserColumns contains columns with participant user.
+var resultUserColumns = []string{
+	"rank", "login", "first_name", "last_name", "middle_name", "attempt_id",
+}
+
+func makeResultUserRecord(result QuizResult) []string {
+	record := []string{strconv.Itoa(result.Rank), "", "", "", ""}
+	if user := result.User; user != nil {
+		record[1] = user.Login
+		record[2] = user.FirstName
+		record[3] = user.LastName
+		record[4] = user.MiddleName
+	}
+	return append(record, strconv.FormatInt(result.AttemptID, 10))
+}
+
+// writeWideQuizResults writes one row per participant with score for
+// every problem.
+//
+// Problems are ordered by sections and problems that were not given
+// to participant are left empty.
+func (v *View) writeWideQuizResults(
+	writer resultsWriter, sections []models.QuizSection,
+	results []QuizResult, states map[int64]models.QuizAttemptState,
+) error {
+	var problems []models.QuizAttemptProblem
+	seen := map[int64]struct{}{}
+	for _, result := range results {
+		for _, problem := range states[result.AttemptID].Problems {
+			if _, ok := seen[problem.ProblemID]; ok {
+				continue
+			}
+			seen[problem.ProblemID] = struct{}{}
+			problems = append(problems, problem)
+		}
+	}
+	positions := map[int64]int{}
+	for i, section := range sections {
+		positions[section.ID] = i
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return positions[problems[i].SectionID] <
+			positions[problems[j].SectionID]
+	})
+	header := append([]string{}, resultUserColumns...)
+	for _, problem := range problems {
+		header = append(header, fmt.Sprintf(
+			"%s (%d)", v.getResultProblemTitle(problem.ProblemID),
+			problem.ProblemID,
+		))
+	}
+	header = append(header, "score", "start_time", "finish_time", "duration")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, result := range results {
+		scores := map[int64]string{}
+		for _, problem := range states[result.AttemptID].Problems {
+			scores[problem.ProblemID] = formatResultScore(problem.Score)
+		}
+		record := makeResultUserRecord(result)
+		for _, problem := range problems {
+			record = append(record, scores[problem.ProblemID])
+		}
+		record = append(
+			record,
+			formatResultScore(result.Score),
+			formatResultTime(result.StartTime),
+			formatResultTime(result.FinishTime),
+			strconv.FormatInt(result.Duration, 10),
+		)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLongQuizResults writes one row per answer of participant.
+func (v *View) writeLongQuizResults(
+	writer resultsWriter, results []QuizResult,
+	states map[int64]models.QuizAttemptState,
+) error {
+	header := append(
+		append([]string{}, resultUserColumns...),
+		"section_id", "problem_id", "problem", "points", "verdict", "score",
+		"answer_time",
+	)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, result := range results {
+		for _, problem := range states[result.AttemptID].Problems {
+			verdict := ""
+			if problem.Verdict != 0 {
+				verdict = problem.Verdict.String()
+			}
+			record := append(
+				makeResultUserRecord(result),
+				strconv.FormatInt(problem.SectionID, 10),
+				strconv.FormatInt(problem.ProblemID, 10),
+				v.getResultProblemTitle(problem.ProblemID),
+				strconv.FormatInt(problem.Points, 10),
+				verdict,
+				formatResultScore(problem.Score),
+				formatResultTime(problem.AnswerTime),
+			)
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *View) getResultProblemTitle(id int64) string {
+	if problem, err := v.core.Problems.Get(id); err == nil {
+		return problem.Title
+	}
+	return fmt.Sprintf("Problem %d", id)
+}
+
+func formatResultScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// formatResultTime formats unix time as RFC 3339 time in UTC.
+func formatResultTime(unix int64) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
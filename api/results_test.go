@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuizResultsScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("text"),
+		Title:     getPtr("Capital"),
+		Statement: getPtr("Capital of France"),
+		Answer:    &ProblemAnswer{Texts: []string{"Paris"}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:       getPtr("Quiz"),
+		Leaderboard: getPtr("scores"),
+		Sections: &[]QuizSection{
+			{Points: 2, Problems: []int64{problem.ID}},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(quiz)
+	var clients []*testClient
+	for _, item := range []struct {
+		Login  string
+		Answer string
+	}{
+		{"first", "London"},
+		{"second", "Paris"},
+		{"third", "Paris"},
+	} {
+		testCreateUser(t, item.Login, "qwerty123")
+		client := newTestClient(testSrv.URL + "/api")
+		if _, err := client.Login(item.Login, "qwerty123"); err != nil {
+			t.Fatal("Error:", err)
+		}
+		attempt, err := client.CreateQuizAttempt(quiz.ID)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		testSyncManagers(t)
+		if _, err := client.UpdateQuizAttemptAnswer(
+			quiz.ID, attempt.ID, problem.ID,
+			updateQuizAttemptAnswerForm{Text: item.Answer},
+		); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if _, err := client.FinishQuizAttempt(quiz.ID, attempt.ID); err != nil {
+			t.Fatal("Error:", err)
+		}
+		testSyncManagers(t)
+		clients = append(clients, client)
+	}
+	results, err := testSocketObserveQuizResults(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	for i := 1; i < len(results.Results); i++ {
+		prev, curr := results.Results[i-1], results.Results[i]
+		if prev.Score == curr.Score && prev.FinishTime > curr.FinishTime {
+			t.Fatalf("Unexpected order of results: %v", results.Results)
+		}
+	}
+	testCheck(testClearQuizResults(results))
+	for _, layout := range []string{"wide", "long"} {
+		data, err := testSocketExportQuizResults(
+			quiz.ID, "format=csv&layout="+layout,
+		)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		testCheck(testClearExportedQuizResults(records))
+	}
+	if data, err := testSocketExportQuizResults(
+		quiz.ID, "format=xlsx",
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if !bytes.HasPrefix(data, []byte("PK")) {
+		t.Fatal("Expected zip archive")
+	}
+	if _, err := testSocketExportQuizResults(
+		quiz.ID, "format=pdf",
+	); err == nil {
+		t.Fatal("Expected error")
+	}
+	if _, err := clients[0].ObserveQuizResults(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if leaderboard, err := clients[0].ObserveQuizLeaderboard(
+		quiz.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(leaderboard)
+	}
+	for _, visibility := range []string{"names", "hidden"} {
+		if _, err := testSocketUpdateQuiz(quiz.ID, updateQuizForm{
+			Leaderboard: getPtr(visibility),
+		}); err != nil {
+			t.Fatal("Error:", err)
+		}
+		testSyncManagers(t)
+		if leaderboard, err := clients[0].ObserveQuizLeaderboard(
+			quiz.ID,
+		); err != nil {
+			testCheck(err)
+		} else {
+			testCheck(leaderboard)
+		}
+	}
+}
+
+// testClearQuizResults clears timestamps of results.
+//
+// Canonical tests does not support current timestamps.
+func testClearQuizResults(results QuizResults) QuizResults {
+	for i := range results.Results {
+		results.Results[i].StartTime = 0
+		results.Results[i].FinishTime = 0
+		results.Results[i].Duration = 0
+	}
+	return results
+}
+
+// testClearExportedQuizResults clears timestamps of exported results.
+//
+// Canonical tests does not support current timestamps.
+func testClearExportedQuizResults(records [][]string) [][]string {
+	if len(records) == 0 {
+		return records
+	}
+	for i, column := range records[0] {
+		switch column {
+		case "start_time", "finish_time", "duration", "answer_time":
+			for _, record := range records[1:] {
+				record[i] = ""
+			}
+		}
+	}
+	return records
+}
+
+func (c *testClient) ObserveQuizResults(quiz int64) (QuizResults, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/results", quiz), nil,
+	)
+	if err != nil {
+		return QuizResults{}, err
+	}
+	var respData QuizResults
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func (c *testClient) ObserveQuizLeaderboard(
+	quiz int64,
+) (QuizLeaderboard, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/leaderboard", quiz), nil,
+	)
+	if err != nil {
+		return QuizLeaderboard{}, err
+	}
+	var respData QuizLeaderboard
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func testSocketObserveQuizResults(quiz int64) (QuizResults, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/quizzes/%d/results", quiz),
+		nil,
+	)
+	var resp QuizResults
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketExportQuizResults(quiz int64, query string) ([]byte, error) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("/socket/v0/quizzes/%d/results/export?%s", quiz, query),
+		nil,
+	)
+	rec := httptest.NewRecorder()
+	if err := testHandler(req, rec); err != nil {
+		return nil, err
+	}
+	if rec.Code != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", rec.Code)
+	}
+	return rec.Body.Bytes(), nil
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// ProblemRevision represents revision of problem.
+type ProblemRevision struct {
+	// ID contains ID of problem event.
+	ID int64 `json:"id"`
+	// Kind contains kind of problem event.
+	Kind string `json:"kind"`
+	// Time contains time of problem event.
+	Time int64 `json:"time"`
+	// AccountID contains ID of account that made revision.
+	AccountID int64 `json:"account_id,omitempty"`
+	// Author contains user that made revision.
+	Author *User `json:"author,omitempty"`
+}
+
+type problemRevisionSorter []ProblemRevision
+
+func (v problemRevisionSorter) Len() int {
+	return len(v)
+}
+
+func (v problemRevisionSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v problemRevisionSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// ProblemRevisions represents problem revisions response.
+type ProblemRevisions struct {
+	Revisions []ProblemRevision `json:"revisions"`
+}
+
+// ProblemRevisionChange represents changed field of problem.
+type ProblemRevisionChange struct {
+	// Field contains name of changed field.
+	Field string `json:"field"`
+	// Old contains value of field in base revision.
+	Old json.RawMessage `json:"old,omitempty"`
+	// New contains value of field in revision.
+	New json.RawMessage `json:"new,omitempty"`
+}
+
+// ProblemRevisionDiff represents difference between two revisions
+// of problem.
+type ProblemRevisionDiff struct {
+	// From contains ID of base revision.
+	From int64 `json:"from,omitempty"`
+	// To contains ID of revision.
+	To int64 `json:"to"`
+	// Changes contains changed fields.
+	Changes []ProblemRevisionChange `json:"changes"`
+}
+
+// registerProblemRevisionHandlers registers handlers for problem
+// revisions.
+func (v *View) registerProblemRevisionHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/problems/:problem/revisions", v.observeProblemRevisions,
+		v.extractAuth(v.sessionAuth), v.extractProblem,
+		v.requirePermission(models.ObserveProblemRevisionsRole),
+	)
+	g.GET(
+		"/v0/problems/:problem/revisions/:revision",
+		v.observeProblemRevision,
+		v.extractAuth(v.sessionAuth), v.extractProblem,
+		v.extractProblemRevision,
+		v.requirePermission(models.ObserveProblemRevisionsRole),
+	)
+	g.GET(
+		"/v0/problems/:problem/revisions/:revision/diff",
+		v.observeProblemRevisionDiff,
+		v.extractAuth(v.sessionAuth), v.extractProblem,
+		v.extractProblemRevision,
+		v.requirePermission(models.ObserveProblemRevisionsRole),
+	)
+}
+
+// registerSocketProblemRevisionHandlers registers socket handlers for
+// problem revisions.
+func (v *View) registerSocketProblemRevisionHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/problems/:problem/revisions", v.observeProblemRevisions,
+		v.extractProblem,
+	)
+	g.GET(
+		"/v0/problems/:problem/revisions/:revision",
+		v.observeProblemRevision,
+		v.extractProblem, v.extractProblemRevision,
+	)
+	g.GET(
+		"/v0/problems/:problem/revisions/:revision/diff",
+		v.observeProblemRevisionDiff,
+		v.extractProblem, v.extractProblemRevision,
+	)
+}
+
+func (v *View) makeProblemRevision(
+	event models.ProblemEvent, permissions managers.Permissions,
+) ProblemRevision {
+	resp := ProblemRevision{
+		ID:        event.EventID(),
+		Kind:      event.EventKind().String(),
+		Time:      event.EventTime().Unix(),
+		AccountID: int64(event.EventAccountID),
+	}
+	if resp.AccountID != 0 {
+		if user, err := v.core.Users.GetByAccount(resp.AccountID); err == nil {
+			userResp := makeUser(user, permissions)
+			resp.Author = &userResp
+		}
+	}
+	return resp
+}
+
+func (v *View) observeProblemRevisions(c echo.Context) error {
+	problem, ok := c.Get(problemKey).(models.Problem)
+	if !ok {
+		c.Logger().Error("problem not extracted")
+		return fmt.Errorf("problem not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	events, err := v.core.Problems.FindObjectEvents(getContext(c), problem.ID)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := ProblemRevisions{Revisions: []ProblemRevision{}}
+	for _, event := range events {
+		resp.Revisions = append(
+			resp.Revisions, v.makeProblemRevision(event, permissions),
+		)
+	}
+	sort.Sort(problemRevisionSorter(resp.Revisions))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observeProblemRevision(c echo.Context) error {
+	event, ok := c.Get(problemRevisionKey).(models.ProblemEvent)
+	if !ok {
+		c.Logger().Error("revision not extracted")
+		return fmt.Errorf("revision not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	resp, err := makeProblem(event.Object(), permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// observeProblemRevisionDiff returns changes of problem between
+// base revision and extracted revision.
+//
+// Base revision is specified by "base" query parameter, by default
+// the previous revision is used.
+func (v *View) observeProblemRevisionDiff(c echo.Context) error {
+	event, ok := c.Get(problemRevisionKey).(models.ProblemEvent)
+	if !ok {
+		c.Logger().Error("revision not extracted")
+		return fmt.Errorf("revision not extracted")
+	}
+	permissions, ok := c.Get(permissionCtxKey).(managers.Permissions)
+	if !ok {
+		c.Logger().Error("permissions not extracted")
+		return fmt.Errorf("permissions not extracted")
+	}
+	events, err := v.core.Problems.FindObjectEvents(
+		getContext(c), event.ObjectID(),
+	)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	var base *models.ProblemEvent
+	if param := c.QueryParam("base"); len(param) > 0 {
+		id, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid base revision ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		for i := range events {
+			if events[i].EventID() == id {
+				base = &events[i]
+				break
+			}
+		}
+		if base == nil {
+			resp := errorResponse{
+				Message: fmt.Sprintf("revision %d not found", id),
+			}
+			return c.JSON(http.StatusNotFound, resp)
+		}
+	} else {
+		for i := range events {
+			if events[i].EventID() >= event.EventID() {
+				break
+			}
+			base = &events[i]
+		}
+	}
+	resp := ProblemRevisionDiff{
+		To:      event.EventID(),
+		Changes: []ProblemRevisionChange{},
+	}
+	newFields, err := getProblemRevisionFields(event, permissions)
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	oldFields := map[string]json.RawMessage{}
+	if base != nil {
+		resp.From = base.EventID()
+		oldFields, err = getProblemRevisionFields(*base, permissions)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+	}
+	for field, value := range newFields {
+		if old, ok := oldFields[field]; !ok || !bytes.Equal(old, value) {
+			resp.Changes = append(resp.Changes, ProblemRevisionChange{
+				Field: field,
+				Old:   old,
+				New:   value,
+			})
+		}
+	}
+	for field, old := range oldFields {
+		if _, ok := newFields[field]; !ok {
+			resp.Changes = append(resp.Changes, ProblemRevisionChange{
+				Field: field,
+				Old:   old,
+			})
+		}
+	}
+	sort.Slice(resp.Changes, func(i, j int) bool {
+		return resp.Changes[i].Field < resp.Changes[j].Field
+	})
+	return c.JSON(http.StatusOK, resp)
+}
+
+// getProblemRevisionFields returns fields of problem revision as they
+// are represented in API.
+func getProblemRevisionFields(
+	event models.ProblemEvent, permissions managers.Permissions,
+) (map[string]json.RawMessage, error) {
+	if event.EventKind() == models.DeleteEvent {
+		return map[string]json.RawMessage{}, nil
+	}
+	problem, err := makeProblem(event.Object(), permissions)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(problem)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (v *View) extractProblemRevision(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseInt(c.Param("revision"), 10, 64)
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid revision ID"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		problem, ok := c.Get(problemKey).(models.Problem)
+		if !ok {
+			c.Logger().Error("problem not extracted")
+			return fmt.Errorf("problem not extracted")
+		}
+		events, err := v.core.Problems.FindObjectEvents(
+			getContext(c), problem.ID,
+		)
+		if err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		for _, event := range events {
+			if event.EventID() == id {
+				c.Set(problemRevisionKey, event)
+				return next(c)
+			}
+		}
+		resp := errorResponse{
+			Message: fmt.Sprintf("revision %d not found", id),
+		}
+		return c.JSON(http.StatusNotFound, resp)
+	}
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemRevisionSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:    getPtr("Quiz"),
+		Sections: &[]QuizSection{{Points: 1, Problems: []int64{problem.ID}}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCreateUser(t, "test", "qwerty123")
+	client := newTestClient(testSrv.URL + "/api")
+	if _, err := client.Login("test", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := client.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketUpdateProblem(problem.ID, updateProblemForm{
+		Statement: getPtr("2 + 3"),
+		Answer:    &ProblemAnswer{Value: 5},
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if problems, err := client.ObserveQuizAttemptProblems(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(problems)
+	}
+	if _, err := client.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, problem.ID,
+		updateQuizAttemptAnswerForm{Value: getPtr(4.0)},
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if finished, err := client.FinishQuizAttempt(
+		quiz.ID, attempt.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearQuizAttempt(finished))
+	}
+	if _, err := client.ObserveProblemRevisions(problem.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	revisions, err := testSocketObserveProblemRevisions(problem.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(revisions.Revisions) != 2 {
+		t.Fatalf("Expected %d revisions, got %d", 2, len(revisions.Revisions))
+	}
+	testCheck(testClearProblemRevisions(revisions))
+	first := revisions.Revisions[1].ID
+	last := revisions.Revisions[0].ID
+	if revision, err := testSocketObserveProblemRevision(
+		problem.ID, first,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(revision)
+	}
+	if _, err := testSocketObserveProblemRevision(
+		problem.ID, last+100,
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if diff, err := testSocketObserveProblemRevisionDiff(
+		problem.ID, last, "",
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(diff)
+	}
+	if diff, err := testSocketObserveProblemRevisionDiff(
+		problem.ID, first, "",
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(diff)
+	}
+	if diff, err := testSocketObserveProblemRevisionDiff(
+		problem.ID, first, fmt.Sprint(last),
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(diff)
+	}
+}
+
+func TestQuizAttemptAnswerDeletedProblem(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	problem, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title:    getPtr("Quiz"),
+		Sections: &[]QuizSection{{Points: 1, Problems: []int64{problem.ID}}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCreateUser(t, "test", "qwerty123")
+	client := newTestClient(testSrv.URL + "/api")
+	if _, err := client.Login("test", "qwerty123"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	attempt, err := client.CreateQuizAttempt(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if _, err := testSocketDeleteProblem(problem.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	if answer, err := client.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, problem.ID,
+		updateQuizAttemptAnswerForm{Value: getPtr(4.0)},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(answer)
+	}
+	if _, err := client.UpdateQuizAttemptAnswer(
+		quiz.ID, attempt.ID, problem.ID+100,
+		updateQuizAttemptAnswerForm{Value: getPtr(4.0)},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+}
+
+// testClearProblemRevisions clears timestamps of revisions.
+//
+// Canonical tests does not support current timestamps.
+func testClearProblemRevisions(revisions ProblemRevisions) ProblemRevisions {
+	for i := range revisions.Revisions {
+		revisions.Revisions[i].Time = 0
+	}
+	return revisions
+}
+
+func (c *testClient) ObserveProblemRevisions(
+	problem int64,
+) (ProblemRevisions, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/problems/%d/revisions", problem), nil,
+	)
+	if err != nil {
+		return ProblemRevisions{}, err
+	}
+	var respData ProblemRevisions
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func testSocketObserveProblemRevisions(
+	problem int64,
+) (ProblemRevisions, error) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("/socket/v0/problems/%d/revisions", problem), nil,
+	)
+	var resp ProblemRevisions
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveProblemRevision(
+	problem, revision int64,
+) (Problem, error) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("/socket/v0/problems/%d/revisions/%d", problem, revision),
+		nil,
+	)
+	var resp Problem
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveProblemRevisionDiff(
+	problem, revision int64, base string,
+) (ProblemRevisionDiff, error) {
+	path := fmt.Sprintf(
+		"/socket/v0/problems/%d/revisions/%d/diff", problem, revision,
+	)
+	if len(base) > 0 {
+		path += "?base=" + base
+	}
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	var resp ProblemRevisionDiff
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/models"
+)
+
+// ScheduledJob represents recurring job.
+type ScheduledJob struct {
+	// Name contains unique name of job.
+	Name string `json:"name"`
+	// Schedule contains schedule of job.
+	Schedule string `json:"schedule"`
+	// Kind contains kind of enqueued task.
+	Kind models.TaskKind `json:"kind"`
+	// Config contains config of enqueued task.
+	Config json.RawMessage `json:"config,omitempty"`
+	// LastTime contains time of last run of job.
+	LastTime int64 `json:"last_time,omitempty"`
+	// NextTime contains time of next run of job.
+	NextTime int64 `json:"next_time"`
+}
+
+// ScheduledJobs represents scheduled jobs response.
+type ScheduledJobs struct {
+	Jobs []ScheduledJob `json:"jobs"`
+}
+
+// registerSocketSchedulerHandlers registers socket handlers for
+// recurring jobs.
+func (v *View) registerSocketSchedulerHandlers(g *echo.Group) {
+	g.GET("/v0/scheduler/jobs", v.observeScheduledJobs)
+}
+
+func (v *View) observeScheduledJobs(c echo.Context) error {
+	statuses, err := v.core.Scheduler.Status(getContext(c), time.Now())
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := ScheduledJobs{Jobs: []ScheduledJob{}}
+	for _, status := range statuses {
+		job := ScheduledJob{
+			Name:     status.Name,
+			Schedule: status.Schedule.String(),
+			Kind:     status.Kind,
+			NextTime: status.NextTime.Unix(),
+		}
+		if len(status.Config) > 0 {
+			job.Config = json.RawMessage(status.Config)
+		}
+		if !status.LastTime.IsZero() {
+			job.LastTime = status.LastTime.Unix()
+		}
+		resp.Jobs = append(resp.Jobs, job)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/udovin/goquiz/core"
+	"github.com/udovin/goquiz/models"
+)
+
+func TestSchedulerSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	if jobs, err := testSocketObserveScheduledJobs(); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(testClearScheduledJobs(jobs))
+	}
+	schedule, err := core.ParseSchedule("every 10 minutes")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testView.core.Scheduler.Register(core.RecurringJob{
+		Name:     "test",
+		Schedule: schedule,
+		Kind:     models.CleanupSessionsTask,
+		Config:   models.JSON(`{"days":30}`),
+	})
+	if jobs, err := testSocketObserveScheduledJobs(); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(jobs.Jobs) != 4 || jobs.Jobs[3].NextTime == 0 {
+		t.Fatalf("Unexpected jobs: %v", jobs)
+	} else {
+		testCheck(testClearScheduledJobs(jobs))
+	}
+}
+
+// testClearScheduledJobs clears timestamps of scheduled jobs.
+//
+// Canonical tests does not support current timestamps.
+func testClearScheduledJobs(jobs ScheduledJobs) ScheduledJobs {
+	for i := range jobs.Jobs {
+		jobs.Jobs[i].LastTime = 0
+		jobs.Jobs[i].NextTime = 0
+	}
+	return jobs
+}
+
+func testSocketObserveScheduledJobs() (ScheduledJobs, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, "/socket/v0/scheduler/jobs", nil,
+	)
+	var resp ScheduledJobs
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func TestSessionExpiration(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	ctx := context.Background()
+	testCreateUser(t, "student", "qwerty123")
+	client := newTestClient(testSrv.URL + "/api")
+	login, err := client.Login("student", "qwerty123")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := testView.core.Settings.Create(ctx, &models.Setting{
+		Key: "sessions.sliding_expiration", Value: "true",
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := testView.core.Settings.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	testUpdateSessionExpireTime(t, login.ID, time.Now().Add(10*24*time.Hour))
+	// Active session is extended.
+	if _, err := client.Status(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := testView.core.Sessions.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	session, err := testView.core.Sessions.Get(login.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if expires := time.Now().Add(sessionLifetime - time.Hour); session.ExpireTime < expires.Unix() {
+		t.Fatalf("Expected extended session, got %d", session.ExpireTime)
+	}
+	// Recently extended session is not updated.
+	if _, err := client.Status(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if events, err := testView.core.Sessions.FindObjectEvents(
+		ctx, login.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(events))
+	}
+	// Expired session is rejected.
+	testUpdateSessionExpireTime(t, login.ID, time.Now().Add(-time.Second))
+	if status, err := client.Status(); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(status)
+	}
+	// Expired session is deleted by cleanup task.
+	if _, err := testView.Tasks.Enqueue(
+		ctx, models.CleanupSessionsTask, nil,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !testView.Tasks.Process(ctx) {
+		t.Fatal("Expected processed task")
+	}
+	if err := testView.core.Sessions.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := testView.core.Sessions.Get(login.ID); err != sql.ErrNoRows {
+		t.Fatalf("Expected %v, got %v", sql.ErrNoRows, err)
+	}
+	if events, err := testView.core.Sessions.FindObjectEvents(
+		ctx, login.ID,
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else if kind := events[len(events)-1].EventKind(); kind != models.DeleteEvent {
+		t.Fatalf("Expected %v, got %v", models.DeleteEvent, kind)
+	}
+}
+
+func testUpdateSessionExpireTime(tb testing.TB, id int64, expires time.Time) {
+	ctx := context.Background()
+	if err := testView.core.Sessions.Sync(ctx); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	session, err := testView.core.Sessions.Get(id)
+	if err != nil {
+		tb.Fatal("Error:", err)
+	}
+	session.ExpireTime = expires.Unix()
+	if err := testView.core.Sessions.Update(ctx, session); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	if err := testView.core.Sessions.Sync(ctx); err != nil {
+		tb.Fatal("Error:", err)
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/models"
+)
+
+// Setting represents setting.
+type Setting struct {
+	// ID contains setting ID.
+	ID int64 `json:"id"`
+	// Key contains setting key.
+	Key string `json:"key"`
+	// Value contains setting value.
+	Value string `json:"value"`
+}
+
+type settingSorter []Setting
+
+func (v settingSorter) Len() int {
+	return len(v)
+}
+
+func (v settingSorter) Less(i, j int) bool {
+	return v[i].ID > v[j].ID
+}
+
+func (v settingSorter) Swap(i, j int) {
+	v[i], v[j] = v[j], v[i]
+}
+
+// Settings represents settings response.
+type Settings struct {
+	Settings []Setting `json:"settings"`
+}
+
+// registerSettingHandlers registers handlers for setting management.
+func (v *View) registerSettingHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/settings", v.observeSettings,
+		v.extractAuth(v.sessionAuth),
+		v.requirePermission(models.ObserveSettingsRole),
+	)
+	g.POST(
+		"/v0/settings", v.createSetting,
+		v.extractAuth(v.sessionAuth),
+		v.requirePermission(models.CreateSettingRole),
+	)
+	g.GET(
+		"/v0/settings/:setting", v.observeSetting,
+		v.extractAuth(v.sessionAuth), v.extractSetting,
+		v.requirePermission(models.ObserveSettingsRole),
+	)
+	g.PATCH(
+		"/v0/settings/:setting", v.updateSetting,
+		v.extractAuth(v.sessionAuth), v.extractSetting,
+		v.requirePermission(models.UpdateSettingRole),
+	)
+	g.DELETE(
+		"/v0/settings/:setting", v.deleteSetting,
+		v.extractAuth(v.sessionAuth), v.extractSetting,
+		v.requirePermission(models.DeleteSettingRole),
+	)
+}
+
+// registerSocketSettingHandlers registers socket handlers for setting
+// management.
+func (v *View) registerSocketSettingHandlers(g *echo.Group) {
+	g.GET("/v0/settings", v.observeSettings)
+	g.POST("/v0/settings", v.createSetting)
+	g.GET("/v0/settings/:setting", v.observeSetting, v.extractSetting)
+	g.PATCH("/v0/settings/:setting", v.updateSetting, v.extractSetting)
+	g.DELETE("/v0/settings/:setting", v.deleteSetting, v.extractSetting)
+}
+
+func makeSetting(setting models.Setting) Setting {
+	return Setting{
+		ID:    setting.ID,
+		Key:   setting.Key,
+		Value: setting.Value,
+	}
+}
+
+func (v *View) observeSettings(c echo.Context) error {
+	// Settings are synced, so recent changes are visible right after
+	// they are made.
+	if err := v.core.Settings.Sync(getContext(c)); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	settings, err := v.core.Settings.All()
+	if err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	resp := Settings{Settings: []Setting{}}
+	for _, setting := range settings {
+		resp.Settings = append(resp.Settings, makeSetting(setting))
+	}
+	sort.Sort(settingSorter(resp.Settings))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observeSetting(c echo.Context) error {
+	setting, ok := c.Get(settingKey).(models.Setting)
+	if !ok {
+		c.Logger().Error("setting not extracted")
+		return fmt.Errorf("setting not extracted")
+	}
+	return c.JSON(http.StatusOK, makeSetting(setting))
+}
+
+// updateSettingForm represents form for creating and updating setting.
+type updateSettingForm struct {
+	Key   *string `json:"key"`
+	Value *string `json:"value"`
+}
+
+func (f updateSettingForm) Update(
+	setting *models.Setting, settings *models.SettingStore,
+) *errorResponse {
+	if f.Key != nil {
+		setting.Key = *f.Key
+	}
+	if f.Value != nil {
+		setting.Value = *f.Value
+	}
+	errors := errorFields{}
+	if len(setting.Key) == 0 {
+		errors["key"] = errorField{Message: "key should not be empty"}
+	} else if len(setting.Key) > 256 {
+		errors["key"] = errorField{Message: "key too long (>256)"}
+	} else if _, err := strconv.ParseInt(setting.Key, 10, 64); err == nil {
+		// Numeric keys can not be addressed, because they are
+		// treated as setting IDs.
+		errors["key"] = errorField{Message: "key should not be numeric"}
+	}
+	if len(setting.Value) > 65536 {
+		errors["value"] = errorField{Message: "value too long (>65536)"}
+	}
+	if len(errors) > 0 {
+		return &errorResponse{
+			Message:       "passed invalid fields to form",
+			InvalidFields: errors,
+		}
+	}
+	if other, err := settings.GetByKey(setting.Key); err != sql.ErrNoRows {
+		if err != nil {
+			return &errorResponse{Message: "unknown error"}
+		}
+		if other.ID != setting.ID {
+			return &errorResponse{
+				Message: fmt.Sprintf("setting %q already exists", setting.Key),
+			}
+		}
+	}
+	return nil
+}
+
+func (v *View) createSetting(c echo.Context) error {
+	var form updateSettingForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	if err := v.core.Settings.Sync(getContext(c)); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	var setting models.Setting
+	if err := form.Update(&setting, v.core.Settings); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if err := v.core.Settings.Create(getContext(c), &setting); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusCreated, makeSetting(setting))
+}
+
+func (v *View) updateSetting(c echo.Context) error {
+	setting, ok := c.Get(settingKey).(models.Setting)
+	if !ok {
+		c.Logger().Error("setting not extracted")
+		return fmt.Errorf("setting not extracted")
+	}
+	var form updateSettingForm
+	if err := c.Bind(&form); err != nil {
+		c.Logger().Warn(err)
+		return c.NoContent(http.StatusBadRequest)
+	}
+	if err := v.core.Settings.Sync(getContext(c)); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	if err := form.Update(&setting, v.core.Settings); err != nil {
+		return c.JSON(http.StatusBadRequest, err)
+	}
+	if err := v.core.Settings.Update(getContext(c), setting); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, makeSetting(setting))
+}
+
+func (v *View) deleteSetting(c echo.Context) error {
+	setting, ok := c.Get(settingKey).(models.Setting)
+	if !ok {
+		c.Logger().Error("setting not extracted")
+		return fmt.Errorf("setting not extracted")
+	}
+	if err := v.core.Settings.Delete(getContext(c), setting.ID); err != nil {
+		c.Logger().Error(err)
+		return err
+	}
+	return c.JSON(http.StatusOK, makeSetting(setting))
+}
+
+// getSettingByParam returns setting by ID or by key.
+func getSettingByParam(
+	settings *models.SettingStore, param string,
+) (models.Setting, error) {
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return settings.GetByKey(param)
+	}
+	return settings.Get(id)
+}
+
+func (v *View) extractSetting(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Keys of settings can contain slashes, so they should be
+		// escaped in path.
+		param, err := url.PathUnescape(c.Param("setting"))
+		if err != nil {
+			c.Logger().Warn(err)
+			resp := errorResponse{Message: "invalid setting key"}
+			return c.JSON(http.StatusBadRequest, resp)
+		}
+		// Settings are synced, so recent changes are visible right
+		// after they are made.
+		if err := v.core.Settings.Sync(getContext(c)); err != nil {
+			c.Logger().Error(err)
+			return err
+		}
+		setting, err := getSettingByParam(v.core.Settings, param)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				resp := errorResponse{
+					Message: fmt.Sprintf("setting %q not found", param),
+				}
+				return c.JSON(http.StatusNotFound, resp)
+			}
+			c.Logger().Error(err)
+			return err
+		}
+		c.Set(settingKey, setting)
+		return next(c)
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSettingSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	setting, err := testSocketCreateSetting(updateSettingForm{
+		Key:   getPtr("sessions.sliding_expiration"),
+		Value: getPtr("true"),
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(setting)
+	if _, err := testSocketCreateSetting(updateSettingForm{
+		Key: getPtr(""),
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketCreateSetting(updateSettingForm{
+		Key:   getPtr("123"),
+		Value: getPtr("true"),
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if _, err := testSocketCreateSetting(updateSettingForm{
+		Key:   getPtr("sessions.sliding_expiration"),
+		Value: getPtr("false"),
+	}); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if resp, err := testSocketUpdateSetting(
+		"sessions.sliding_expiration", updateSettingForm{
+			Value: getPtr("false"),
+		},
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	testSyncSettings(t)
+	if resp, err := testSocketObserveSettings(); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	if resp, err := testSocketObserveSetting(
+		"sessions.sliding_expiration",
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	if _, err := testSocketUpdateSetting(
+		"not.found", updateSettingForm{Value: getPtr("1")},
+	); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	if resp, err := testSocketDeleteSetting(
+		fmt.Sprint(setting.ID),
+	); err != nil {
+		t.Fatal("Error:", err)
+	} else {
+		testCheck(resp)
+	}
+	testSyncSettings(t)
+	if _, err := testSocketDeleteSetting(fmt.Sprint(setting.ID)); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	// Settings are not available for guests.
+	req := httptest.NewRequest(http.MethodGet, "/api/v0/settings", nil)
+	rec := httptest.NewRecorder()
+	if err := testHandler(req, rec); err != nil {
+		t.Fatal("Error:", err)
+	}
+	expectStatus(t, http.StatusForbidden, rec.Code)
+}
+
+func testSyncSettings(tb testing.TB) {
+	if err := testView.core.Settings.Sync(context.Background()); err != nil {
+		tb.Fatal("Error:", err)
+	}
+}
+
+func testSocketObserveSettings() (Settings, error) {
+	req := httptest.NewRequest(http.MethodGet, "/socket/v0/settings", nil)
+	var resp Settings
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveSetting(setting string) (Setting, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/settings/%s", setting), nil,
+	)
+	var resp Setting
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketCreateSetting(form updateSettingForm) (Setting, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Setting{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPost, "/socket/v0/settings", bytes.NewReader(data),
+	)
+	var resp Setting
+	err = doSocketRequest(req, http.StatusCreated, &resp)
+	return resp, err
+}
+
+func testSocketUpdateSetting(
+	setting string, form updateSettingForm,
+) (Setting, error) {
+	data, err := json.Marshal(form)
+	if err != nil {
+		return Setting{}, err
+	}
+	req := httptest.NewRequest(
+		http.MethodPatch, fmt.Sprintf("/socket/v0/settings/%s", setting),
+		bytes.NewReader(data),
+	)
+	var resp Setting
+	err = doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketDeleteSetting(setting string) (Setting, error) {
+	req := httptest.NewRequest(
+		http.MethodDelete, fmt.Sprintf("/socket/v0/settings/%s", setting),
+		nil,
+	)
+	var resp Setting
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
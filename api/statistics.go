@@ -0,0 +1,150 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/udovin/goquiz/managers"
+	"github.com/udovin/goquiz/models"
+)
+
+// ProblemOptionStatistics represents statistics of choice option.
+type ProblemOptionStatistics struct {
+	// Option contains original index of option.
+	Option int `json:"option"`
+	// Text contains current text of option.
+	Text string `json:"text,omitempty"`
+	// Count contains amount of responses that selected option.
+	Count int `json:"count"`
+	// Frequency contains fraction of responses that selected option.
+	Frequency float64 `json:"frequency"`
+}
+
+// ProblemStatistics represents item statistics of problem.
+type ProblemStatistics struct {
+	// ProblemID contains ID of problem.
+	ProblemID int64 `json:"problem_id"`
+	// Responses contains amount of graded responses.
+	Responses int `json:"responses"`
+	// Difficulty contains proportion of correct responses.
+	Difficulty float64 `json:"difficulty"`
+	// Discrimination contains point-biserial correlation between
+	// correctness of response and rest score of attempt.
+	Discrimination *float64 `json:"discrimination,omitempty"`
+	// Options contains statistics of options for choice problems.
+	Options []ProblemOptionStatistics `json:"options,omitempty"`
+	// AverageTime contains average time spent on problem in seconds.
+	AverageTime *float64 `json:"average_time,omitempty"`
+	// UpdateTime contains time of last update of statistics.
+	UpdateTime int64 `json:"update_time,omitempty"`
+}
+
+// QuizStatistics represents item statistics of quiz.
+type QuizStatistics struct {
+	// QuizID contains ID of quiz.
+	QuizID int64 `json:"quiz_id"`
+	// Attempts contains amount of finished attempts.
+	Attempts int `json:"attempts"`
+	// AverageScore contains average score of finished attempts.
+	AverageScore float64 `json:"average_score"`
+	// Problems contains statistics of quiz problems.
+	Problems []ProblemStatistics `json:"problems"`
+	// UpdateTime contains time of last update of statistics.
+	UpdateTime int64 `json:"update_time,omitempty"`
+}
+
+// registerStatisticsHandlers registers handlers for item
+// statistics of problems and quizzes.
+func (v *View) registerStatisticsHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/problems/:problem/statistics", v.observeProblemStatistics,
+		v.extractAuth(v.sessionAuth), v.extractProblem,
+		v.requirePermission(models.ObserveProblemStatisticsRole),
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/statistics", v.observeQuizStatistics,
+		v.extractAuth(v.sessionAuth), v.extractQuiz,
+		v.requirePermission(models.ObserveQuizStatisticsRole),
+	)
+}
+
+// registerSocketStatisticsHandlers registers socket handlers for item
+// statistics of problems and quizzes.
+func (v *View) registerSocketStatisticsHandlers(g *echo.Group) {
+	g.GET(
+		"/v0/problems/:problem/statistics", v.observeProblemStatistics,
+		v.extractProblem,
+	)
+	g.GET(
+		"/v0/quizzes/:quiz/statistics", v.observeQuizStatistics,
+		v.extractQuiz,
+	)
+}
+
+func (v *View) makeProblemStatistics(
+	stats managers.ProblemStatistics,
+) ProblemStatistics {
+	resp := ProblemStatistics{
+		ProblemID:      stats.ProblemID,
+		Responses:      stats.Responses,
+		Difficulty:     stats.Difficulty,
+		Discrimination: stats.Discrimination,
+		AverageTime:    stats.AverageTime,
+	}
+	var config models.ProblemConfig
+	if problem, err := v.core.Problems.Get(stats.ProblemID); err == nil {
+		_ = problem.ScanConfig(&config)
+	}
+	for i, option := range stats.Options {
+		optionResp := ProblemOptionStatistics{
+			Option:    i,
+			Count:     option.Count,
+			Frequency: option.Frequency,
+		}
+		if i < len(config.Options) {
+			optionResp.Text = config.Options[i].Text
+		}
+		resp.Options = append(resp.Options, optionResp)
+	}
+	return resp
+}
+
+func (v *View) observeProblemStatistics(c echo.Context) error {
+	problem, ok := c.Get(problemKey).(models.Problem)
+	if !ok {
+		c.Logger().Error("problem not extracted")
+		return fmt.Errorf("problem not extracted")
+	}
+	stats, updateTime := v.Statistics.GetProblem(problem.ID)
+	resp := v.makeProblemStatistics(stats)
+	if !updateTime.IsZero() {
+		resp.UpdateTime = updateTime.Unix()
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (v *View) observeQuizStatistics(c echo.Context) error {
+	quiz, ok := c.Get(quizKey).(models.Quiz)
+	if !ok {
+		c.Logger().Error("quiz not extracted")
+		return fmt.Errorf("quiz not extracted")
+	}
+	stats, updateTime := v.Statistics.GetQuiz(quiz.ID)
+	resp := QuizStatistics{
+		QuizID:       stats.QuizID,
+		Attempts:     stats.Attempts,
+		AverageScore: stats.AverageScore,
+		Problems:     []ProblemStatistics{},
+	}
+	for _, problemStats := range stats.Problems {
+		resp.Problems = append(
+			resp.Problems, v.makeProblemStatistics(problemStats),
+		)
+	}
+	if !updateTime.IsZero() {
+		resp.UpdateTime = updateTime.Unix()
+	}
+	return c.JSON(http.StatusOK, resp)
+}
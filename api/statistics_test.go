@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatisticsSimpleScenario(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	choice, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("single_choice"),
+		Title:     getPtr("Choice"),
+		Statement: getPtr("Choose B"),
+		Options: &[]ProblemOption{
+			{Text: "A"}, {Text: "B"}, {Text: "C"},
+		},
+		Answer: &ProblemAnswer{Options: []int{1}},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	numeric, err := testSocketCreateProblem(updateProblemForm{
+		Kind:      getPtr("numeric"),
+		Title:     getPtr("Numeric"),
+		Statement: getPtr("2 + 2"),
+		Answer:    &ProblemAnswer{Value: 4},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testSyncManagers(t)
+	quiz, err := testSocketCreateQuiz(updateQuizForm{
+		Title: getPtr("Quiz"),
+		Sections: &[]QuizSection{
+			{Points: 1, Problems: []int64{choice.ID}},
+			{Points: 2, Problems: []int64{numeric.ID}},
+		},
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	answers := []struct {
+		Login  string
+		Option string
+		Value  float64
+	}{
+		{Login: "first", Option: "B", Value: 4},
+		{Login: "second", Option: "B", Value: 5},
+		{Login: "third", Option: "A", Value: 5},
+	}
+	clients := map[string]*testClient{}
+	for _, answer := range answers {
+		testCreateUser(t, answer.Login, "qwerty123")
+		client := newTestClient(testSrv.URL + "/api")
+		if _, err := client.Login(answer.Login, "qwerty123"); err != nil {
+			t.Fatal("Error:", err)
+		}
+		clients[answer.Login] = client
+		attempt, err := client.CreateQuizAttempt(quiz.ID)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		testSyncManagers(t)
+		problems, err := client.ObserveQuizAttemptProblems(quiz.ID, attempt.ID)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		option := -1
+		for i, problemOption := range problems.Problems[0].Options {
+			if problemOption.Text == answer.Option {
+				option = i
+			}
+		}
+		if _, err := client.UpdateQuizAttemptAnswer(
+			quiz.ID, attempt.ID, choice.ID,
+			updateQuizAttemptAnswerForm{Options: []int{option}},
+		); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if _, err := client.UpdateQuizAttemptAnswer(
+			quiz.ID, attempt.ID, numeric.ID,
+			updateQuizAttemptAnswerForm{Value: getPtr(answer.Value)},
+		); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if _, err := client.FinishQuizAttempt(quiz.ID, attempt.ID); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	testSyncManagers(t)
+	testView.Statistics.Update()
+	if _, err := clients["first"].ObserveQuizStatistics(quiz.ID); err == nil {
+		t.Fatal("Expected error")
+	} else {
+		testCheck(err)
+	}
+	stats, err := testSocketObserveQuizStatistics(quiz.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(testClearQuizStatistics(stats))
+	problemStats, err := testSocketObserveProblemStatistics(choice.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	testCheck(testClearProblemStatistics(problemStats))
+}
+
+// testClearProblemStatistics clears timings of problem statistics.
+//
+// Canonical tests does not support current timestamps.
+func testClearProblemStatistics(stats ProblemStatistics) ProblemStatistics {
+	stats.AverageTime = nil
+	stats.UpdateTime = 0
+	return stats
+}
+
+// testClearQuizStatistics clears timings of quiz statistics.
+//
+// Canonical tests does not support current timestamps.
+func testClearQuizStatistics(stats QuizStatistics) QuizStatistics {
+	for i := range stats.Problems {
+		stats.Problems[i] = testClearProblemStatistics(stats.Problems[i])
+	}
+	stats.UpdateTime = 0
+	return stats
+}
+
+func (c *testClient) ObserveQuizStatistics(quiz int64) (QuizStatistics, error) {
+	req, err := http.NewRequest(
+		http.MethodGet, c.getURL("/v0/quizzes/%d/statistics", quiz), nil,
+	)
+	if err != nil {
+		return QuizStatistics{}, err
+	}
+	var respData QuizStatistics
+	err = c.doRequest(req, http.StatusOK, &respData)
+	return respData, err
+}
+
+func testSocketObserveQuizStatistics(quiz int64) (QuizStatistics, error) {
+	req := httptest.NewRequest(
+		http.MethodGet, fmt.Sprintf("/socket/v0/quizzes/%d/statistics", quiz),
+		nil,
+	)
+	var resp QuizStatistics
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
+
+func testSocketObserveProblemStatistics(
+	problem int64,
+) (ProblemStatistics, error) {
+	req := httptest.NewRequest(
+		http.MethodGet,
+		fmt.Sprintf("/socket/v0/problems/%d/statistics", problem), nil,
+	)
+	var resp ProblemStatistics
+	err := doSocketRequest(req, http.StatusOK, &resp)
+	return resp, err
+}
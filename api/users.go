@@ -326,6 +326,14 @@ func (v *View) status(c echo.Context) error {
 	return c.JSON(http.StatusOK, status)
 }
 
+const (
+	// sessionLifetime contains lifetime of session.
+	sessionLifetime = 90 * 24 * time.Hour
+	// sessionRefreshInterval contains minimal interval between
+	// extensions of session with sliding expiration.
+	sessionRefreshInterval = 24 * time.Hour
+)
+
 // loginAccount creates a new session for account.
 func (v *View) loginAccount(c echo.Context) error {
 	accountCtx, ok := c.Get(accountCtxKey).(*managers.AccountContext)
@@ -334,7 +342,7 @@ func (v *View) loginAccount(c echo.Context) error {
 		return fmt.Errorf("auth not extracted")
 	}
 	created := time.Now()
-	expires := created.Add(time.Hour * 24 * 90)
+	expires := created.Add(sessionLifetime)
 	session := models.Session{
 		AccountID:  accountCtx.Account.ID,
 		CreateTime: created.Unix(),
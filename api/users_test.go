@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/udovin/goquiz/models"
 )
 
 var testSimpleUser = registerUserForm{
@@ -71,6 +73,33 @@ func testSyncManagers(tb testing.TB) {
 			if err := testView.core.AccountRoles.Sync(ctx); err != nil {
 				return err
 			}
+			if err := testView.core.Problems.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.Pools.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.PoolProblems.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.Quizes.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.QuizSections.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.QuizAttempts.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.QuizOverrides.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.QuizParticipants.Sync(ctx); err != nil {
+				return err
+			}
+			if err := testView.core.QuizGrades.Sync(ctx); err != nil {
+				return err
+			}
 			return nil
 		},
 		sqlReadOnly,
@@ -94,3 +123,29 @@ func testSocketObserveUserRoles(tb testing.TB, login string) Roles {
 	}
 	return resp
 }
+
+// testCreateUser creates user without registration form.
+//
+// Registration checks email domain, so scenarios that are not related
+// to registration should create users with this function.
+func testCreateUser(tb testing.TB, login, password string) models.User {
+	user := models.User{Login: login}
+	if err := testView.core.Users.SetPassword(&user, password); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	if err := testView.core.WrapTx(
+		context.Background(),
+		func(ctx context.Context) error {
+			account := models.Account{Kind: user.AccountKind()}
+			if err := testView.core.Accounts.Create(ctx, &account); err != nil {
+				return err
+			}
+			user.AccountID = account.ID
+			return testView.core.Users.Create(ctx, &user)
+		},
+	); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	testSyncManagers(tb)
+	return user
+}
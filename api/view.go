@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
 	"github.com/udovin/goquiz/core"
+	"github.com/udovin/goquiz/live"
 	"github.com/udovin/goquiz/managers"
 	"github.com/udovin/goquiz/models"
 	"github.com/udovin/gosql"
@@ -17,8 +19,13 @@ import (
 
 // View represents API view.
 type View struct {
-	core     *core.Core
-	Accounts *managers.AccountManager
+	core       *core.Core
+	Accounts   *managers.AccountManager
+	Statistics *managers.StatisticsManager
+	Live       *live.Manager
+	Tasks      *managers.TaskManager
+	// proctoringLimiter limits amount of proctoring events per attempt.
+	proctoringLimiter *rateLimiter
 }
 
 // Register registers handlers in specified group.
@@ -29,6 +36,21 @@ func (v *View) Register(g *echo.Group) {
 	v.registerUserHandlers(g)
 	v.registerRoleHandlers(g)
 	v.registerSessionHandlers(g)
+	v.registerProblemHandlers(g)
+	v.registerProblemRevisionHandlers(g)
+	v.registerPoolHandlers(g)
+	v.registerImportHandlers(g)
+	v.registerFileHandlers(g)
+	v.registerQuizHandlers(g)
+	v.registerQuizAttemptHandlers(g)
+	v.registerQuizResultHandlers(g)
+	v.registerQuizOverrideHandlers(g)
+	v.registerQuizParticipantHandlers(g)
+	v.registerQuizGradeHandlers(g)
+	v.registerStatisticsHandlers(g)
+	v.registerProctoringHandlers(g)
+	v.registerLiveHandlers(g)
+	v.registerSettingHandlers(g)
 }
 
 func (v *View) RegisterSocket(g *echo.Group) {
@@ -37,6 +59,23 @@ func (v *View) RegisterSocket(g *echo.Group) {
 	g.GET("/health", v.health)
 	v.registerSocketUserHandlers(g)
 	v.registerSocketRoleHandlers(g)
+	// Remaining handlers are authorized with all built-in permissions.
+	g = g.Group("", v.extractAuth(v.socketAuth))
+	v.registerSocketProblemHandlers(g)
+	v.registerSocketProblemRevisionHandlers(g)
+	v.registerSocketPoolHandlers(g)
+	v.registerSocketImportHandlers(g)
+	v.registerSocketFileHandlers(g)
+	v.registerSocketQuizHandlers(g)
+	v.registerSocketQuizAttemptHandlers(g)
+	v.registerSocketQuizResultHandlers(g)
+	v.registerSocketQuizOverrideHandlers(g)
+	v.registerSocketQuizParticipantHandlers(g)
+	v.registerSocketQuizGradeHandlers(g)
+	v.registerSocketStatisticsHandlers(g)
+	v.registerSocketProctoringHandlers(g)
+	v.registerSocketSchedulerHandlers(g)
+	v.registerSocketSettingHandlers(g)
 }
 
 // ping returns pong.
@@ -56,8 +95,14 @@ func (v *View) health(c echo.Context) error {
 // NewView returns a new instance of view.
 func NewView(core *core.Core) *View {
 	return &View{
-		core:     core,
-		Accounts: managers.NewAccountManager(core),
+		core:       core,
+		Accounts:   managers.NewAccountManager(core),
+		Statistics: managers.NewStatisticsManager(core),
+		Live:       live.NewManager(),
+		Tasks:      managers.NewTaskManager(core),
+		proctoringLimiter: newRateLimiter(
+			proctoringEventsBurst, proctoringEventsRate,
+		),
 	}
 }
 
@@ -76,6 +121,16 @@ const (
 	contestParticipantKey = "contest_participant"
 	contestSolutionKey    = "contest_solution"
 	problemKey            = "problem"
+	problemRevisionKey    = "problem_revision"
+	poolKey               = "pool"
+	fileKey               = "file"
+	quizKey               = "quiz"
+	quizAttemptKey        = "quiz_attempt"
+	quizOverrideKey       = "quiz_override"
+	quizParticipantKey    = "quiz_participant"
+	quizGradeKey          = "quiz_grade"
+	liveSessionKey        = "live_session"
+	settingKey            = "setting"
 	solutionKey           = "solution"
 	compilerKey           = "compiler"
 )
@@ -191,6 +246,11 @@ func (v *View) sessionAuth(c echo.Context) (bool, error) {
 		}
 		return false, err
 	}
+	if s := v.getBoolSetting(c, "sessions.sliding_expiration"); s != nil && *s {
+		if err := v.extendSession(c, &session); err != nil {
+			c.Logger().Warn("Unable to extend session: ", err)
+		}
+	}
 	account, err := v.core.Accounts.Get(session.AccountID)
 	if err != nil {
 		return false, err
@@ -205,6 +265,27 @@ func (v *View) sessionAuth(c echo.Context) (bool, error) {
 	return true, nil
 }
 
+// extendSession extends expiration time of session.
+//
+// Session is updated at most once per refresh interval, so active
+// sessions do not produce write on every request.
+func (v *View) extendSession(c echo.Context, session *models.Session) error {
+	expires := time.Now().Add(sessionLifetime).Unix()
+	if expires-session.ExpireTime < int64(sessionRefreshInterval/time.Second) {
+		return nil
+	}
+	updated := *session
+	updated.ExpireTime = expires
+	if err := v.core.Sessions.Update(getContext(c), updated); err != nil {
+		return err
+	}
+	*session = updated
+	cookie := session.Cookie()
+	cookie.Name = sessionCookie
+	c.SetCookie(&cookie)
+	return nil
+}
+
 type userAuthForm struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
@@ -266,6 +347,20 @@ func (v *View) guestAuth(c echo.Context) (bool, error) {
 	return true, nil
 }
 
+// socketAuth authorizes unix socket requests with all built-in
+// permissions, because socket is available only for administrators.
+func (v *View) socketAuth(c echo.Context) (bool, error) {
+	ctx, err := v.Accounts.MakeContext(getContext(c), nil)
+	if err != nil {
+		return false, err
+	}
+	ctx.Permissions = managers.PermissionSet{}
+	ctx.Permissions.AddPermission(models.GetBuiltInRoles()...)
+	c.Set(accountCtxKey, ctx)
+	c.Set(permissionCtxKey, ctx)
+	return true, nil
+}
+
 // requireRole check that user has required roles.
 func (v *View) requirePermission(names ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -119,6 +119,9 @@ func testSetup(tb testing.TB) {
 		Security: &config.Security{
 			PasswordSalt: "qwerty123",
 		},
+		Storage: &config.Storage{
+			Options: config.LocalStorageOptions{Path: tb.TempDir()},
+		},
 	}
 	if _, ok := tb.(*testing.B); ok {
 		cfg.LogLevel = config.LogLevel(log.OFF)
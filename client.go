@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// socketError represents error response of socket API.
+type socketError struct {
+	Code          int    `json:"-"`
+	Message       string `json:"message"`
+	InvalidFields map[string]struct {
+		Message string `json:"message"`
+	} `json:"invalid_fields"`
+}
+
+// Error returns error message with all invalid fields.
+func (e socketError) Error() string {
+	var result strings.Builder
+	result.WriteString(e.Message)
+	if len(e.Message) == 0 {
+		fmt.Fprintf(&result, "unexpected status %d", e.Code)
+	}
+	names := make([]string, 0, len(e.InvalidFields))
+	for name := range e.InvalidFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&result, "\n  %s: %s", name, e.InvalidFields[name].Message)
+	}
+	return result.String()
+}
+
+// socketClient represents client for API on unix socket.
+type socketClient struct {
+	client http.Client
+}
+
+// newSocketClient creates a new client for API on unix socket.
+func newSocketClient(file string) *socketClient {
+	return &socketClient{
+		client: http.Client{
+			Transport: &http.Transport{
+				DialContext: func(
+					ctx context.Context, _, _ string,
+				) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", file)
+				},
+			},
+		},
+	}
+}
+
+// Do sends request to socket API and decodes response to resp.
+//
+// If resp is io.Writer, response body is copied to it as is.
+// Responses with status other than code are returned as errors.
+func (c *socketClient) Do(
+	method, path, contentType string, body io.Reader, code int, resp any,
+) error {
+	req, err := http.NewRequest(method, "http://socket/socket"+path, body)
+	if err != nil {
+		return err
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	httpResp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = httpResp.Body.Close()
+	}()
+	if httpResp.StatusCode != code {
+		respErr := socketError{Code: httpResp.StatusCode}
+		_ = json.NewDecoder(httpResp.Body).Decode(&respErr)
+		return respErr
+	}
+	if resp == nil {
+		return nil
+	}
+	if writer, ok := resp.(io.Writer); ok {
+		_, err := io.Copy(writer, httpResp.Body)
+		return err
+	}
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
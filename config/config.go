@@ -26,6 +26,17 @@ type Config struct {
 	Server *Server `json:"server"`
 	// Security contains security config.
 	Security *Security `json:"security"`
+	// Storage contains file storage config.
+	Storage *Storage `json:"storage,omitempty"`
+	// VisitArchiveDir contains path to directory where old visits are
+	// saved before deletion.
+	//
+	// By default old visits are deleted without saving.
+	VisitArchiveDir string `json:"visit_archive_dir,omitempty"`
+	// TaskWorkers contains amount of workers for background tasks.
+	//
+	// By default one worker is started.
+	TaskWorkers int `json:"task_workers,omitempty"`
 	// LogLevel contains level of logging.
 	//
 	// You can use following values:
@@ -37,6 +48,14 @@ type Config struct {
 	LogLevel LogLevel `json:"log_level,omitempty"`
 }
 
+// GetTaskWorkers returns amount of workers for background tasks.
+func (c Config) GetTaskWorkers() int {
+	if c.TaskWorkers <= 0 {
+		return 1
+	}
+	return c.TaskWorkers
+}
+
 // Server contains server config.
 type Server struct {
 	// Host contains server host.
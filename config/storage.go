@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/udovin/goquiz/files"
+)
+
+// StorageDriver represents file storage driver name.
+type StorageDriver string
+
+const (
+	// LocalStorageDriver represents local filesystem storage driver.
+	LocalStorageDriver StorageDriver = "local"
+	// S3StorageDriver represents S3-compatible storage driver.
+	S3StorageDriver StorageDriver = "s3"
+)
+
+// LocalStorageOptions stores local filesystem storage options.
+type LocalStorageOptions struct {
+	// Path contains path to directory with files.
+	Path string `json:"path"`
+}
+
+// S3StorageOptions stores S3-compatible storage options.
+type S3StorageOptions struct {
+	// Endpoint contains base URL of storage API.
+	Endpoint string `json:"endpoint"`
+	// Region contains region of bucket.
+	Region string `json:"region,omitempty"`
+	// Bucket contains name of bucket.
+	Bucket string `json:"bucket"`
+	// Prefix contains prefix for keys of objects.
+	Prefix string `json:"prefix,omitempty"`
+	// AccessKeyID contains ID of access key.
+	AccessKeyID string `json:"access_key_id"`
+	// SecretAccessKey contains secret of access key.
+	SecretAccessKey string `json:"secret_access_key"`
+}
+
+// Storage stores configuration for file storage.
+type Storage struct {
+	// Options contains options for storage driver.
+	//
+	// For LocalStorageDriver field should contains LocalStorageOptions.
+	// For S3StorageDriver field should contains S3StorageOptions.
+	Options any
+}
+
+// UnmarshalJSON parses JSON to create appropriate storage configuration.
+func (c *Storage) UnmarshalJSON(bytes []byte) error {
+	var cfg struct {
+		Driver  StorageDriver   `json:"driver"`
+		Options json.RawMessage `json:"options"`
+	}
+	if err := json.Unmarshal(bytes, &cfg); err != nil {
+		return err
+	}
+	switch cfg.Driver {
+	case LocalStorageDriver:
+		var options LocalStorageOptions
+		if err := json.Unmarshal(cfg.Options, &options); err != nil {
+			return err
+		}
+		c.Options = options
+	case S3StorageDriver:
+		var options S3StorageOptions
+		if err := json.Unmarshal(cfg.Options, &options); err != nil {
+			return err
+		}
+		c.Options = options
+	default:
+		return fmt.Errorf("driver %q is not supported", cfg.Driver)
+	}
+	return nil
+}
+
+func (c Storage) MarshalJSON() ([]byte, error) {
+	cfg := struct {
+		Driver  StorageDriver `json:"driver"`
+		Options any           `json:"options"`
+	}{
+		Options: c.Options,
+	}
+	switch t := c.Options.(type) {
+	case LocalStorageOptions:
+		cfg.Driver = LocalStorageDriver
+	case S3StorageOptions:
+		cfg.Driver = S3StorageDriver
+	default:
+		return nil, fmt.Errorf("options of type %T is not supported", t)
+	}
+	return json.Marshal(cfg)
+}
+
+// Create creates file storage using current configuration.
+func (c *Storage) Create() (files.Storage, error) {
+	switch v := c.Options.(type) {
+	case LocalStorageOptions:
+		return files.NewLocalStorage(v.Path), nil
+	case S3StorageOptions:
+		return files.NewS3Storage(files.S3Options{
+			Endpoint:        v.Endpoint,
+			Region:          v.Region,
+			Bucket:          v.Bucket,
+			Prefix:          v.Prefix,
+			AccessKeyID:     v.AccessKeyID,
+			SecretAccessKey: v.SecretAccessKey,
+		}), nil
+	default:
+		return nil, errors.New("unsupported storage config type")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/labstack/gommon/log"
 
 	"github.com/udovin/goquiz/config"
+	"github.com/udovin/goquiz/files"
 	"github.com/udovin/goquiz/models"
 	"github.com/udovin/gosql"
 	"github.com/udovin/solve/db"
@@ -34,12 +35,40 @@ type Core struct {
 	Users *models.UserStore
 	// Visits contains visit store.
 	Visits *models.VisitStore
-	//
+	// VisitRollups contains store for aggregated visits.
+	VisitRollups *models.VisitRollupStore
+	// Quizes contains quiz store.
 	Quizes *models.QuizStore
-	//
+	// QuizSections contains quiz section store.
+	QuizSections *models.QuizSectionStore
+	// QuizAttempts contains quiz attempt store.
+	QuizAttempts *models.QuizAttemptStore
+	// QuizOverrides contains quiz override store.
+	QuizOverrides *models.QuizOverrideStore
+	// QuizParticipants contains quiz participant store.
+	QuizParticipants *models.QuizParticipantStore
+	// QuizGrades contains quiz grade store.
+	QuizGrades *models.QuizGradeStore
+	// ProctoringEvents contains proctoring event store.
+	ProctoringEvents *models.ProctoringEventStore
+	// Pools contains pool store.
 	Pools *models.PoolStore
-	//
+	// PoolProblems contains pool problem store.
+	PoolProblems *models.PoolProblemStore
+	// Problems contains problem store.
 	Problems *models.ProblemStore
+	// Files contains file store.
+	Files *models.FileStore
+	// Tasks contains task store.
+	Tasks *models.TaskStore
+	// ScheduledJobs contains store for runs of recurring jobs.
+	ScheduledJobs *models.ScheduledJobStore
+	// Scheduler contains scheduler of recurring jobs.
+	Scheduler *Scheduler
+	// FileStorage contains storage for file contents.
+	//
+	// FileStorage is nil when storage is not configured.
+	FileStorage files.Storage
 	//
 	context context.Context
 	cancel  context.CancelFunc
@@ -59,7 +88,18 @@ func NewCore(cfg config.Config) (*Core, error) {
 	logger := log.New("core")
 	logger.SetLevel(log.Lvl(cfg.LogLevel))
 	logger.EnableColor()
-	return &Core{Config: cfg, DB: conn, logger: logger}, nil
+	var storage files.Storage
+	if cfg.Storage != nil {
+		storage, err = cfg.Storage.Create()
+		if err != nil {
+			return nil, err
+		}
+	}
+	c := Core{
+		Config: cfg, DB: conn, FileStorage: storage, logger: logger,
+	}
+	c.Scheduler = newScheduler(&c)
+	return &c, nil
 }
 
 // Logger returns logger instance.
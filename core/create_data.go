@@ -47,6 +47,10 @@ func CreateData(ctx context.Context, c *Core) error {
 		models.RegisterRole,
 		models.StatusRole,
 		models.ObserveUserRole,
+		models.ObserveQuizzesRole,
+		models.ObserveQuizRole,
+		models.ObserveQuizLeaderboardRole,
+		models.ObserveFileRole,
 	} {
 		if err := join(role, "guest_group"); err != nil {
 			return err
@@ -57,6 +61,11 @@ func CreateData(ctx context.Context, c *Core) error {
 		models.LogoutRole,
 		models.StatusRole,
 		models.ObserveUserRole,
+		models.ObserveQuizzesRole,
+		models.ObserveQuizRole,
+		models.ObserveQuizLeaderboardRole,
+		models.CreateQuizAttemptRole,
+		models.ObserveFileRole,
 	} {
 		if err := join(role, "user_group"); err != nil {
 			return err
@@ -0,0 +1,356 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/udovin/goquiz/models"
+)
+
+const (
+	// schedulerInterval contains interval between checks of schedules.
+	schedulerInterval = 10 * time.Second
+	// schedulerSettingPrefix contains prefix of settings with jobs.
+	schedulerSettingPrefix = "scheduler.jobs."
+)
+
+// Schedule represents schedule of recurring job.
+//
+// All schedules use UTC, so servers in different time zones produce
+// the same ticks.
+type Schedule interface {
+	// Prev returns last tick of schedule before or at specified time.
+	Prev(now time.Time) time.Time
+	// Next returns first tick of schedule after specified time.
+	Next(now time.Time) time.Time
+	// String returns string representation of schedule.
+	String() string
+}
+
+// intervalSchedule represents schedule with ticks aligned to interval.
+type intervalSchedule struct {
+	interval time.Duration
+	text     string
+}
+
+func (s intervalSchedule) Prev(now time.Time) time.Time {
+	return now.UTC().Truncate(s.interval)
+}
+
+func (s intervalSchedule) Next(now time.Time) time.Time {
+	return s.Prev(now).Add(s.interval)
+}
+
+func (s intervalSchedule) String() string {
+	return s.text
+}
+
+// dailySchedule represents schedule with tick at time of day.
+type dailySchedule struct {
+	offset time.Duration
+	text   string
+}
+
+func (s dailySchedule) Prev(now time.Time) time.Time {
+	now = now.UTC()
+	tick := now.Truncate(24 * time.Hour).Add(s.offset)
+	if tick.After(now) {
+		tick = tick.Add(-24 * time.Hour)
+	}
+	return tick
+}
+
+func (s dailySchedule) Next(now time.Time) time.Time {
+	return s.Prev(now).Add(24 * time.Hour)
+}
+
+func (s dailySchedule) String() string {
+	return s.text
+}
+
+var scheduleUnits = map[string]time.Duration{
+	"second":  time.Second,
+	"seconds": time.Second,
+	"minute":  time.Minute,
+	"minutes": time.Minute,
+	"hour":    time.Hour,
+	"hours":   time.Hour,
+	"day":     24 * time.Hour,
+	"days":    24 * time.Hour,
+}
+
+// ParseSchedule parses schedule from text.
+//
+// Following formats are supported:
+//   - every <unit>, for example "every hour";
+//   - every <n> <units>, for example "every 10 minutes";
+//   - daily at <hh:mm>, for example "daily at 03:00".
+func ParseSchedule(text string) (Schedule, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	text = strings.Join(fields, " ")
+	switch {
+	case len(fields) == 3 && fields[0] == "daily" && fields[1] == "at":
+		at, err := time.Parse("15:04", fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid time of day: %q", fields[2])
+		}
+		offset := time.Duration(at.Hour())*time.Hour +
+			time.Duration(at.Minute())*time.Minute
+		return dailySchedule{offset: offset, text: text}, nil
+	case len(fields) == 2 && fields[0] == "every":
+		unit, ok := scheduleUnits[fields[1]]
+		if !ok {
+			return nil, fmt.Errorf("unsupported unit: %q", fields[1])
+		}
+		return intervalSchedule{interval: unit, text: text}, nil
+	case len(fields) == 3 && fields[0] == "every":
+		count, err := strconv.Atoi(fields[1])
+		if err != nil || count <= 0 {
+			return nil, fmt.Errorf("invalid interval: %q", fields[1])
+		}
+		unit, ok := scheduleUnits[fields[2]]
+		if !ok {
+			return nil, fmt.Errorf("unsupported unit: %q", fields[2])
+		}
+		return intervalSchedule{
+			interval: time.Duration(count) * unit, text: text,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported schedule: %q", text)
+	}
+}
+
+// RecurringJob represents job that enqueues task on every tick of
+// schedule.
+type RecurringJob struct {
+	// Name contains unique name of job.
+	Name string
+	// Schedule contains schedule of job.
+	Schedule Schedule
+	// Kind contains kind of enqueued task.
+	Kind models.TaskKind
+	// Config contains config of enqueued task.
+	Config models.JSON
+}
+
+// recurringJobSetting represents value of setting with job.
+type recurringJobSetting struct {
+	Schedule string          `json:"schedule"`
+	Kind     models.TaskKind `json:"kind"`
+	Config   models.JSON     `json:"config"`
+}
+
+// RecurringJobStatus represents status of recurring job.
+type RecurringJobStatus struct {
+	RecurringJob
+	// LastTime contains time of last run of job.
+	//
+	// LastTime is zero when job was never run.
+	LastTime time.Time
+	// NextTime contains time of next run of job.
+	NextTime time.Time
+}
+
+// Scheduler runs recurring jobs.
+//
+// Jobs are registered in code or defined in settings with key
+// "scheduler.jobs.<name>" and JSON value with schedule, kind and
+// config of task. Jobs from settings override jobs with the same
+// name registered in code.
+//
+// Every tick of schedule is claimed in database, so task is enqueued
+// exactly once even if several servers share the same database.
+// Ticks that were missed while all servers were stopped result in
+// single run of job. New job is not run until its first tick.
+type Scheduler struct {
+	core  *Core
+	mutex sync.RWMutex
+	jobs  map[string]RecurringJob
+}
+
+// Register registers recurring job.
+func (s *Scheduler) Register(job RecurringJob) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobs[job.Name] = job
+}
+
+// Jobs returns recurring jobs ordered by name.
+func (s *Scheduler) Jobs() []RecurringJob {
+	jobs := map[string]RecurringJob{}
+	func() {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		for name, job := range s.jobs {
+			jobs[name] = job
+		}
+	}()
+	if s.core.Settings != nil {
+		settings, err := s.core.Settings.All()
+		if err != nil {
+			s.core.Logger().Error("Unable to load settings: ", err)
+		}
+		for _, setting := range settings {
+			if !strings.HasPrefix(setting.Key, schedulerSettingPrefix) {
+				continue
+			}
+			job, err := parseRecurringJob(setting)
+			if err != nil {
+				s.core.Logger().Warn("Invalid job setting: ", err)
+				continue
+			}
+			jobs[job.Name] = job
+		}
+	}
+	var result []RecurringJob
+	for _, job := range jobs {
+		result = append(result, job)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func parseRecurringJob(setting models.Setting) (RecurringJob, error) {
+	var value recurringJobSetting
+	if err := json.Unmarshal([]byte(setting.Value), &value); err != nil {
+		return RecurringJob{}, fmt.Errorf("setting %q: %w", setting.Key, err)
+	}
+	schedule, err := ParseSchedule(value.Schedule)
+	if err != nil {
+		return RecurringJob{}, fmt.Errorf("setting %q: %w", setting.Key, err)
+	}
+	return RecurringJob{
+		Name:     strings.TrimPrefix(setting.Key, schedulerSettingPrefix),
+		Schedule: schedule,
+		Kind:     value.Kind,
+		Config:   value.Config,
+	}, nil
+}
+
+// Status returns statuses of recurring jobs ordered by name.
+func (s *Scheduler) Status(
+	ctx context.Context, now time.Time,
+) ([]RecurringJobStatus, error) {
+	var statuses []RecurringJobStatus
+	for _, job := range s.Jobs() {
+		status := RecurringJobStatus{
+			RecurringJob: job,
+			NextTime:     job.Schedule.Next(now),
+		}
+		run, err := s.core.ScheduledJobs.GetByName(ctx, job.Name)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			if run.LastTime != 0 {
+				status.LastTime = time.Unix(run.LastTime, 0)
+			}
+			// Current tick is not claimed yet.
+			if prev := job.Schedule.Prev(now); run.LastTime < prev.Unix() {
+				status.NextTime = prev
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Run runs scheduler until context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			s.core.Logger().Error("Unable to run jobs: ", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick enqueues tasks for recurring jobs with unclaimed ticks.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	var lastErr error
+	for _, job := range s.Jobs() {
+		if err := s.tickJob(ctx, job, now); err != nil {
+			s.core.Logger().Error("Unable to run job ", job.Name, ": ", err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+func (s *Scheduler) tickJob(
+	ctx context.Context, job RecurringJob, now time.Time,
+) error {
+	tick := job.Schedule.Prev(now).Unix()
+	run, err := s.core.ScheduledJobs.GetByName(ctx, job.Name)
+	if err == sql.ErrNoRows {
+		// Several servers can create run of the same job at once,
+		// but ticks are claimed for all runs with job name, so
+		// duplicate runs are harmless.
+		run = models.ScheduledJob{Name: job.Name, LastTime: tick}
+		return s.core.ScheduledJobs.Create(ctx, &run)
+	}
+	if err != nil {
+		return err
+	}
+	if run.LastTime >= tick {
+		return nil
+	}
+	return s.core.WrapTx(ctx, func(ctx context.Context) error {
+		ok, err := s.core.ScheduledJobs.Claim(ctx, job.Name, tick)
+		if err != nil || !ok {
+			return err
+		}
+		task := models.Task{
+			Status: models.QueuedTask,
+			Kind:   job.Kind,
+			Config: job.Config.Clone(),
+		}
+		if err := s.core.Tasks.Create(ctx, &task); err != nil {
+			return err
+		}
+		s.core.Logger().Info("Enqueued task ", task.ID, " for job ", job.Name)
+		return nil
+	})
+}
+
+// builtInJobs contains recurring jobs that are always registered.
+var builtInJobs = []RecurringJob{
+	{
+		Name:     "cleanup_sessions",
+		Schedule: intervalSchedule{interval: time.Hour, text: "every hour"},
+		Kind:     models.CleanupSessionsTask,
+	},
+	{
+		Name:     "cleanup_visits",
+		Schedule: intervalSchedule{interval: time.Hour, text: "every hour"},
+		Kind:     models.CleanupVisitsTask,
+	},
+	{
+		Name:     "cleanup_tasks",
+		Schedule: intervalSchedule{interval: 24 * time.Hour, text: "every day"},
+		Kind:     models.CleanupTasksTask,
+	},
+}
+
+func newScheduler(core *Core) *Scheduler {
+	s := Scheduler{core: core, jobs: map[string]RecurringJob{}}
+	for _, job := range builtInJobs {
+		s.Register(job)
+	}
+	return &s
+}
@@ -0,0 +1,172 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/udovin/goquiz/core"
+	"github.com/udovin/goquiz/models"
+	"github.com/udovin/solve/db"
+)
+
+func TestParseSchedule(t *testing.T) {
+	now := time.Date(2022, 7, 10, 12, 34, 56, 0, time.UTC)
+	for _, test := range []struct {
+		Text string
+		Prev time.Time
+		Next time.Time
+	}{
+		{
+			"every 10 minutes",
+			time.Date(2022, 7, 10, 12, 30, 0, 0, time.UTC),
+			time.Date(2022, 7, 10, 12, 40, 0, 0, time.UTC),
+		},
+		{
+			"Every  Hour",
+			time.Date(2022, 7, 10, 12, 0, 0, 0, time.UTC),
+			time.Date(2022, 7, 10, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			"daily at 03:00",
+			time.Date(2022, 7, 10, 3, 0, 0, 0, time.UTC),
+			time.Date(2022, 7, 11, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			"daily at 13:15",
+			time.Date(2022, 7, 9, 13, 15, 0, 0, time.UTC),
+			time.Date(2022, 7, 10, 13, 15, 0, 0, time.UTC),
+		},
+	} {
+		schedule, err := core.ParseSchedule(test.Text)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		if prev := schedule.Prev(now); !prev.Equal(test.Prev) {
+			t.Fatalf("Expected %v, got %v", test.Prev, prev)
+		}
+		if next := schedule.Next(now); !next.Equal(test.Next) {
+			t.Fatalf("Expected %v, got %v", test.Next, next)
+		}
+	}
+	for _, text := range []string{
+		"", "every", "every 0 minutes", "every 10 weeks", "daily at 25:00",
+		"weekly",
+	} {
+		if _, err := core.ParseSchedule(text); err == nil {
+			t.Fatalf("Expected error for %q", text)
+		}
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	c, err := core.NewCore(testCfg)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	c.SetupAllStores()
+	ctx := context.Background()
+	if err := db.ApplyMigrations(ctx, c.DB); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	defer c.Stop()
+	schedule, err := core.ParseSchedule("every 10 minutes")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	c.Scheduler.Register(core.RecurringJob{
+		Name: "code", Schedule: schedule, Kind: models.CleanupSessionsTask,
+	})
+	if err := c.Settings.Create(ctx, &models.Setting{
+		Key:   "scheduler.jobs.setting",
+		Value: `{"schedule":"daily at 03:00","kind":"cleanup_sessions","config":{"days":30}}`,
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := c.Settings.Create(ctx, &models.Setting{
+		Key: "scheduler.jobs.invalid", Value: `{"schedule":"never"}`,
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := c.Settings.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	jobs := c.Scheduler.Jobs()
+	if len(jobs) != 5 || jobs[0].Name != "cleanup_sessions" ||
+		jobs[1].Name != "cleanup_tasks" || jobs[2].Name != "cleanup_visits" ||
+		jobs[3].Name != "code" || jobs[4].Name != "setting" {
+		t.Fatalf("Unexpected jobs: %v", jobs)
+	}
+	now := time.Date(2022, 7, 10, 12, 34, 56, 0, time.UTC)
+	// New jobs are not run until first tick.
+	if err := c.Scheduler.Tick(ctx, now); err != nil {
+		t.Fatal("Error:", err)
+	}
+	// Every tick is run exactly once.
+	for i := 0; i < 2; i++ {
+		if err := c.Scheduler.Tick(ctx, now.Add(24*time.Hour)); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	if err := c.Tasks.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	tasks, err := c.Tasks.FindByStatus(models.QueuedTask)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(tasks) != 5 {
+		t.Fatalf("Expected 5 tasks, got %d", len(tasks))
+	}
+	statuses, err := c.Scheduler.Status(ctx, now.Add(24*time.Hour+time.Minute))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(statuses) != 5 {
+		t.Fatalf("Expected 5 statuses, got %d", len(statuses))
+	}
+	for i, test := range []struct {
+		LastTime time.Time
+		NextTime time.Time
+	}{
+		{
+			time.Date(2022, 7, 11, 12, 0, 0, 0, time.UTC),
+			time.Date(2022, 7, 11, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2022, 7, 11, 0, 0, 0, 0, time.UTC),
+			time.Date(2022, 7, 12, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2022, 7, 11, 12, 0, 0, 0, time.UTC),
+			time.Date(2022, 7, 11, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2022, 7, 11, 12, 30, 0, 0, time.UTC),
+			time.Date(2022, 7, 11, 12, 40, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2022, 7, 11, 3, 0, 0, 0, time.UTC),
+			time.Date(2022, 7, 12, 3, 0, 0, 0, time.UTC),
+		},
+	} {
+		if !statuses[i].LastTime.Equal(test.LastTime) {
+			t.Fatalf("Expected %v, got %v", test.LastTime, statuses[i].LastTime)
+		}
+		if !statuses[i].NextTime.Equal(test.NextTime) {
+			t.Fatalf("Expected %v, got %v", test.NextTime, statuses[i].NextTime)
+		}
+	}
+	// Unclaimed tick is reported as next run.
+	statuses, err = c.Scheduler.Status(ctx, now.Add(48*time.Hour))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	next := time.Date(2022, 7, 12, 3, 0, 0, 0, time.UTC)
+	if !statuses[4].NextTime.Equal(next) {
+		t.Fatalf("Expected %v, got %v", next, statuses[4].NextTime)
+	}
+}
@@ -35,9 +35,34 @@ func (c *Core) SetupAllStores() {
 		)
 	}
 	c.Visits = models.NewVisitStore(c.DB, "goquiz_visit")
+	c.VisitRollups = models.NewVisitRollupStore(c.DB, "goquiz_visit_rollup")
 	c.Quizes = models.NewQuizStore(c.DB, "goquiz_quiz", "goquiz_quiz_event")
+	c.QuizSections = models.NewQuizSectionStore(
+		c.DB, "goquiz_quiz_section", "goquiz_quiz_section_event",
+	)
+	c.QuizAttempts = models.NewQuizAttemptStore(
+		c.DB, "goquiz_quiz_attempt", "goquiz_quiz_attempt_event",
+	)
+	c.QuizOverrides = models.NewQuizOverrideStore(
+		c.DB, "goquiz_quiz_override", "goquiz_quiz_override_event",
+	)
+	c.QuizParticipants = models.NewQuizParticipantStore(
+		c.DB, "goquiz_quiz_participant", "goquiz_quiz_participant_event",
+	)
+	c.QuizGrades = models.NewQuizGradeStore(
+		c.DB, "goquiz_quiz_grade", "goquiz_quiz_grade_event",
+	)
+	c.ProctoringEvents = models.NewProctoringEventStore(
+		c.DB, "goquiz_proctoring_event",
+	)
 	c.Pools = models.NewPoolStore(c.DB, "goquiz_pool", "goquiz_pool_event")
+	c.PoolProblems = models.NewPoolProblemStore(
+		c.DB, "goquiz_pool_problem", "goquiz_pool_problem_event",
+	)
 	c.Problems = models.NewProblemStore(c.DB, "goquiz_problem", "goquiz_problem_event")
+	c.Files = models.NewFileStore(c.DB, "goquiz_file", "goquiz_file_event")
+	c.Tasks = models.NewTaskStore(c.DB, "goquiz_task", "goquiz_task_event")
+	c.ScheduledJobs = models.NewScheduledJobStore(c.DB, "goquiz_scheduled_job")
 }
 
 func (c *Core) startStores(start func(models.Store, time.Duration)) {
@@ -49,8 +74,16 @@ func (c *Core) startStores(start func(models.Store, time.Duration)) {
 	start(c.Sessions, time.Second)
 	start(c.Users, time.Second)
 	start(c.Quizes, time.Second)
+	start(c.QuizSections, time.Second)
+	start(c.QuizAttempts, time.Second)
+	start(c.QuizOverrides, time.Second)
+	start(c.QuizParticipants, time.Second)
+	start(c.QuizGrades, time.Second)
 	start(c.Pools, time.Second)
+	start(c.PoolProblems, time.Second)
 	start(c.Problems, time.Second)
+	start(c.Files, time.Second)
+	start(c.Tasks, time.Second)
 }
 
 func (c *Core) startStoreLoops() error {
@@ -0,0 +1,516 @@
+// Package expr implements parsing and safe evaluation of arithmetic
+// expressions over named variables.
+//
+// Expressions consist of numbers, variables, operators +, -, *, /, %, ^,
+// parentheses and calls of built-in functions. Nothing else can be
+// evaluated, so expressions from untrusted sources are safe to run.
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	// maxLength contains maximal length of expression.
+	maxLength = 1024
+	// maxDepth contains maximal nesting depth of expression.
+	maxDepth = 64
+)
+
+// Expr represents parsed expression.
+type Expr struct {
+	root      node
+	variables []string
+}
+
+// Variables returns sorted names of variables used in expression.
+func (e *Expr) Variables() []string {
+	return append([]string(nil), e.variables...)
+}
+
+// Eval evaluates expression using specified values of variables.
+//
+// Result is always finite number, otherwise error is returned.
+func (e *Expr) Eval(values map[string]float64) (float64, error) {
+	value, err := e.root.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return value, nil
+}
+
+// Parse parses expression.
+func Parse(text string) (*Expr, error) {
+	if len(text) > maxLength {
+		return nil, fmt.Errorf("expression is too long (>%d)", maxLength)
+	}
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{tokens: tokens, variables: map[string]struct{}{}}
+	root, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if token := p.peek(); token.kind != endToken {
+		return nil, fmt.Errorf("unexpected %s at %d", token, token.pos+1)
+	}
+	var variables []string
+	for name := range p.variables {
+		variables = append(variables, name)
+	}
+	sort.Strings(variables)
+	return &Expr{root: root, variables: variables}, nil
+}
+
+// IsReserved returns flag that name is reserved for constant or function
+// and can not be used as variable name.
+func IsReserved(name string) bool {
+	if _, ok := constants[name]; ok {
+		return true
+	}
+	_, ok := functions[name]
+	return ok
+}
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// function represents built-in function.
+type function struct {
+	// minArgs contains minimal amount of arguments.
+	minArgs int
+	// maxArgs contains maximal amount of arguments or -1 if amount
+	// is not limited.
+	maxArgs int
+	// call evaluates function.
+	call func(args []float64) (float64, error)
+}
+
+func unary(fn func(float64) float64) function {
+	return function{
+		minArgs: 1,
+		maxArgs: 1,
+		call: func(args []float64) (float64, error) {
+			return fn(args[0]), nil
+		},
+	}
+}
+
+var functions = map[string]function{
+	"abs":   unary(math.Abs),
+	"sqrt":  unary(math.Sqrt),
+	"exp":   unary(math.Exp),
+	"log":   unary(math.Log),
+	"log10": unary(math.Log10),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"pow": {
+		minArgs: 2,
+		maxArgs: 2,
+		call: func(args []float64) (float64, error) {
+			return math.Pow(args[0], args[1]), nil
+		},
+	},
+	"min": {
+		minArgs: 1,
+		maxArgs: -1,
+		call: func(args []float64) (float64, error) {
+			result := args[0]
+			for _, arg := range args[1:] {
+				result = math.Min(result, arg)
+			}
+			return result, nil
+		},
+	},
+	"max": {
+		minArgs: 1,
+		maxArgs: -1,
+		call: func(args []float64) (float64, error) {
+			result := args[0]
+			for _, arg := range args[1:] {
+				result = math.Max(result, arg)
+			}
+			return result, nil
+		},
+	},
+}
+
+type node interface {
+	eval(values map[string]float64) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]float64) (float64, error) {
+	return float64(n), nil
+}
+
+type variableNode string
+
+func (n variableNode) eval(values map[string]float64) (float64, error) {
+	if value, ok := constants[string(n)]; ok {
+		return value, nil
+	}
+	value, ok := values[string(n)]
+	if !ok {
+		return 0, fmt.Errorf("variable %q is not defined", string(n))
+	}
+	return value, nil
+}
+
+type negateNode struct {
+	arg node
+}
+
+func (n negateNode) eval(values map[string]float64) (float64, error) {
+	value, err := n.arg.eval(values)
+	return -value, err
+}
+
+type binaryNode struct {
+	op          byte
+	left, right node
+}
+
+func (n binaryNode) eval(values map[string]float64) (float64, error) {
+	left, err := n.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	right, err := n.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	case '/':
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	case '%':
+		if right == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return math.Mod(left, right), nil
+	case '^':
+		return math.Pow(left, right), nil
+	default:
+		return 0, fmt.Errorf("unsupported operator %q", n.op)
+	}
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n callNode) eval(values map[string]float64) (float64, error) {
+	args := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(values)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+	return functions[n.name].call(args)
+}
+
+type tokenKind int
+
+const (
+	endToken tokenKind = iota
+	numberToken
+	identToken
+	operatorToken
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64
+	pos   int
+}
+
+func (t token) String() string {
+	if t.kind == endToken {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// IsIdent returns flag that name can be used as variable name.
+func IsIdent(name string) bool {
+	if len(name) == 0 || !isIdentStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isIdentStart(name[i]) && !isDigit(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || c == '.':
+			j := i
+			for j < len(text) && (isDigit(text[j]) || text[j] == '.') {
+				j++
+			}
+			if j < len(text) && (text[j] == 'e' || text[j] == 'E') {
+				k := j + 1
+				if k < len(text) && (text[k] == '+' || text[k] == '-') {
+					k++
+				}
+				if k < len(text) && isDigit(text[k]) {
+					for k < len(text) && isDigit(text[k]) {
+						k++
+					}
+					j = k
+				}
+			}
+			value, err := strconv.ParseFloat(text[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at %d", text[i:j], i+1)
+			}
+			tokens = append(tokens, token{
+				kind: numberToken, text: text[i:j], value: value, pos: i,
+			})
+			i = j
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(text) && (isIdentStart(text[j]) || isDigit(text[j])) {
+				j++
+			}
+			tokens = append(tokens, token{
+				kind: identToken, text: text[i:j], pos: i,
+			})
+			i = j
+		case c == '+' || c == '-' || c == '*' || c == '/' || c == '%' ||
+			c == '^' || c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, token{
+				kind: operatorToken, text: text[i : i+1], pos: i,
+			})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", c, i+1)
+		}
+	}
+	tokens = append(tokens, token{kind: endToken, pos: len(text)})
+	return tokens, nil
+}
+
+type parser struct {
+	tokens    []token
+	pos       int
+	variables map[string]struct{}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	token := p.tokens[p.pos]
+	if token.kind != endToken {
+		p.pos++
+	}
+	return token
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	token := p.peek()
+	if token.kind != operatorToken {
+		return false
+	}
+	for _, op := range ops {
+		if token.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOperator(op) {
+		token := p.peek()
+		return fmt.Errorf(
+			"expected %q, got %s at %d", op, token, token.pos+1,
+		)
+	}
+	p.next()
+	return nil
+}
+
+// parseExpr parses sum of terms.
+func (p *parser) parseExpr(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("expression is too deep (>%d)", maxDepth)
+	}
+	left, err := p.parseTerm(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+", "-") {
+		op := p.next().text[0]
+		right, err := p.parseTerm(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseTerm parses product of factors.
+func (p *parser) parseTerm(depth int) (node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*", "/", "%") {
+		op := p.next().text[0]
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses factor with optional unary sign.
+func (p *parser) parseUnary(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("expression is too deep (>%d)", maxDepth)
+	}
+	if p.isOperator("-", "+") {
+		negate := p.next().text == "-"
+		arg, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			return negateNode{arg: arg}, nil
+		}
+		return arg, nil
+	}
+	return p.parsePower(depth)
+}
+
+// parsePower parses right associative power.
+func (p *parser) parsePower(depth int) (node, error) {
+	base, err := p.parsePrimary(depth)
+	if err != nil {
+		return nil, err
+	}
+	if p.isOperator("^") {
+		p.next()
+		exponent, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: '^', left: base, right: exponent}, nil
+	}
+	return base, nil
+}
+
+// parsePrimary parses number, variable, call or parenthesized expression.
+func (p *parser) parsePrimary(depth int) (node, error) {
+	token := p.next()
+	switch token.kind {
+	case numberToken:
+		return numberNode(token.value), nil
+	case identToken:
+		if !p.isOperator("(") {
+			if _, ok := functions[token.text]; ok {
+				return nil, fmt.Errorf(
+					"function %q should be called at %d",
+					token.text, token.pos+1,
+				)
+			}
+			if _, ok := constants[token.text]; !ok {
+				p.variables[token.text] = struct{}{}
+			}
+			return variableNode(token.text), nil
+		}
+		fn, ok := functions[token.text]
+		if !ok {
+			return nil, fmt.Errorf(
+				"unknown function %q at %d", token.text, token.pos+1,
+			)
+		}
+		p.next()
+		var args []node
+		if !p.isOperator(")") {
+			for {
+				arg, err := p.parseExpr(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if !p.isOperator(",") {
+					break
+				}
+				p.next()
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+			return nil, fmt.Errorf(
+				"invalid amount of arguments for %q at %d",
+				token.text, token.pos+1,
+			)
+		}
+		return callNode{name: token.text, args: args}, nil
+	case operatorToken:
+		if token.text == "(" {
+			inner, err := p.parseExpr(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s at %d", token, token.pos+1)
+}
@@ -0,0 +1,122 @@
+package expr
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	values := map[string]float64{"a": 3, "b": 4, "x_1": 0.5}
+	tests := []struct {
+		Text  string
+		Value float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-a + b", 1},
+		{"--a", 3},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"sqrt(a^2 + b^2)", 5},
+		{"a / b", 0.75},
+		{"b % a", 1},
+		{"min(a, b, 1) + max(a, b)", 5},
+		{"pow(2, 10)", 1024},
+		{"round(x_1 * 3)", 2},
+		{"abs(-a) + floor(2.7) + ceil(2.1)", 8},
+		{"2 * pi", 2 * math.Pi},
+		{"log(e)", 1},
+		{"1.5e3 + .5", 1500.5},
+		{"10 - 4 - 3", 3},
+		{"12 / 3 / 2", 2},
+	}
+	for _, test := range tests {
+		expr, err := Parse(test.Text)
+		if err != nil {
+			t.Fatalf("Unable to parse %q: %v", test.Text, err)
+		}
+		value, err := expr.Eval(values)
+		if err != nil {
+			t.Fatalf("Unable to evaluate %q: %v", test.Text, err)
+		}
+		if math.Abs(value-test.Value) > 1e-9 {
+			t.Fatalf("Expected %v for %q, got %v", test.Value, test.Text, value)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	expr, err := Parse("b * sqrt(a) + a - pi")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if vars := expr.Variables(); !reflect.DeepEqual(vars, []string{"a", "b"}) {
+		t.Fatalf("Unexpected variables: %v", vars)
+	}
+	if _, err := expr.Eval(map[string]float64{"a": 1}); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, text := range []string{
+		"1 / 0",
+		"1 % (a - a)",
+		"sqrt(-1)",
+		"log(0)",
+		"10 ^ 400",
+	} {
+		expr, err := Parse(text)
+		if err != nil {
+			t.Fatalf("Unable to parse %q: %v", text, err)
+		}
+		if _, err := expr.Eval(map[string]float64{"a": 1}); err == nil {
+			t.Fatalf("Expected error for %q", text)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 + 2)",
+		"a b",
+		"sqrt",
+		"sqrt(1, 2)",
+		"pow(1)",
+		"min()",
+		"unknown(1)",
+		"1..2",
+		"a = 1",
+		"os.Exit(1)",
+		"\"text\"",
+		strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100),
+		strings.Repeat("-", 100) + "1",
+		strings.Repeat("1+", 600) + "1",
+	} {
+		if _, err := Parse(text); err == nil {
+			t.Fatalf("Expected error for %q", text)
+		}
+	}
+}
+
+func TestIdent(t *testing.T) {
+	for _, name := range []string{"a", "x_1", "_", "Speed"} {
+		if !IsIdent(name) {
+			t.Fatalf("Expected %q to be identifier", name)
+		}
+	}
+	for _, name := range []string{"", "1a", "a-b", "a b"} {
+		if IsIdent(name) {
+			t.Fatalf("Expected %q not to be identifier", name)
+		}
+	}
+	if !IsReserved("pi") || !IsReserved("sqrt") || IsReserved("a") {
+		t.Fatal("Unexpected reserved names")
+	}
+}
@@ -0,0 +1,143 @@
+// Package files implements storages for contents of uploaded files.
+//
+// Contents are addressed by SHA-256 hash, so the same content is
+// stored only once regardless of how many times it was uploaded.
+package files
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Storage represents storage for file contents.
+type Storage interface {
+	// Write writes content of specified size with specified key.
+	//
+	// Writing content with existing key replaces previous content.
+	Write(ctx context.Context, key string, r io.Reader, size int64) error
+	// Read returns reader for content with specified key.
+	//
+	// If there is no content with specified key then
+	// os.ErrNotExist will be returned.
+	Read(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete deletes content with specified key.
+	//
+	// Deleting missing content is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Hash returns hex encoded SHA-256 hash of content.
+func Hash(r io.Reader) (string, int64, error) {
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// Key returns storage key for content with specified SHA-256 hash.
+func Key(hash string) string {
+	if len(hash) < 2 {
+		return hash
+	}
+	return hash[:2] + "/" + hash
+}
+
+// LocalStorage represents storage that keeps contents in local
+// directory.
+type LocalStorage struct {
+	dir string
+}
+
+// Write writes content to temporary file and then renames it, so
+// readers never observe partially written content.
+func (s *LocalStorage) Write(
+	ctx context.Context, key string, r io.Reader, size int64,
+) error {
+	path, err := s.getPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	written, err := io.Copy(file, r)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if written != size {
+		return fmt.Errorf("expected %d bytes, got %d", size, written)
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Read returns reader for content file.
+func (s *LocalStorage) Read(
+	ctx context.Context, key string,
+) (io.ReadCloser, error) {
+	path, err := s.getPath(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete removes content file.
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.getPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) getPath(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// NewLocalStorage creates a new instance of LocalStorage.
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+// checkKey checks that key can not escape root of storage.
+func checkKey(key string) error {
+	if len(key) == 0 || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid key %q", key)
+		}
+	}
+	return nil
+}
+
+var (
+	_ Storage = (*LocalStorage)(nil)
+	_ Storage = (*S3Storage)(nil)
+)
@@ -0,0 +1,145 @@
+package files
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testStorage(t *testing.T, storage Storage) {
+	ctx := context.Background()
+	content := "Hello, World!"
+	hash, size, err := Hash(strings.NewReader(content))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if hash != "dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f" {
+		t.Fatalf("Unexpected hash: %q", hash)
+	}
+	key := Key(hash)
+	if _, err := storage.Read(ctx, key); !os.IsNotExist(err) {
+		t.Fatalf("Expected not exist error, got %v", err)
+	}
+	if err := storage.Write(
+		ctx, key, strings.NewReader(content), size,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	reader, err := storage.Read(ctx, key)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	data, err := io.ReadAll(reader)
+	_ = reader.Close()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if string(data) != content {
+		t.Fatalf("Expected %q, got %q", content, data)
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := storage.Read(ctx, key); !os.IsNotExist(err) {
+		t.Fatalf("Expected not exist error, got %v", err)
+	}
+	if err := storage.Write(
+		ctx, "../"+key, strings.NewReader(content), size,
+	); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	testStorage(t, NewLocalStorage(dir))
+	storage := NewLocalStorage(dir)
+	if err := storage.Write(
+		context.Background(), "ab/abc", strings.NewReader("abc"), 4,
+	); err == nil {
+		t.Fatal("Expected error")
+	}
+	if _, err := storage.Read(
+		context.Background(), "ab/abc",
+	); !os.IsNotExist(err) {
+		t.Fatalf("Expected not exist error, got %v", err)
+	}
+}
+
+// testS3Server represents in-memory stand-in for S3-compatible storage.
+type testS3Server struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *testS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(
+		auth, "AWS4-HMAC-SHA256 Credential=key/20220101/test/s3/aws4_request, "+
+			"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=",
+	) {
+		s.t.Errorf("Unexpected authorization: %q", auth)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if date := r.Header.Get("X-Amz-Date"); date != "20220101T120000Z" {
+		s.t.Errorf("Unexpected date: %q", date)
+	}
+	if !strings.HasPrefix(r.URL.Path, "/bucket/prefix/") {
+		s.t.Errorf("Unexpected path: %q", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	server := httptest.NewServer(&testS3Server{
+		t: t, objects: map[string][]byte{},
+	})
+	defer server.Close()
+	storage := NewS3Storage(S3Options{
+		Endpoint:        server.URL,
+		Region:          "test",
+		Bucket:          "bucket",
+		Prefix:          "prefix/",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+	})
+	storage.now = func() time.Time {
+		return time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	}
+	testStorage(t, storage)
+}
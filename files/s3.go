@@ -0,0 +1,197 @@
+package files
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// S3Options contains options of S3-compatible storage.
+type S3Options struct {
+	// Endpoint contains base URL of storage API.
+	Endpoint string
+	// Region contains region of bucket.
+	Region string
+	// Bucket contains name of bucket.
+	Bucket string
+	// Prefix contains prefix for keys of objects.
+	Prefix string
+	// AccessKeyID contains ID of access key.
+	AccessKeyID string
+	// SecretAccessKey contains secret of access key.
+	SecretAccessKey string
+}
+
+// S3Storage represents storage that keeps contents in bucket of
+// S3-compatible object storage.
+//
+// Objects are addressed using path-style URLs and requests are signed
+// with AWS Signature Version 4.
+type S3Storage struct {
+	options S3Options
+	client  *http.Client
+	now     func() time.Time
+}
+
+// Write uploads object with specified key.
+func (s *S3Storage) Write(
+	ctx context.Context, key string, r io.Reader, size int64,
+) error {
+	req, err := s.newRequest(ctx, http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Read downloads object with specified key.
+func (s *S3Storage) Read(
+	ctx context.Context, key string,
+) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete deletes object with specified key.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *S3Storage) newRequest(
+	ctx context.Context, method, key string, body io.Reader,
+) (*http.Request, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	endpoint, err := url.Parse(s.options.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	var path strings.Builder
+	path.WriteString(strings.TrimRight(endpoint.Path, "/"))
+	path.WriteString("/" + s.options.Bucket)
+	for _, part := range strings.Split(s.options.Prefix+key, "/") {
+		if part != "" {
+			path.WriteString("/" + url.PathEscape(part))
+		}
+	}
+	endpoint.Path = path.String()
+	endpoint.RawPath = path.String()
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req)
+	return req, nil
+}
+
+// do sends request and checks response status.
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, os.ErrNotExist
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf(
+		"unexpected status %d: %s", resp.StatusCode, message,
+	)
+}
+
+// unsignedPayload is used instead of payload hash, so contents are
+// streamed without reading them twice.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// sign adds AWS Signature Version 4 headers to request.
+func (s *S3Storage) sign(req *http.Request) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + unsignedPayload + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	scope := date + "/" + s.options.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+	key := []byte("AWS4" + s.options.SecretAccessKey)
+	for _, part := range []string{date, s.options.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.options.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// NewS3Storage creates a new instance of S3Storage.
+func NewS3Storage(options S3Options) *S3Storage {
+	if options.Region == "" {
+		options.Region = "us-east-1"
+	}
+	return &S3Storage{
+		options: options,
+		client:  &http.Client{},
+		now:     time.Now,
+	}
+}
@@ -0,0 +1,563 @@
+// Package gift implements parser of questions in Moodle GIFT format.
+package gift
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/udovin/goquiz/models"
+)
+
+// Question represents question parsed from GIFT file.
+type Question struct {
+	// Line contains number of line where question starts.
+	Line int
+	// Problem contains problem built from question.
+	Problem models.Problem
+}
+
+// Error represents error of parsing GIFT file.
+type Error struct {
+	// Line contains number of line where error occurred.
+	Line int
+	// Message contains error message.
+	Message string
+}
+
+// Error returns error message with line number.
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Errors represents list of errors of parsing GIFT file.
+type Errors []*Error
+
+// Error returns messages of all errors.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// maxTitleLength contains maximal length of generated title.
+const maxTitleLength = 128
+
+// Parse parses questions from GIFT file.
+//
+// Multiple choice, true/false, short answer, numeric and matching
+// questions are supported. If file contains unsupported constructs
+// then Errors with all found errors will be returned and no questions
+// should be imported.
+func Parse(r io.Reader) ([]Question, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var questions []Question
+	var errs Errors
+	var block textBlock
+	flush := func() {
+		if len(block.lines) > 0 {
+			question, err := block.parse()
+			if err != nil {
+				errs = append(errs, err)
+			} else if question != nil {
+				questions = append(questions, *question)
+			}
+		}
+		block = textBlock{}
+	}
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case len(trimmed) == 0:
+			flush()
+		case strings.HasPrefix(trimmed, "//"):
+			// Comments are skipped without splitting questions.
+		default:
+			block.lines = append(block.lines, text)
+			block.numbers = append(block.numbers, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return questions, nil
+}
+
+// textBlock represents lines of one question.
+type textBlock struct {
+	lines   []string
+	numbers []int
+}
+
+// getLine returns number of line for offset in joined block text.
+func (b textBlock) getLine(text string, offset int) int {
+	index := strings.Count(text[:offset], "\n")
+	if index >= len(b.numbers) {
+		index = len(b.numbers) - 1
+	}
+	return b.numbers[index]
+}
+
+func (b textBlock) parse() (*Question, *Error) {
+	lines, numbers := b.lines, b.numbers
+	for len(lines) > 0 &&
+		strings.HasPrefix(strings.TrimSpace(lines[0]), "$CATEGORY:") {
+		lines, numbers = lines[1:], numbers[1:]
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	b = textBlock{lines: lines, numbers: numbers}
+	text := strings.Join(lines, "\n")
+	errorf := func(offset int, format string, args ...any) *Error {
+		return &Error{
+			Line:    b.getLine(text, offset),
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+	begin := 0
+	for begin < len(text) && isSpace(text[begin]) {
+		begin++
+	}
+	var title string
+	if strings.HasPrefix(text[begin:], "::") {
+		end := indexUnescaped(text, "::", begin+2)
+		if end < 0 {
+			return nil, errorf(begin, "title is not closed")
+		}
+		title = strings.TrimSpace(unescape(text[begin+2 : end]))
+		begin = end + 2
+	}
+	open := indexUnescaped(text, "{", begin)
+	if open < 0 {
+		return nil, errorf(begin, "question without answers is not supported")
+	}
+	close := indexUnescaped(text, "}", open+1)
+	if close < 0 {
+		return nil, errorf(open, "answers are not closed")
+	}
+	if next := indexUnescaped(text, "{", open+1); next >= 0 && next < close {
+		return nil, errorf(next, "nested answers are not supported")
+	}
+	if next := indexUnescaped(text, "{", close+1); next >= 0 {
+		return nil, errorf(next, "several answer blocks are not supported")
+	}
+	prefix := strings.TrimSpace(text[begin:open])
+	for _, format := range []string{"[html]", "[moodle]", "[plain]", "[markdown]"} {
+		if strings.HasPrefix(prefix, format) {
+			prefix = strings.TrimSpace(prefix[len(format):])
+			break
+		}
+	}
+	statement := unescape(prefix)
+	if suffix := strings.TrimSpace(text[close+1:]); len(suffix) > 0 {
+		// Missing word format.
+		statement = strings.TrimSpace(statement + " _____ " + unescape(suffix))
+	}
+	if len(statement) == 0 {
+		return nil, errorf(begin, "question text should not be empty")
+	}
+	if len(title) == 0 {
+		title = makeTitle(statement)
+	}
+	problem := models.Problem{Title: title, Statement: statement}
+	parser := answerParser{text: text, begin: open + 1, end: close, errorf: errorf}
+	if err := parser.parse(&problem); err != nil {
+		return nil, err
+	}
+	return &Question{Line: numbers[0], Problem: problem}, nil
+}
+
+// answerItem represents one answer of question.
+type answerItem struct {
+	// offset contains offset of answer in block text.
+	offset int
+	// correct contains flag that answer was marked with "=".
+	correct bool
+	// weight contains weight of answer in percents.
+	weight float64
+	// hasWeight contains flag that weight was specified explicitly.
+	hasWeight bool
+	// text contains unescaped answer text without feedback.
+	text string
+	// raw contains answer text without feedback.
+	raw string
+}
+
+type answerParser struct {
+	text       string
+	begin, end int
+	errorf     func(offset int, format string, args ...any) *Error
+}
+
+func (p answerParser) parse(problem *models.Problem) *Error {
+	body := p.text[p.begin:p.end]
+	trimmed := strings.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return p.errorf(p.begin, "essay questions are not supported")
+	}
+	if strings.HasPrefix(trimmed, "#") {
+		return p.parseNumeric(problem)
+	}
+	value := trimmed
+	if pos := indexUnescaped(value, "#", 0); pos >= 0 {
+		value = strings.TrimSpace(value[:pos])
+	}
+	switch strings.ToUpper(value) {
+	case "T", "TRUE":
+		return p.setTrueFalse(problem, true)
+	case "F", "FALSE":
+		return p.setTrueFalse(problem, false)
+	}
+	items, err := p.splitItems(p.begin, p.end)
+	if err != nil {
+		return err
+	}
+	matching := 0
+	for _, item := range items {
+		if indexUnescaped(item.raw, "->", 0) >= 0 {
+			matching++
+		}
+	}
+	if matching > 0 {
+		if matching != len(items) {
+			return p.errorf(p.begin, "matching question should contain only pairs")
+		}
+		return p.parseMatching(problem, items)
+	}
+	return p.parseChoice(problem, items)
+}
+
+func (p answerParser) setTrueFalse(problem *models.Problem, value bool) *Error {
+	problem.Kind = models.SingleChoiceProblem
+	answer := models.ProblemAnswer{Options: []int{0}}
+	if !value {
+		answer.Options = []int{1}
+	}
+	return p.setProblem(problem, models.ProblemConfig{
+		Options: []models.ProblemOption{{Text: "True"}, {Text: "False"}},
+	}, answer)
+}
+
+func (p answerParser) parseChoice(
+	problem *models.Problem, items []answerItem,
+) *Error {
+	var config models.ProblemConfig
+	var answer models.ProblemAnswer
+	equals, weighted := 0, 0
+	for _, item := range items {
+		// Only weights of completely correct and completely wrong
+		// answers can be represented by problem answer.
+		if item.hasWeight && item.weight != 100 &&
+			(item.correct || item.weight != 0) {
+			return p.errorf(item.offset, "partial credit answers are not supported")
+		}
+		if item.correct {
+			equals++
+		} else if item.weight > 0 {
+			weighted++
+		}
+	}
+	if equals == len(items) {
+		// Short answer question.
+		problem.Kind = models.TextProblem
+		for _, item := range items {
+			if len(item.text) == 0 {
+				return p.errorf(item.offset, "answer should not be empty")
+			}
+			answer.Texts = append(answer.Texts, item.text)
+		}
+		return p.setProblem(problem, config, answer)
+	}
+	if equals > 1 || (equals > 0 && weighted > 0) {
+		return p.errorf(
+			p.begin, "several correct answers should be marked with weights",
+		)
+	}
+	problem.Kind = models.SingleChoiceProblem
+	if weighted > 0 {
+		problem.Kind = models.MultipleChoiceProblem
+	}
+	for i, item := range items {
+		if len(item.text) == 0 {
+			return p.errorf(item.offset, "answer should not be empty")
+		}
+		config.Options = append(config.Options, models.ProblemOption{
+			Text: item.text,
+		})
+		if item.correct || item.weight > 0 {
+			answer.Options = append(answer.Options, i)
+		}
+	}
+	if len(answer.Options) == 0 {
+		return p.errorf(p.begin, "question does not have correct answer")
+	}
+	return p.setProblem(problem, config, answer)
+}
+
+func (p answerParser) parseMatching(
+	problem *models.Problem, items []answerItem,
+) *Error {
+	problem.Kind = models.MatchingProblem
+	var config models.ProblemConfig
+	var answer models.ProblemAnswer
+	options := map[string]int{}
+	for _, item := range items {
+		if !item.correct || item.hasWeight {
+			return p.errorf(item.offset, "matching pair should be marked with \"=\"")
+		}
+		pos := indexUnescaped(item.raw, "->", 0)
+		prompt := strings.TrimSpace(unescape(item.raw[:pos]))
+		option := strings.TrimSpace(unescape(item.raw[pos+2:]))
+		if len(option) == 0 {
+			return p.errorf(item.offset, "matching pair should have answer")
+		}
+		index, ok := options[option]
+		if !ok {
+			index = len(config.Options)
+			options[option] = index
+			config.Options = append(config.Options, models.ProblemOption{
+				Text: option,
+			})
+		}
+		// Pairs without prompt contain extra wrong options.
+		if len(prompt) > 0 {
+			config.Prompts = append(config.Prompts, models.ProblemOption{
+				Text: prompt,
+			})
+			answer.Options = append(answer.Options, index)
+		}
+	}
+	if len(config.Prompts) == 0 {
+		return p.errorf(p.begin, "matching question should have prompts")
+	}
+	return p.setProblem(problem, config, answer)
+}
+
+func (p answerParser) parseNumeric(problem *models.Problem) *Error {
+	problem.Kind = models.NumericProblem
+	begin := indexUnescaped(p.text, "#", p.begin) + 1
+	body := strings.TrimSpace(p.text[begin:p.end])
+	if !strings.HasPrefix(body, "=") && !strings.HasPrefix(body, "~") {
+		if pos := indexUnescaped(body, "#", 0); pos >= 0 {
+			body = body[:pos]
+		}
+		answer, ok := parseNumericAnswer(body)
+		if !ok {
+			return p.errorf(begin, "invalid numeric answer: %q", body)
+		}
+		return p.setProblem(problem, models.ProblemConfig{}, answer)
+	}
+	items, err := p.splitItems(begin, p.end)
+	if err != nil {
+		return err
+	}
+	var answers []models.ProblemAnswer
+	for _, item := range items {
+		if !item.correct && item.weight <= 0 {
+			// Wrong answers are used only for feedback.
+			continue
+		}
+		if !item.correct || (item.hasWeight && item.weight != 100) {
+			return p.errorf(item.offset, "partial credit answers are not supported")
+		}
+		answer, ok := parseNumericAnswer(item.raw)
+		if !ok {
+			return p.errorf(item.offset, "invalid numeric answer: %q", item.raw)
+		}
+		answers = append(answers, answer)
+	}
+	if len(answers) != 1 {
+		return p.errorf(
+			begin, "numeric question should have exactly one correct answer",
+		)
+	}
+	return p.setProblem(problem, models.ProblemConfig{}, answers[0])
+}
+
+func (p answerParser) setProblem(
+	problem *models.Problem, config models.ProblemConfig,
+	answer models.ProblemAnswer,
+) *Error {
+	if err := problem.SetConfig(config); err != nil {
+		return p.errorf(p.begin, "unable to save problem config")
+	}
+	if err := problem.SetAnswer(answer); err != nil {
+		return p.errorf(p.begin, "unable to save problem answer")
+	}
+	return nil
+}
+
+// splitItems splits answers marked with "=" and "~".
+func (p answerParser) splitItems(begin, end int) ([]answerItem, *Error) {
+	var items []answerItem
+	start := -1
+	push := func(pos int) *Error {
+		if start < 0 {
+			if text := strings.TrimSpace(p.text[begin:pos]); len(text) > 0 {
+				return p.errorf(begin, "answer should start with \"=\" or \"~\"")
+			}
+			return nil
+		}
+		item, err := p.parseItem(start, pos)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	}
+	for i := begin; i < end; i++ {
+		switch p.text[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			if err := push(i); err != nil {
+				return nil, err
+			}
+			start = i
+		}
+	}
+	if err := push(end); err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, p.errorf(begin, "question does not have answers")
+	}
+	return items, nil
+}
+
+func (p answerParser) parseItem(begin, end int) (answerItem, *Error) {
+	item := answerItem{offset: begin, correct: p.text[begin] == '='}
+	raw := p.text[begin+1 : end]
+	if pos := indexUnescaped(raw, "#", 0); pos >= 0 {
+		raw = raw[:pos]
+	}
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "%") {
+		pos := strings.Index(raw[1:], "%")
+		if pos < 0 {
+			return answerItem{}, p.errorf(begin, "weight is not closed")
+		}
+		weight, err := strconv.ParseFloat(raw[1:pos+1], 64)
+		if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return answerItem{}, p.errorf(begin, "invalid weight: %q", raw[1:pos+1])
+		}
+		item.weight, item.hasWeight = weight, true
+		raw = strings.TrimSpace(raw[pos+2:])
+	} else if item.correct {
+		item.weight = 100
+	}
+	item.raw = raw
+	item.text = unescape(raw)
+	return item, nil
+}
+
+// parseNumericAnswer parses numeric answer in formats "value",
+// "value:tolerance" and "min..max".
+func parseNumericAnswer(text string) (models.ProblemAnswer, bool) {
+	text = strings.TrimSpace(text)
+	parse := func(s string) (float64, bool) {
+		value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return 0, false
+		}
+		return value, true
+	}
+	if pos := strings.Index(text, ".."); pos >= 0 {
+		min, ok := parse(text[:pos])
+		if !ok {
+			return models.ProblemAnswer{}, false
+		}
+		max, ok := parse(text[pos+2:])
+		if !ok || max < min {
+			return models.ProblemAnswer{}, false
+		}
+		return models.ProblemAnswer{
+			Value:     (min + max) / 2,
+			Tolerance: (max - min) / 2,
+		}, true
+	}
+	if pos := strings.Index(text, ":"); pos >= 0 {
+		value, ok := parse(text[:pos])
+		if !ok {
+			return models.ProblemAnswer{}, false
+		}
+		tolerance, ok := parse(text[pos+1:])
+		if !ok || tolerance < 0 {
+			return models.ProblemAnswer{}, false
+		}
+		return models.ProblemAnswer{Value: value, Tolerance: tolerance}, true
+	}
+	value, ok := parse(text)
+	if !ok {
+		return models.ProblemAnswer{}, false
+	}
+	return models.ProblemAnswer{Value: value}, true
+}
+
+// indexUnescaped returns index of first unescaped occurrence of sub
+// in text starting from specified offset.
+func indexUnescaped(text, sub string, from int) int {
+	for i := from; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape replaces GIFT escape sequences.
+func unescape(text string) string {
+	var result strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			switch c := text[i+1]; c {
+			case '~', '=', '#', '{', '}', ':', '\\':
+				result.WriteByte(c)
+				i++
+				continue
+			case 'n':
+				result.WriteByte('\n')
+				i++
+				continue
+			}
+		}
+		result.WriteByte(text[i])
+	}
+	return result.String()
+}
+
+// makeTitle makes title of question from its statement.
+func makeTitle(statement string) string {
+	title := strings.Join(strings.Fields(statement), " ")
+	if len(title) <= maxTitleLength {
+		return title
+	}
+	end := maxTitleLength - len("...")
+	for end > 0 && !utf8.RuneStart(title[end]) {
+		end--
+	}
+	return title[:end] + "..."
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
@@ -0,0 +1,198 @@
+package gift
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/udovin/goquiz/models"
+)
+
+const testFile = `// Geography questions.
+$CATEGORY: $course$/Geography
+
+::Capital::What is the capital of France? {
+	=Paris#Right!
+	~Berlin
+	~Rome
+}
+
+// True/false question.
+The Sun is a star.{T}
+
+::Primes::Which numbers are prime? {
+	~%100%2
+	~%100%3
+	~4
+}
+
+Who wrote "War and Peace"? {=Tolstoy =Leo Tolstoy}
+
+::Pi::Value of pi {#3.14:0.01}
+
+Number between one and five {#1..5}
+
+::Countries::Match countries with capitals {
+	=France -> Paris
+	=Italy -> Rome
+	= -> Berlin
+}
+
+Escaped \{braces\} and 1 \= 1 {=yes ~no}
+
+The {=quick ~slow} brown fox.
+`
+
+func TestParse(t *testing.T) {
+	questions, err := Parse(strings.NewReader(testFile))
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	type expected struct {
+		Line      int
+		Kind      models.ProblemKind
+		Title     string
+		Statement string
+		Config    models.ProblemConfig
+		Answer    models.ProblemAnswer
+	}
+	tests := []expected{
+		{
+			4, models.SingleChoiceProblem, "Capital",
+			"What is the capital of France?",
+			models.ProblemConfig{Options: []models.ProblemOption{
+				{Text: "Paris"}, {Text: "Berlin"}, {Text: "Rome"},
+			}},
+			models.ProblemAnswer{Options: []int{0}},
+		},
+		{
+			11, models.SingleChoiceProblem, "The Sun is a star.",
+			"The Sun is a star.",
+			models.ProblemConfig{Options: []models.ProblemOption{
+				{Text: "True"}, {Text: "False"},
+			}},
+			models.ProblemAnswer{Options: []int{0}},
+		},
+		{
+			13, models.MultipleChoiceProblem, "Primes",
+			"Which numbers are prime?",
+			models.ProblemConfig{Options: []models.ProblemOption{
+				{Text: "2"}, {Text: "3"}, {Text: "4"},
+			}},
+			models.ProblemAnswer{Options: []int{0, 1}},
+		},
+		{
+			19, models.TextProblem, `Who wrote "War and Peace"?`,
+			`Who wrote "War and Peace"?`,
+			models.ProblemConfig{},
+			models.ProblemAnswer{Texts: []string{"Tolstoy", "Leo Tolstoy"}},
+		},
+		{
+			21, models.NumericProblem, "Pi", "Value of pi",
+			models.ProblemConfig{},
+			models.ProblemAnswer{Value: 3.14, Tolerance: 0.01},
+		},
+		{
+			23, models.NumericProblem, "Number between one and five",
+			"Number between one and five",
+			models.ProblemConfig{},
+			models.ProblemAnswer{Value: 3, Tolerance: 2},
+		},
+		{
+			25, models.MatchingProblem, "Countries",
+			"Match countries with capitals",
+			models.ProblemConfig{
+				Options: []models.ProblemOption{
+					{Text: "Paris"}, {Text: "Rome"}, {Text: "Berlin"},
+				},
+				Prompts: []models.ProblemOption{
+					{Text: "France"}, {Text: "Italy"},
+				},
+			},
+			models.ProblemAnswer{Options: []int{0, 1}},
+		},
+		{
+			31, models.SingleChoiceProblem, "Escaped {braces} and 1 = 1",
+			"Escaped {braces} and 1 = 1",
+			models.ProblemConfig{Options: []models.ProblemOption{
+				{Text: "yes"}, {Text: "no"},
+			}},
+			models.ProblemAnswer{Options: []int{0}},
+		},
+		{
+			33, models.SingleChoiceProblem, "The _____ brown fox.",
+			"The _____ brown fox.",
+			models.ProblemConfig{Options: []models.ProblemOption{
+				{Text: "quick"}, {Text: "slow"},
+			}},
+			models.ProblemAnswer{Options: []int{0}},
+		},
+	}
+	if len(questions) != len(tests) {
+		t.Fatalf("Expected %d questions, got %d", len(tests), len(questions))
+	}
+	for i, test := range tests {
+		question := questions[i]
+		var config models.ProblemConfig
+		if err := question.Problem.ScanConfig(&config); err != nil {
+			t.Fatal("Error:", err)
+		}
+		var answer models.ProblemAnswer
+		if err := question.Problem.ScanAnswer(&answer); err != nil {
+			t.Fatal("Error:", err)
+		}
+		actual := expected{
+			Line:      question.Line,
+			Kind:      question.Problem.Kind,
+			Title:     question.Problem.Title,
+			Statement: question.Problem.Statement,
+			Config:    config,
+			Answer:    answer,
+		}
+		if !reflect.DeepEqual(actual, test) {
+			t.Fatalf("Test %d: expected %+v, got %+v", i+1, test, actual)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	file := `::Essay::Tell about yourself {}
+
+Description without answers.
+
+::Partial:: Capital of France {=%50%Paris =Paris}
+
+Pi {#=%50%3.14 =3.14}
+
+Half {~%50%A ~%50%B ~C}
+
+Negative {~%100%A ~%-25%B}
+
+Unclosed {
+	=answer
+`
+	_, err := Parse(strings.NewReader(file))
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("Expected Errors, got %v", err)
+	}
+	lines := []int{1, 3, 5, 7, 9, 11, 13}
+	if len(errs) != len(lines) {
+		t.Fatalf("Expected %d errors, got %v", len(lines), errs)
+	}
+	for i, line := range lines {
+		if errs[i].Line != line {
+			t.Fatalf("Expected error at line %d, got %v", line, errs[i])
+		}
+	}
+}
+
+func TestMakeTitle(t *testing.T) {
+	title := makeTitle(strings.Repeat("слово ", 50))
+	if len(title) > maxTitleLength {
+		t.Fatalf("Title too long: %d", len(title))
+	}
+	if !strings.HasSuffix(title, "...") {
+		t.Fatalf("Expected truncated title, got %q", title)
+	}
+}
@@ -0,0 +1,329 @@
+// Package grading implements automatic grading of answers.
+package grading
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/udovin/goquiz/expr"
+	"github.com/udovin/goquiz/models"
+)
+
+// Task represents answer that should be graded.
+type Task struct {
+	// Key contains answer key of problem.
+	Key models.ProblemAnswer
+	// Answer contains given answer.
+	Answer models.QuizAttemptAnswer
+	// Options contains amount of options for choice problems.
+	Options int
+	// Policy contains scoring policy.
+	Policy models.ScoringPolicy
+}
+
+// Result represents result of grading answer.
+type Result struct {
+	// Verdict contains verdict of answer.
+	Verdict models.Verdict
+	// Score contains fraction of points for answer.
+	//
+	// Score is not greater than 1 and can be negative only for
+	// NegativeMarkingScoring policy.
+	Score float64
+}
+
+// Grader represents grader of answers for problems of one kind.
+type Grader interface {
+	// Grade grades answer using answer key of problem.
+	Grade(task Task) Result
+}
+
+var graders = map[models.ProblemKind]Grader{
+	models.SingleChoiceProblem:   choiceGrader{},
+	models.MultipleChoiceProblem: setGrader{},
+	models.NumericProblem:        numericGrader{},
+	models.TextProblem:           textGrader{},
+	models.MatchingProblem:       matchingGrader{},
+	models.EssayProblem:          manualGrader{},
+}
+
+// GetGrader returns grader for specified kind of problem.
+func GetGrader(kind models.ProblemKind) (Grader, error) {
+	grader, ok := graders[kind]
+	if !ok {
+		return nil, fmt.Errorf("grader for %q does not exist", kind)
+	}
+	return grader, nil
+}
+
+// GradeProblem grades answer for problem using scoring policy.
+//
+// For parameterized problems correct value is computed from answer
+// formula using drawn values of variables.
+func GradeProblem(
+	problem models.Problem, answer *models.QuizAttemptAnswer,
+	values map[string]float64, policy models.ScoringPolicy,
+) (Result, error) {
+	if answer == nil || answer.IsEmpty() {
+		return Result{Verdict: models.NotAnsweredVerdict}, nil
+	}
+	grader, err := GetGrader(problem.Kind)
+	if err != nil {
+		return Result{}, err
+	}
+	task := Task{Answer: *answer, Policy: policy}
+	if err := problem.ScanAnswer(&task.Key); err != nil {
+		return Result{}, err
+	}
+	if len(task.Key.Formula) > 0 {
+		value, err := evalFormula(task.Key.Formula, values)
+		if err != nil {
+			return Result{}, err
+		}
+		task.Key.Value = value
+	}
+	var config models.ProblemConfig
+	if err := problem.ScanConfig(&config); err != nil {
+		return Result{}, err
+	}
+	task.Options = len(config.Options)
+	return grader.Grade(task), nil
+}
+
+// GradeAttempt grades all answers of attempt state.
+//
+// Verdicts and scores of problems and total score of state are updated.
+// Scoring policy for every problem is returned by getPolicy.
+// Answers that are already graded manually keep their scores.
+func GradeAttempt(
+	state *models.QuizAttemptState,
+	getProblem func(int64) (models.Problem, error),
+	getPolicy func(models.QuizAttemptProblem) models.ScoringPolicy,
+) error {
+	state.Score = 0
+	for i := range state.Problems {
+		attemptProblem := &state.Problems[i]
+		problem, err := getProblem(attemptProblem.ProblemID)
+		if err != nil {
+			return err
+		}
+		result, err := GradeProblem(
+			problem, attemptProblem.Answer, attemptProblem.Values,
+			getPolicy(*attemptProblem),
+		)
+		if err != nil {
+			return err
+		}
+		if result.Verdict == models.PendingVerdict &&
+			isManuallyGraded(attemptProblem.Verdict) {
+			state.Score += attemptProblem.Score
+			continue
+		}
+		attemptProblem.Verdict = result.Verdict
+		attemptProblem.Score = result.Score * float64(attemptProblem.Points)
+		state.Score += attemptProblem.Score
+	}
+	return nil
+}
+
+// SetManualScore sets score and comment of grader for problem of
+// attempt state.
+//
+// Verdict of problem and total score of state are updated.
+func SetManualScore(
+	state *models.QuizAttemptState, problemID int64,
+	score float64, comment string,
+) error {
+	pos := -1
+	for i, attemptProblem := range state.Problems {
+		if attemptProblem.ProblemID == problemID {
+			pos = i
+			break
+		}
+	}
+	if pos == -1 {
+		return fmt.Errorf("problem %d does not exist", problemID)
+	}
+	attemptProblem := &state.Problems[pos]
+	points := float64(attemptProblem.Points)
+	if math.IsNaN(score) || score < 0 || score > points {
+		return fmt.Errorf("invalid score: %v", score)
+	}
+	result := makeResult(0)
+	if points > 0 {
+		result = makeResult(score / points)
+	}
+	attemptProblem.Verdict = result.Verdict
+	attemptProblem.Score = score
+	attemptProblem.Comment = comment
+	state.Score = 0
+	for _, attemptProblem := range state.Problems {
+		state.Score += attemptProblem.Score
+	}
+	return nil
+}
+
+func isManuallyGraded(verdict models.Verdict) bool {
+	switch verdict {
+	case models.AcceptedVerdict, models.PartiallyAcceptedVerdict,
+		models.RejectedVerdict:
+		return true
+	default:
+		return false
+	}
+}
+
+// evalFormula evaluates answer formula using values of variables.
+func evalFormula(formula string, values map[string]float64) (float64, error) {
+	parsed, err := expr.Parse(formula)
+	if err != nil {
+		return 0, err
+	}
+	return parsed.Eval(values)
+}
+
+func makeResult(score float64) Result {
+	switch {
+	case score >= 1:
+		return Result{Verdict: models.AcceptedVerdict, Score: 1}
+	case score > 0:
+		return Result{Verdict: models.PartiallyAcceptedVerdict, Score: score}
+	default:
+		return Result{Verdict: models.RejectedVerdict, Score: score}
+	}
+}
+
+// choiceGrader grades single choice problems with exact match.
+type choiceGrader struct{}
+
+func (choiceGrader) Grade(task Task) Result {
+	if len(task.Key.Options) == 1 && len(task.Answer.Options) == 1 &&
+		task.Key.Options[0] == task.Answer.Options[0] {
+		return makeResult(1)
+	}
+	if task.Policy == models.NegativeMarkingScoring && task.Options > 1 {
+		// Expected score of random guess should be zero.
+		return makeResult(-1 / float64(task.Options-1))
+	}
+	return makeResult(0)
+}
+
+// setGrader grades multiple choice problems with set match.
+type setGrader struct{}
+
+func (setGrader) Grade(task Task) Result {
+	expected := map[int]struct{}{}
+	for _, option := range task.Key.Options {
+		expected[option] = struct{}{}
+	}
+	selected := map[int]struct{}{}
+	for _, option := range task.Answer.Options {
+		selected[option] = struct{}{}
+	}
+	correct, wrong := 0, 0
+	for option := range selected {
+		if _, ok := expected[option]; ok {
+			correct++
+		} else {
+			wrong++
+		}
+	}
+	if len(expected) == 0 {
+		return makeResult(0)
+	}
+	switch task.Policy {
+	case models.ProportionalScoring:
+		score := float64(correct) / float64(len(expected))
+		// Every selected wrong option takes its share of points, so
+		// selecting all options gives zero points.
+		if wrongOptions := task.Options - len(expected); wrongOptions > 0 {
+			score -= float64(wrong) / float64(wrongOptions)
+		}
+		return makeResult(math.Max(score, 0))
+	case models.PenaltyScoring:
+		score := float64(correct-wrong) / float64(len(expected))
+		return makeResult(math.Max(score, 0))
+	default:
+		if wrong == 0 && correct == len(expected) {
+			return makeResult(1)
+		}
+		return makeResult(0)
+	}
+}
+
+// matchingGrader grades matching problems with pairwise match.
+type matchingGrader struct{}
+
+func (matchingGrader) Grade(task Task) Result {
+	if len(task.Key.Options) == 0 {
+		return makeResult(0)
+	}
+	correct, wrong := 0, 0
+	for i, option := range task.Key.Options {
+		if i >= len(task.Answer.Options) || task.Answer.Options[i] < 0 {
+			continue
+		}
+		if task.Answer.Options[i] == option {
+			correct++
+		} else {
+			wrong++
+		}
+	}
+	switch task.Policy {
+	case models.ProportionalScoring:
+		return makeResult(float64(correct) / float64(len(task.Key.Options)))
+	case models.PenaltyScoring:
+		score := float64(correct-wrong) / float64(len(task.Key.Options))
+		return makeResult(math.Max(score, 0))
+	default:
+		if correct == len(task.Key.Options) {
+			return makeResult(1)
+		}
+		return makeResult(0)
+	}
+}
+
+// numericEpsilon contains allowed error of float computations.
+const numericEpsilon = 1e-9
+
+// numericGrader grades numeric problems with tolerance.
+type numericGrader struct{}
+
+func (numericGrader) Grade(task Task) Result {
+	if task.Answer.Value == nil || math.IsNaN(*task.Answer.Value) {
+		return makeResult(0)
+	}
+	diff := math.Abs(*task.Answer.Value - task.Key.Value)
+	if diff <= task.Key.Tolerance+numericEpsilon {
+		return makeResult(1)
+	}
+	return makeResult(0)
+}
+
+// textGrader grades text problems with normalized match.
+type textGrader struct{}
+
+func (textGrader) Grade(task Task) Result {
+	text := NormalizeText(task.Answer.Text)
+	for _, expected := range task.Key.Texts {
+		if text == NormalizeText(expected) {
+			return makeResult(1)
+		}
+	}
+	return makeResult(0)
+}
+
+// manualGrader leaves answers for manual grading.
+type manualGrader struct{}
+
+func (manualGrader) Grade(task Task) Result {
+	return Result{Verdict: models.PendingVerdict}
+}
+
+// NormalizeText normalizes text answer for comparison.
+//
+// Letter case and repeated or surrounding whitespaces are ignored.
+func NormalizeText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
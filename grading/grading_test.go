@@ -0,0 +1,406 @@
+package grading
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func getPtr[T any](object T) *T {
+	return &object
+}
+
+func TestGraders(t *testing.T) {
+	tests := []struct {
+		Kind    models.ProblemKind
+		Key     models.ProblemAnswer
+		Answer  models.QuizAttemptAnswer
+		Verdict models.Verdict
+	}{
+		{
+			models.SingleChoiceProblem,
+			models.ProblemAnswer{Options: []int{1}},
+			models.QuizAttemptAnswer{Options: []int{1}},
+			models.AcceptedVerdict,
+		},
+		{
+			models.SingleChoiceProblem,
+			models.ProblemAnswer{Options: []int{1}},
+			models.QuizAttemptAnswer{Options: []int{0}},
+			models.RejectedVerdict,
+		},
+		{
+			models.SingleChoiceProblem,
+			models.ProblemAnswer{Options: []int{1}},
+			models.QuizAttemptAnswer{Options: []int{1, 0}},
+			models.RejectedVerdict,
+		},
+		{
+			models.MultipleChoiceProblem,
+			models.ProblemAnswer{Options: []int{0, 2}},
+			models.QuizAttemptAnswer{Options: []int{2, 0}},
+			models.AcceptedVerdict,
+		},
+		{
+			models.MultipleChoiceProblem,
+			models.ProblemAnswer{Options: []int{0, 2}},
+			models.QuizAttemptAnswer{Options: []int{0}},
+			models.RejectedVerdict,
+		},
+		{
+			models.MultipleChoiceProblem,
+			models.ProblemAnswer{Options: []int{0, 2}},
+			models.QuizAttemptAnswer{Options: []int{0, 1, 2}},
+			models.RejectedVerdict,
+		},
+		{
+			models.NumericProblem,
+			models.ProblemAnswer{Value: 1.4142, Tolerance: 0.001},
+			models.QuizAttemptAnswer{Value: getPtr(1.414)},
+			models.AcceptedVerdict,
+		},
+		{
+			models.NumericProblem,
+			models.ProblemAnswer{Value: 0.3},
+			models.QuizAttemptAnswer{Value: getPtr(0.1 + 0.2)},
+			models.AcceptedVerdict,
+		},
+		{
+			models.NumericProblem,
+			models.ProblemAnswer{Value: 1.4142, Tolerance: 0.001},
+			models.QuizAttemptAnswer{Value: getPtr(1.42)},
+			models.RejectedVerdict,
+		},
+		{
+			models.NumericProblem,
+			models.ProblemAnswer{Value: 1},
+			models.QuizAttemptAnswer{Text: "1"},
+			models.RejectedVerdict,
+		},
+		{
+			models.TextProblem,
+			models.ProblemAnswer{Texts: []string{"Paris", "Paname"}},
+			models.QuizAttemptAnswer{Text: "  paname "},
+			models.AcceptedVerdict,
+		},
+		{
+			models.TextProblem,
+			models.ProblemAnswer{Texts: []string{"New  York"}},
+			models.QuizAttemptAnswer{Text: "new york"},
+			models.AcceptedVerdict,
+		},
+		{
+			models.TextProblem,
+			models.ProblemAnswer{Texts: []string{"Paris"}},
+			models.QuizAttemptAnswer{Text: "Rome"},
+			models.RejectedVerdict,
+		},
+		{
+			models.MatchingProblem,
+			models.ProblemAnswer{Options: []int{2, 0, 1}},
+			models.QuizAttemptAnswer{Options: []int{2, 0, 1}},
+			models.AcceptedVerdict,
+		},
+		{
+			models.MatchingProblem,
+			models.ProblemAnswer{Options: []int{2, 0, 1}},
+			models.QuizAttemptAnswer{Options: []int{2, 1, 0}},
+			models.RejectedVerdict,
+		},
+	}
+	for i, test := range tests {
+		grader, err := GetGrader(test.Kind)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		result := grader.Grade(Task{
+			Key:     test.Key,
+			Answer:  test.Answer,
+			Options: 3,
+		})
+		if result.Verdict != test.Verdict {
+			t.Fatalf(
+				"Test %d: expected %v, got %v", i+1, test.Verdict, result.Verdict,
+			)
+		}
+		if result.Verdict == models.AcceptedVerdict && result.Score != 1 {
+			t.Fatalf("Test %d: expected score 1, got %v", i+1, result.Score)
+		}
+		if result.Verdict == models.RejectedVerdict && result.Score != 0 {
+			t.Fatalf("Test %d: expected score 0, got %v", i+1, result.Score)
+		}
+	}
+	if _, err := GetGrader(models.ProblemKind(0)); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestGradeAttempt(t *testing.T) {
+	problems := map[int64]models.Problem{
+		1: {
+			Kind:   models.SingleChoiceProblem,
+			Answer: models.JSON(`{"options":[1]}`),
+		},
+		2: {
+			Kind:   models.NumericProblem,
+			Answer: models.JSON(`{"value":4}`),
+		},
+		3: {
+			Kind:   models.TextProblem,
+			Answer: models.JSON(`{"texts":["yes"]}`),
+		},
+	}
+	getProblem := func(id int64) (models.Problem, error) {
+		if problem, ok := problems[id]; ok {
+			return problem, nil
+		}
+		return models.Problem{}, sql.ErrNoRows
+	}
+	state := models.QuizAttemptState{
+		Problems: []models.QuizAttemptProblem{
+			{
+				ProblemID: 1,
+				Points:    2,
+				Answer:    &models.QuizAttemptAnswer{Options: []int{1}},
+			},
+			{
+				ProblemID: 2,
+				Points:    3,
+				Answer:    &models.QuizAttemptAnswer{Value: getPtr(5.0)},
+			},
+			{ProblemID: 3, Points: 4},
+		},
+	}
+	getPolicy := func(models.QuizAttemptProblem) models.ScoringPolicy {
+		return models.AllOrNothingScoring
+	}
+	if err := GradeAttempt(&state, getProblem, getPolicy); err != nil {
+		t.Fatal("Error:", err)
+	}
+	verdicts := []models.Verdict{
+		models.AcceptedVerdict,
+		models.RejectedVerdict,
+		models.NotAnsweredVerdict,
+	}
+	for i, verdict := range verdicts {
+		if state.Problems[i].Verdict != verdict {
+			t.Fatalf(
+				"Expected %v, got %v", verdict, state.Problems[i].Verdict,
+			)
+		}
+	}
+	if state.Problems[0].Score != 2 {
+		t.Fatalf("Expected score %v, got %v", 2, state.Problems[0].Score)
+	}
+	if state.Score != 2 {
+		t.Fatalf("Expected total score %v, got %v", 2, state.Score)
+	}
+	problems[5] = models.Problem{
+		Kind:   models.NumericProblem,
+		Answer: models.JSON(`{"formula":"a * b","tolerance":0.01}`),
+	}
+	formulaState := models.QuizAttemptState{
+		Problems: []models.QuizAttemptProblem{
+			{
+				ProblemID: 5,
+				Points:    1,
+				Values:    map[string]float64{"a": 2, "b": 3.5},
+				Answer:    &models.QuizAttemptAnswer{Value: getPtr(7.0)},
+			},
+			{
+				ProblemID: 5,
+				Points:    1,
+				Values:    map[string]float64{"a": 3, "b": 3.5},
+				Answer:    &models.QuizAttemptAnswer{Value: getPtr(7.0)},
+			},
+		},
+	}
+	if err := GradeAttempt(&formulaState, getProblem, getPolicy); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if formulaState.Problems[0].Verdict != models.AcceptedVerdict ||
+		formulaState.Problems[1].Verdict != models.RejectedVerdict {
+		t.Fatalf("Unexpected verdicts: %v", formulaState.Problems)
+	}
+	formulaState.Problems[0].Values = nil
+	if err := GradeAttempt(&formulaState, getProblem, getPolicy); err == nil {
+		t.Fatal("Expected error")
+	}
+	state.Problems = append(state.Problems, models.QuizAttemptProblem{
+		ProblemID: 4,
+		Answer:    &models.QuizAttemptAnswer{Text: "test"},
+	})
+	if err := GradeAttempt(&state, getProblem, getPolicy); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestManualGrading(t *testing.T) {
+	problems := map[int64]models.Problem{
+		1: {
+			Kind:   models.SingleChoiceProblem,
+			Answer: models.JSON(`{"options":[1]}`),
+		},
+		2: {Kind: models.EssayProblem},
+	}
+	getProblem := func(id int64) (models.Problem, error) {
+		if problem, ok := problems[id]; ok {
+			return problem, nil
+		}
+		return models.Problem{}, sql.ErrNoRows
+	}
+	getPolicy := func(models.QuizAttemptProblem) models.ScoringPolicy {
+		return models.AllOrNothingScoring
+	}
+	state := models.QuizAttemptState{
+		Problems: []models.QuizAttemptProblem{
+			{
+				ProblemID: 1,
+				Points:    2,
+				Answer:    &models.QuizAttemptAnswer{Options: []int{1}},
+			},
+			{
+				ProblemID: 2,
+				Points:    4,
+				Answer:    &models.QuizAttemptAnswer{Text: "Essay"},
+			},
+		},
+	}
+	if err := GradeAttempt(&state, getProblem, getPolicy); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if state.Problems[1].Verdict != models.PendingVerdict {
+		t.Fatalf("Expected pending verdict, got %v", state.Problems[1].Verdict)
+	}
+	if state.Score != 2 {
+		t.Fatalf("Expected total score %v, got %v", 2, state.Score)
+	}
+	if err := SetManualScore(&state, 2, 5, ""); err == nil {
+		t.Fatal("Expected error")
+	}
+	if err := SetManualScore(&state, 3, 1, ""); err == nil {
+		t.Fatal("Expected error")
+	}
+	if err := SetManualScore(&state, 2, 3, "Good"); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if state.Problems[1].Verdict != models.PartiallyAcceptedVerdict {
+		t.Fatalf("Unexpected verdict: %v", state.Problems[1].Verdict)
+	}
+	if state.Score != 5 {
+		t.Fatalf("Expected total score %v, got %v", 5, state.Score)
+	}
+	// Regrading should not reset manual scores.
+	if err := GradeAttempt(&state, getProblem, getPolicy); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if state.Score != 5 || state.Problems[1].Comment != "Good" {
+		t.Fatalf("Manual score is lost: %+v", state)
+	}
+	if err := SetManualScore(&state, 2, 4, ""); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if state.Problems[1].Verdict != models.AcceptedVerdict {
+		t.Fatalf("Unexpected verdict: %v", state.Problems[1].Verdict)
+	}
+}
+
+func TestScoringPolicies(t *testing.T) {
+	choice := models.ProblemAnswer{Options: []int{0}}
+	multiple := models.ProblemAnswer{Options: []int{0, 1}}
+	matching := models.ProblemAnswer{Options: []int{3, 2, 1, 0}}
+	tests := []struct {
+		Kind    models.ProblemKind
+		Policy  models.ScoringPolicy
+		Key     models.ProblemAnswer
+		Options []int
+		Verdict models.Verdict
+		Score   float64
+	}{
+		// Options: 0 and 1 are correct, 2 and 3 are wrong.
+		{
+			models.MultipleChoiceProblem, models.AllOrNothingScoring,
+			multiple, []int{0}, models.RejectedVerdict, 0,
+		},
+		{
+			models.MultipleChoiceProblem, models.ProportionalScoring,
+			multiple, []int{0}, models.PartiallyAcceptedVerdict, 0.5,
+		},
+		{
+			models.MultipleChoiceProblem, models.ProportionalScoring,
+			multiple, []int{0, 1, 2}, models.PartiallyAcceptedVerdict, 0.5,
+		},
+		{
+			models.MultipleChoiceProblem, models.ProportionalScoring,
+			multiple, []int{0, 1, 2, 3}, models.RejectedVerdict, 0,
+		},
+		{
+			models.MultipleChoiceProblem, models.ProportionalScoring,
+			choice, []int{2}, models.RejectedVerdict, 0,
+		},
+		{
+			models.MultipleChoiceProblem, models.ProportionalScoring,
+			multiple, []int{1, 0}, models.AcceptedVerdict, 1,
+		},
+		{
+			models.MultipleChoiceProblem, models.PenaltyScoring,
+			multiple, []int{0}, models.PartiallyAcceptedVerdict, 0.5,
+		},
+		{
+			models.MultipleChoiceProblem, models.PenaltyScoring,
+			multiple, []int{0, 2}, models.RejectedVerdict, 0,
+		},
+		{
+			models.MultipleChoiceProblem, models.PenaltyScoring,
+			multiple, []int{0, 2, 3}, models.RejectedVerdict, 0,
+		},
+		{
+			models.SingleChoiceProblem, models.NegativeMarkingScoring,
+			choice, []int{0}, models.AcceptedVerdict, 1,
+		},
+		{
+			models.SingleChoiceProblem, models.NegativeMarkingScoring,
+			choice, []int{3}, models.RejectedVerdict, -1.0 / 3,
+		},
+		{
+			models.SingleChoiceProblem, models.PenaltyScoring,
+			choice, []int{3}, models.RejectedVerdict, 0,
+		},
+		{
+			models.MatchingProblem, models.AllOrNothingScoring,
+			matching, []int{3, 2, -1, 0}, models.RejectedVerdict, 0,
+		},
+		{
+			models.MatchingProblem, models.ProportionalScoring,
+			matching, []int{3, 2, -1, 0}, models.PartiallyAcceptedVerdict, 0.75,
+		},
+		{
+			models.MatchingProblem, models.PenaltyScoring,
+			matching, []int{3, 2, 0, -1}, models.PartiallyAcceptedVerdict, 0.25,
+		},
+	}
+	for i, test := range tests {
+		grader, err := GetGrader(test.Kind)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		result := grader.Grade(Task{
+			Key:     test.Key,
+			Answer:  models.QuizAttemptAnswer{Options: test.Options},
+			Options: 4,
+			Policy:  test.Policy,
+		})
+		if result.Verdict != test.Verdict {
+			t.Fatalf(
+				"Test %d: expected %v, got %v", i+1, test.Verdict, result.Verdict,
+			)
+		}
+		if math.Abs(result.Score-test.Score) > 1e-9 {
+			t.Fatalf(
+				"Test %d: expected score %v, got %v", i+1, test.Score, result.Score,
+			)
+		}
+	}
+}
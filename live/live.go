@@ -0,0 +1,684 @@
+// Package live implements instructor-paced quiz sessions.
+//
+// Host of session opens questions one by one and participants answer
+// them within a countdown. Every change of session is pushed to
+// subscribers of session, so transport (for example WebSocket) only
+// has to deliver messages and forward commands to session.
+package live
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/udovin/goquiz/grading"
+	"github.com/udovin/goquiz/models"
+)
+
+// Stage represents stage of live session.
+type Stage int
+
+const (
+	// WaitingStage represents session that waits for first question.
+	WaitingStage Stage = 1
+	// QuestionStage represents session with open question.
+	QuestionStage Stage = 2
+	// ClosedStage represents session with closed question.
+	ClosedStage Stage = 3
+	// RevealedStage represents session with revealed answer.
+	RevealedStage Stage = 4
+	// FinishedStage represents finished session.
+	FinishedStage Stage = 5
+)
+
+// String returns string representation.
+func (s Stage) String() string {
+	switch s {
+	case WaitingStage:
+		return "waiting"
+	case QuestionStage:
+		return "question"
+	case ClosedStage:
+		return "closed"
+	case RevealedStage:
+		return "revealed"
+	case FinishedStage:
+		return "finished"
+	default:
+		return fmt.Sprintf("Stage(%d)", s)
+	}
+}
+
+// MarshalText marshals stage to text.
+func (s Stage) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText unmarshals stage from text.
+func (s *Stage) UnmarshalText(data []byte) error {
+	switch v := string(data); v {
+	case "waiting":
+		*s = WaitingStage
+	case "question":
+		*s = QuestionStage
+	case "closed":
+		*s = ClosedStage
+	case "revealed":
+		*s = RevealedStage
+	case "finished":
+		*s = FinishedStage
+	default:
+		return fmt.Errorf("unsupported stage: %q", v)
+	}
+	return nil
+}
+
+// Types of messages pushed to subscribers.
+const (
+	// StateMessage contains full state of session.
+	StateMessage = "state"
+	// QuestionMessage contains opened question.
+	QuestionMessage = "question"
+	// CloseMessage notifies that question is closed.
+	CloseMessage = "close"
+	// RevealMessage contains answer key of closed question.
+	RevealMessage = "reveal"
+	// LeaderboardMessage contains leaderboard of session.
+	LeaderboardMessage = "leaderboard"
+	// ResultMessage contains result of answer of participant.
+	ResultMessage = "result"
+	// FinishMessage notifies that session is finished.
+	FinishMessage = "finish"
+	// ErrorMessage contains error of command.
+	ErrorMessage = "error"
+)
+
+// Question represents question of live session.
+type Question struct {
+	// Problem contains problem of question.
+	Problem models.Problem
+	// Points contains maximal amount of points for question.
+	Points int64
+	// Policy contains scoring policy of question.
+	Policy models.ScoringPolicy
+}
+
+// QuestionView represents question as it is shown to participants.
+type QuestionView struct {
+	// ProblemID contains ID of problem.
+	ProblemID int64 `json:"problem_id"`
+	// Kind contains kind of problem.
+	Kind models.ProblemKind `json:"kind"`
+	// Title contains title of problem.
+	Title string `json:"title"`
+	// Statement contains statement of problem.
+	Statement string `json:"statement,omitempty"`
+	// Options contains options of choice and matching problems.
+	Options []string `json:"options,omitempty"`
+	// Prompts contains prompts of matching problems.
+	Prompts []string `json:"prompts,omitempty"`
+	// Points contains maximal amount of points for question.
+	Points int64 `json:"points"`
+}
+
+// Result represents result of answer.
+type Result struct {
+	// Verdict contains verdict of answer.
+	Verdict models.Verdict `json:"verdict"`
+	// Score contains amount of points for answer.
+	Score float64 `json:"score"`
+}
+
+// LeaderboardEntry represents entry of leaderboard.
+type LeaderboardEntry struct {
+	// Rank contains position of participant.
+	Rank int `json:"rank"`
+	// AccountID contains ID of participant account.
+	AccountID int64 `json:"account_id"`
+	// Name contains name of participant.
+	Name string `json:"name"`
+	// Score contains total score of participant.
+	Score float64 `json:"score"`
+}
+
+// State represents state of session.
+type State struct {
+	// Code contains join code of session.
+	Code string `json:"code"`
+	// QuizID contains ID of quiz.
+	QuizID int64 `json:"quiz_id"`
+	// Stage contains stage of session.
+	Stage Stage `json:"stage"`
+	// Index contains index of current question.
+	Index int `json:"index"`
+	// Questions contains amount of questions.
+	Questions int `json:"questions"`
+	// Players contains amount of joined participants.
+	Players int `json:"players"`
+	// Answers contains amount of answers for current question.
+	Answers int `json:"answers"`
+	// Deadline contains time when current question closes.
+	Deadline int64 `json:"deadline,omitempty"`
+	// Question contains current question.
+	Question *QuestionView `json:"question,omitempty"`
+	// Score contains total score of subscriber.
+	Score *float64 `json:"score,omitempty"`
+	// Result contains result of subscriber for current question.
+	Result *Result `json:"result,omitempty"`
+}
+
+// Message represents message pushed to subscribers.
+type Message struct {
+	// Type contains type of message.
+	Type string `json:"type"`
+	// Index contains index of question.
+	Index int `json:"index"`
+	// State contains state of session for StateMessage.
+	State *State `json:"state,omitempty"`
+	// Question contains question for QuestionMessage.
+	Question *QuestionView `json:"question,omitempty"`
+	// Deadline contains time when question closes.
+	Deadline int64 `json:"deadline,omitempty"`
+	// Answer contains answer key for RevealMessage.
+	Answer *models.ProblemAnswer `json:"answer,omitempty"`
+	// Correct contains amount of correct answers for RevealMessage.
+	Correct *int `json:"correct,omitempty"`
+	// Result contains result of answer for ResultMessage.
+	Result *Result `json:"result,omitempty"`
+	// Leaderboard contains entries for LeaderboardMessage.
+	Leaderboard []LeaderboardEntry `json:"leaderboard,omitempty"`
+	// Message contains error message for ErrorMessage.
+	Message string `json:"message,omitempty"`
+}
+
+// Subscriber represents receiver of session messages.
+type Subscriber interface {
+	// AccountID returns ID of subscribed account.
+	AccountID() int64
+	// Send should deliver message without blocking.
+	Send(Message)
+	// Close should close subscriber.
+	Close()
+}
+
+type player struct {
+	accountID int64
+	name      string
+	score     float64
+	results   map[int]Result
+}
+
+// Session represents live session of quiz.
+type Session struct {
+	code        string
+	quizID      int64
+	hostID      int64
+	questions   []Question
+	mutex       sync.Mutex
+	stage       Stage
+	index       int
+	deadline    time.Time
+	players     map[int64]*player
+	subscribers map[Subscriber]struct{}
+	updateTime  time.Time
+}
+
+// Code returns join code of session.
+func (s *Session) Code() string {
+	return s.code
+}
+
+// QuizID returns ID of quiz of session.
+func (s *Session) QuizID() int64 {
+	return s.quizID
+}
+
+// IsHost reports whether account is host of session.
+func (s *Session) IsHost(accountID int64) bool {
+	return s.hostID == accountID
+}
+
+// State returns state of session for specified account.
+func (s *Session) State(accountID int64) State {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.getState(accountID)
+}
+
+// Join adds participant to session.
+//
+// Joining session for the second time keeps score of participant.
+func (s *Session) Join(accountID int64, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stage == FinishedStage {
+		return fmt.Errorf("session is finished")
+	}
+	if s.IsHost(accountID) {
+		return nil
+	}
+	if _, ok := s.players[accountID]; !ok {
+		s.players[accountID] = &player{
+			accountID: accountID,
+			name:      name,
+			results:   map[int]Result{},
+		}
+	}
+	return nil
+}
+
+// Subscribe subscribes to messages of session.
+//
+// Subscriber immediately receives current state of session, so host
+// and participants can restore their view after page reload.
+func (s *Session) Subscribe(subscriber Subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subscribers[subscriber] = struct{}{}
+	state := s.getState(subscriber.AccountID())
+	subscriber.Send(Message{
+		Type: StateMessage, Index: state.Index, State: &state,
+	})
+}
+
+// Unsubscribe unsubscribes from messages of session.
+func (s *Session) Unsubscribe(subscriber Subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.subscribers, subscriber)
+}
+
+// Next opens next question with countdown of specified duration.
+func (s *Session) Next(duration time.Duration, now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch s.stage {
+	case WaitingStage, ClosedStage, RevealedStage:
+	default:
+		return fmt.Errorf("question can not be opened in stage %q", s.stage)
+	}
+	index := s.index + 1
+	if s.stage == WaitingStage {
+		index = 0
+	}
+	if index >= len(s.questions) {
+		return fmt.Errorf("there are no more questions")
+	}
+	if duration <= 0 {
+		return fmt.Errorf("duration should be positive")
+	}
+	s.stage = QuestionStage
+	s.index = index
+	s.deadline = now.Add(duration)
+	s.updateTime = now
+	view := makeQuestionView(s.questions[index])
+	s.broadcast(Message{
+		Type:     QuestionMessage,
+		Index:    index,
+		Question: &view,
+		Deadline: s.deadline.Unix(),
+	})
+	return nil
+}
+
+// Close closes current question before deadline.
+func (s *Session) Close(now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stage != QuestionStage {
+		return fmt.Errorf("there is no open question")
+	}
+	s.close(now)
+	return nil
+}
+
+// Reveal reveals answer key of closed question.
+func (s *Session) Reveal(now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stage == QuestionStage {
+		s.close(now)
+	}
+	if s.stage != ClosedStage {
+		return fmt.Errorf("there is no closed question")
+	}
+	var answer models.ProblemAnswer
+	if err := s.questions[s.index].Problem.ScanAnswer(&answer); err != nil {
+		return err
+	}
+	correct := 0
+	for _, player := range s.players {
+		if result, ok := player.results[s.index]; ok &&
+			result.Verdict == models.AcceptedVerdict {
+			correct++
+		}
+	}
+	s.stage = RevealedStage
+	s.updateTime = now
+	s.broadcast(Message{
+		Type:    RevealMessage,
+		Index:   s.index,
+		Answer:  &answer,
+		Correct: &correct,
+	})
+	return nil
+}
+
+// ShowLeaderboard pushes leaderboard to subscribers.
+func (s *Session) ShowLeaderboard() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.broadcast(Message{
+		Type:        LeaderboardMessage,
+		Index:       s.index,
+		Leaderboard: s.getLeaderboard(),
+	})
+}
+
+// Leaderboard returns leaderboard of session.
+func (s *Session) Leaderboard() []LeaderboardEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.getLeaderboard()
+}
+
+// Finish finishes session and pushes final leaderboard.
+func (s *Session) Finish(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stage == FinishedStage {
+		return
+	}
+	s.stage = FinishedStage
+	s.updateTime = now
+	s.broadcast(Message{
+		Type:        FinishMessage,
+		Index:       s.index,
+		Leaderboard: s.getLeaderboard(),
+	})
+}
+
+// Answer grades answer of participant for current question.
+//
+// Only first answer of participant for question is accepted.
+func (s *Session) Answer(
+	accountID int64, index int, answer models.QuizAttemptAnswer,
+	now time.Time,
+) (Result, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	player, ok := s.players[accountID]
+	if !ok {
+		return Result{}, fmt.Errorf("account has not joined session")
+	}
+	if s.stage != QuestionStage || index != s.index ||
+		!now.Before(s.deadline) {
+		return Result{}, fmt.Errorf("question %d is not open", index)
+	}
+	if _, ok := player.results[index]; ok {
+		return Result{}, fmt.Errorf("question %d is already answered", index)
+	}
+	question := s.questions[index]
+	graded, err := grading.GradeProblem(
+		question.Problem, &answer, nil, question.Policy,
+	)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{
+		Verdict: graded.Verdict,
+		Score:   graded.Score * float64(question.Points),
+	}
+	player.results[index] = result
+	player.score += result.Score
+	s.updateTime = now
+	for subscriber := range s.subscribers {
+		if subscriber.AccountID() == accountID {
+			subscriber.Send(Message{
+				Type: ResultMessage, Index: index, Result: &result,
+			})
+		}
+	}
+	return result, nil
+}
+
+// Tick closes current question if its deadline is passed.
+func (s *Session) Tick(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stage == QuestionStage && !now.Before(s.deadline) {
+		s.close(now)
+	}
+}
+
+// Shutdown closes all subscribers of session.
+func (s *Session) Shutdown() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for subscriber := range s.subscribers {
+		subscriber.Close()
+		delete(s.subscribers, subscriber)
+	}
+}
+
+func (s *Session) close(now time.Time) {
+	s.stage = ClosedStage
+	s.updateTime = now
+	s.broadcast(Message{Type: CloseMessage, Index: s.index})
+}
+
+func (s *Session) broadcast(message Message) {
+	for subscriber := range s.subscribers {
+		subscriber.Send(message)
+	}
+}
+
+func (s *Session) getState(accountID int64) State {
+	state := State{
+		Code:      s.code,
+		QuizID:    s.quizID,
+		Stage:     s.stage,
+		Index:     s.index,
+		Questions: len(s.questions),
+		Players:   len(s.players),
+	}
+	if s.stage == QuestionStage || s.stage == ClosedStage ||
+		s.stage == RevealedStage {
+		view := makeQuestionView(s.questions[s.index])
+		state.Question = &view
+		for _, player := range s.players {
+			if _, ok := player.results[s.index]; ok {
+				state.Answers++
+			}
+		}
+	}
+	if s.stage == QuestionStage {
+		state.Deadline = s.deadline.Unix()
+	}
+	if player, ok := s.players[accountID]; ok {
+		score := player.score
+		state.Score = &score
+		if result, ok := player.results[s.index]; ok &&
+			state.Question != nil {
+			state.Result = &result
+		}
+	}
+	return state
+}
+
+func (s *Session) getLeaderboard() []LeaderboardEntry {
+	entries := []LeaderboardEntry{}
+	for _, player := range s.players {
+		entries = append(entries, LeaderboardEntry{
+			AccountID: player.accountID,
+			Name:      player.name,
+			Score:     player.score,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].AccountID < entries[j].AccountID
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries
+}
+
+func makeQuestionView(question Question) QuestionView {
+	view := QuestionView{
+		ProblemID: question.Problem.ID,
+		Kind:      question.Problem.Kind,
+		Title:     question.Problem.Title,
+		Statement: question.Problem.Statement,
+		Points:    question.Points,
+	}
+	var config models.ProblemConfig
+	if err := question.Problem.ScanConfig(&config); err == nil {
+		for _, option := range config.Options {
+			view.Options = append(view.Options, option.Text)
+		}
+		for _, prompt := range config.Prompts {
+			view.Prompts = append(view.Prompts, prompt.Text)
+		}
+	}
+	return view
+}
+
+const (
+	// codeAlphabet contains characters of join codes without
+	// characters that are easily confused.
+	codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// codeLength contains length of join codes.
+	codeLength = 6
+	// finishedSessionTTL contains time after which finished
+	// session is removed.
+	finishedSessionTTL = time.Hour
+	// idleSessionTTL contains time after which session without
+	// activity is removed.
+	idleSessionTTL = 12 * time.Hour
+	// tickInterval contains interval between checks of deadlines.
+	tickInterval = 200 * time.Millisecond
+)
+
+// Manager manages live sessions.
+//
+// Sessions are stored in memory of server, so participants and host
+// should be connected to the same server.
+type Manager struct {
+	mutex    sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewManager creates a new instance of Manager.
+func NewManager() *Manager {
+	return &Manager{sessions: map[string]*Session{}}
+}
+
+// Create creates a new session of quiz with specified questions.
+func (m *Manager) Create(
+	quizID, hostID int64, questions []Question, now time.Time,
+) (*Session, error) {
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("session should have questions")
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var code string
+	for {
+		var err error
+		code, err = generateCode()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m.sessions[code]; !ok {
+			break
+		}
+	}
+	session := &Session{
+		code:        code,
+		quizID:      quizID,
+		hostID:      hostID,
+		questions:   questions,
+		stage:       WaitingStage,
+		players:     map[int64]*player{},
+		subscribers: map[Subscriber]struct{}{},
+		updateTime:  now,
+	}
+	m.sessions[code] = session
+	return session, nil
+}
+
+// Get returns session by join code.
+func (m *Manager) Get(code string) (*Session, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	session, ok := m.sessions[code]
+	return session, ok
+}
+
+// Tick closes expired questions and removes outdated sessions.
+func (m *Manager) Tick(now time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for code, session := range m.sessions {
+		session.Tick(now)
+		session.mutex.Lock()
+		ttl := idleSessionTTL
+		if session.stage == FinishedStage {
+			ttl = finishedSessionTTL
+		}
+		expired := now.Sub(session.updateTime) > ttl
+		session.mutex.Unlock()
+		if expired {
+			session.Shutdown()
+			delete(m.sessions, code)
+		}
+	}
+}
+
+// Run closes expired questions until context is done.
+//
+// Run should be started using core.Core.StartTask. Subscribers of all
+// sessions are closed when context is done.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			m.Shutdown()
+			return
+		case now := <-ticker.C:
+			m.Tick(now)
+		}
+	}
+}
+
+// Shutdown closes subscribers of all sessions.
+func (m *Manager) Shutdown() {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	for _, session := range m.sessions {
+		session.Shutdown()
+	}
+}
+
+func generateCode() (string, error) {
+	code := make([]byte, codeLength)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,197 @@
+package live
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/udovin/goquiz/models"
+)
+
+type testSubscriber struct {
+	accountID int64
+	messages  []Message
+	closed    bool
+}
+
+func (s *testSubscriber) AccountID() int64 {
+	return s.accountID
+}
+
+func (s *testSubscriber) Send(message Message) {
+	s.messages = append(s.messages, message)
+}
+
+func (s *testSubscriber) Close() {
+	s.closed = true
+}
+
+func (s *testSubscriber) last() Message {
+	return s.messages[len(s.messages)-1]
+}
+
+func testQuestions() []Question {
+	return []Question{
+		{
+			Problem: models.Problem{
+				Kind:   models.SingleChoiceProblem,
+				Title:  "Choice",
+				Config: models.JSON(`{"options":[{"text":"A"},{"text":"B"}]}`),
+				Answer: models.JSON(`{"options":[1]}`),
+			},
+			Points: 2,
+			Policy: models.AllOrNothingScoring,
+		},
+		{
+			Problem: models.Problem{
+				Kind:   models.NumericProblem,
+				Title:  "Numeric",
+				Answer: models.JSON(`{"value":4}`),
+			},
+			Points: 1,
+			Policy: models.AllOrNothingScoring,
+		},
+	}
+}
+
+func TestSession(t *testing.T) {
+	manager := NewManager()
+	now := time.Unix(1000, 0)
+	if _, err := manager.Create(1, 1, nil, now); err == nil {
+		t.Fatal("Expected error")
+	}
+	session, err := manager.Create(1, 1, testQuestions(), now)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(session.Code()) != codeLength {
+		t.Fatalf("Unexpected code: %q", session.Code())
+	}
+	if found, ok := manager.Get(session.Code()); !ok || found != session {
+		t.Fatal("Session not found")
+	}
+	host := &testSubscriber{accountID: 1}
+	session.Subscribe(host)
+	if msg := host.last(); msg.Type != StateMessage ||
+		msg.State.Stage != WaitingStage {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+	for _, id := range []int64{2, 3} {
+		if err := session.Join(id, "player"); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	first := &testSubscriber{accountID: 2}
+	session.Subscribe(first)
+	if _, err := session.Answer(2, 0, models.QuizAttemptAnswer{
+		Options: []int{1},
+	}, now); err == nil {
+		t.Fatal("Expected error")
+	}
+	if err := session.Next(10*time.Second, now); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if msg := first.last(); msg.Type != QuestionMessage ||
+		msg.Question.Title != "Choice" || len(msg.Question.Options) != 2 {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+	if result, err := session.Answer(2, 0, models.QuizAttemptAnswer{
+		Options: []int{1},
+	}, now); err != nil {
+		t.Fatal("Error:", err)
+	} else if result.Verdict != models.AcceptedVerdict || result.Score != 2 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if msg := first.last(); msg.Type != ResultMessage {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+	if _, err := session.Answer(2, 0, models.QuizAttemptAnswer{
+		Options: []int{0},
+	}, now); err == nil {
+		t.Fatal("Expected error")
+	}
+	if _, err := session.Answer(4, 0, models.QuizAttemptAnswer{
+		Options: []int{0},
+	}, now); err == nil {
+		t.Fatal("Expected error")
+	}
+	// Host reload restores current question.
+	reloaded := &testSubscriber{accountID: 1}
+	session.Subscribe(reloaded)
+	if state := reloaded.last().State; state.Stage != QuestionStage ||
+		state.Question == nil || state.Answers != 1 {
+		t.Fatalf("Unexpected state: %+v", state)
+	}
+	// Answers after deadline are rejected.
+	if _, err := session.Answer(3, 0, models.QuizAttemptAnswer{
+		Options: []int{1},
+	}, now.Add(10*time.Second)); err == nil {
+		t.Fatal("Expected error")
+	}
+	manager.Tick(now.Add(10 * time.Second))
+	if msg := host.last(); msg.Type != CloseMessage {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+	if err := session.Reveal(now); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if msg := host.last(); msg.Type != RevealMessage ||
+		*msg.Correct != 1 || msg.Answer.Options[0] != 1 {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+	if err := session.Next(10*time.Second, now); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := session.Answer(3, 1, models.QuizAttemptAnswer{
+		Value: new(float64),
+	}, now); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := session.Close(now); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := session.Next(10*time.Second, now); err == nil {
+		t.Fatal("Expected error")
+	}
+	leaderboard := session.Leaderboard()
+	if len(leaderboard) != 2 || leaderboard[0].AccountID != 2 ||
+		leaderboard[0].Score != 2 || leaderboard[1].Rank != 2 {
+		t.Fatalf("Unexpected leaderboard: %+v", leaderboard)
+	}
+	session.Finish(now)
+	if msg := first.last(); msg.Type != FinishMessage ||
+		len(msg.Leaderboard) != 2 {
+		t.Fatalf("Unexpected message: %+v", msg)
+	}
+	if err := session.Join(5, "late"); err == nil {
+		t.Fatal("Expected error")
+	}
+	manager.Tick(now.Add(finishedSessionTTL + time.Second))
+	if _, ok := manager.Get(session.Code()); ok {
+		t.Fatal("Expected session to be removed")
+	}
+	if !first.closed {
+		t.Fatal("Expected subscriber to be closed")
+	}
+}
+
+func TestManagerRun(t *testing.T) {
+	manager := NewManager()
+	session, err := manager.Create(1, 1, testQuestions(), time.Now())
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	host := &testSubscriber{accountID: 1}
+	session.Subscribe(host)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		manager.Run(ctx)
+	}()
+	cancel()
+	<-done
+	if !host.closed {
+		t.Fatal("Expected subscriber to be closed")
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
@@ -9,6 +12,7 @@ import (
 	"os/signal"
 	"path"
 	"path/filepath"
+	"sort"
 	"sync"
 	"syscall"
 
@@ -87,6 +91,12 @@ func serverMain(cmd *cobra.Command, _ []string) {
 	}
 	defer c.Stop()
 	v := api.NewView(c)
+	c.StartTask(v.Statistics.Run)
+	c.StartTask(v.Live.Run)
+	c.StartTask(c.Scheduler.Run)
+	for i := 0; i < cfg.GetTaskWorkers(); i++ {
+		c.StartTask(v.Tasks.Run)
+	}
 	var waiter sync.WaitGroup
 	defer waiter.Wait()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -175,6 +185,225 @@ func migrateMain(cmd *cobra.Command, args []string) {
 	}
 }
 
+// importGIFTMain imports problems from GIFT file to pool.
+func importGIFTMain(cmd *cobra.Command, args []string) {
+	importProblems(cmd, args[0], "gift", "text/plain")
+}
+
+// importQTIMain imports problems from IMS QTI 2.1 content package
+// to pool.
+func importQTIMain(cmd *cobra.Command, args []string) {
+	importProblems(cmd, args[0], "qti", "application/zip")
+}
+
+// importProblems imports problems from file with specified format
+// to pool.
+//
+// Problems are imported by running server using unix socket API.
+func importProblems(cmd *cobra.Command, name, format, contentType string) {
+	poolID, err := cmd.Flags().GetInt64("pool")
+	if err != nil {
+		panic(err)
+	}
+	cfg, err := getConfig(cmd)
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	var resp struct {
+		Problems []struct {
+			ID    int64  `json:"id"`
+			Title string `json:"title"`
+		} `json:"problems"`
+	}
+	if err := newSocketClient(cfg.SocketFile).Do(
+		http.MethodPost, fmt.Sprintf("/v0/pools/%d/import/%s", poolID, format),
+		contentType, file, http.StatusCreated, &resp,
+	); err != nil {
+		fmt.Fprintln(os.Stderr, "Import failed:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Imported %d problems to pool %d\n", len(resp.Problems), poolID)
+}
+
+// exportQTIMain exports problems of pool or quiz to IMS QTI 2.1
+// content package.
+//
+// Problems are exported by running server using unix socket API.
+func exportQTIMain(cmd *cobra.Command, _ []string) {
+	poolID, err := cmd.Flags().GetInt64("pool")
+	if err != nil {
+		panic(err)
+	}
+	quizID, err := cmd.Flags().GetInt64("quiz")
+	if err != nil {
+		panic(err)
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		panic(err)
+	}
+	var path string
+	switch {
+	case poolID != 0 && quizID == 0:
+		path = fmt.Sprintf("/v0/pools/%d/export/qti", poolID)
+	case quizID != 0 && poolID == 0:
+		path = fmt.Sprintf("/v0/quizzes/%d/export/qti", quizID)
+	default:
+		fmt.Fprintln(os.Stderr, "Either --pool or --quiz should be specified")
+		os.Exit(1)
+	}
+	cfg, err := getConfig(cmd)
+	if err != nil {
+		panic(err)
+	}
+	var data bytes.Buffer
+	if err := newSocketClient(cfg.SocketFile).Do(
+		http.MethodGet, path, "", nil, http.StatusOK, &data,
+	); err != nil {
+		fmt.Fprintln(os.Stderr, "Export failed:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(output, data.Bytes(), 0644); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Exported problems to %s\n", output)
+}
+
+// exportResultsMain exports results of quiz to CSV or XLSX file.
+//
+// Results are exported by running server using unix socket API.
+func exportResultsMain(cmd *cobra.Command, _ []string) {
+	quizID, err := cmd.Flags().GetInt64("quiz")
+	if err != nil {
+		panic(err)
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		panic(err)
+	}
+	layout, err := cmd.Flags().GetString("layout")
+	if err != nil {
+		panic(err)
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		panic(err)
+	}
+	cfg, err := getConfig(cmd)
+	if err != nil {
+		panic(err)
+	}
+	query := url.Values{}
+	query.Set("format", format)
+	query.Set("layout", layout)
+	var data bytes.Buffer
+	if err := newSocketClient(cfg.SocketFile).Do(
+		http.MethodGet,
+		fmt.Sprintf("/v0/quizzes/%d/results/export?%s", quizID, query.Encode()),
+		"", nil, http.StatusOK, &data,
+	); err != nil {
+		fmt.Fprintln(os.Stderr, "Export failed:", err)
+		os.Exit(1)
+	}
+	// Results are written to stdout when output is not specified.
+	if len(output) == 0 {
+		if _, err := os.Stdout.Write(data.Bytes()); err != nil {
+			panic(err)
+		}
+		return
+	}
+	if err := os.WriteFile(output, data.Bytes(), 0644); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Exported results to %s\n", output)
+}
+
+// getSocketSettings returns settings of running server using unix
+// socket API.
+func getSocketSettings(client *socketClient) ([]api.Setting, error) {
+	var resp api.Settings
+	if err := client.Do(
+		http.MethodGet, "/v0/settings", "", nil, http.StatusOK, &resp,
+	); err != nil {
+		return nil, err
+	}
+	sort.Slice(resp.Settings, func(i, j int) bool {
+		return resp.Settings[i].Key < resp.Settings[j].Key
+	})
+	return resp.Settings, nil
+}
+
+// settingsGetMain prints value of setting or all settings when key
+// is not specified.
+func settingsGetMain(cmd *cobra.Command, args []string) {
+	cfg, err := getConfig(cmd)
+	if err != nil {
+		panic(err)
+	}
+	client := newSocketClient(cfg.SocketFile)
+	if len(args) == 0 {
+		settings, err := getSocketSettings(client)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Unable to get settings:", err)
+			os.Exit(1)
+		}
+		for _, setting := range settings {
+			fmt.Printf("%s=%s\n", setting.Key, setting.Value)
+		}
+		return
+	}
+	var setting api.Setting
+	if err := client.Do(
+		http.MethodGet, "/v0/settings/"+url.PathEscape(args[0]),
+		"", nil, http.StatusOK, &setting,
+	); err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to get setting:", err)
+		os.Exit(1)
+	}
+	fmt.Println(setting.Value)
+}
+
+// settingsSetMain creates or updates setting.
+//
+// Settings are updated by running server using unix socket API.
+func settingsSetMain(cmd *cobra.Command, args []string) {
+	cfg, err := getConfig(cmd)
+	if err != nil {
+		panic(err)
+	}
+	client := newSocketClient(cfg.SocketFile)
+	data, err := json.Marshal(map[string]string{
+		"key": args[0], "value": args[1],
+	})
+	if err != nil {
+		panic(err)
+	}
+	err = client.Do(
+		http.MethodPatch, "/v0/settings/"+url.PathEscape(args[0]),
+		"application/json", bytes.NewReader(data), http.StatusOK, nil,
+	)
+	// Setting is created only when it does not exist.
+	if respErr, ok := err.(socketError); ok &&
+		respErr.Code == http.StatusNotFound {
+		err = client.Do(
+			http.MethodPost, "/v0/settings", "application/json",
+			bytes.NewReader(data), http.StatusCreated, nil,
+		)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to set setting:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Setting %q is set to %q\n", args[0], args[1])
+}
+
 func versionMain(cmd *cobra.Command, _ []string) {
 	println("GoQuiz version:", config.Version)
 }
@@ -195,6 +424,73 @@ func main() {
 	}
 	migrateCmd.Flags().Bool("create-data", false, "Create default objects")
 	rootCmd.AddCommand(&migrateCmd)
+	importCmd := cobra.Command{
+		Use:   "import",
+		Short: "Imports problems",
+	}
+	importGIFTCmd := cobra.Command{
+		Use:   "gift <file>",
+		Args:  cobra.ExactArgs(1),
+		Run:   importGIFTMain,
+		Short: "Imports problems from GIFT file to pool",
+	}
+	importGIFTCmd.Flags().Int64("pool", 0, "ID of pool")
+	_ = importGIFTCmd.MarkFlagRequired("pool")
+	importCmd.AddCommand(&importGIFTCmd)
+	importQTICmd := cobra.Command{
+		Use:   "qti <file>",
+		Args:  cobra.ExactArgs(1),
+		Run:   importQTIMain,
+		Short: "Imports problems from QTI 2.1 package to pool",
+	}
+	importQTICmd.Flags().Int64("pool", 0, "ID of pool")
+	_ = importQTICmd.MarkFlagRequired("pool")
+	importCmd.AddCommand(&importQTICmd)
+	rootCmd.AddCommand(&importCmd)
+	exportCmd := cobra.Command{
+		Use:   "export",
+		Short: "Exports problems and results",
+	}
+	exportQTICmd := cobra.Command{
+		Use:   "qti",
+		Args:  cobra.NoArgs,
+		Run:   exportQTIMain,
+		Short: "Exports problems of pool or quiz to QTI 2.1 package",
+	}
+	exportQTICmd.Flags().Int64("pool", 0, "ID of pool")
+	exportQTICmd.Flags().Int64("quiz", 0, "ID of quiz")
+	exportQTICmd.Flags().String("output", "problems.zip", "Output file")
+	exportCmd.AddCommand(&exportQTICmd)
+	exportResultsCmd := cobra.Command{
+		Use:   "results",
+		Args:  cobra.NoArgs,
+		Run:   exportResultsMain,
+		Short: "Exports results of quiz to CSV or XLSX file",
+	}
+	exportResultsCmd.Flags().Int64("quiz", 0, "ID of quiz")
+	_ = exportResultsCmd.MarkFlagRequired("quiz")
+	exportResultsCmd.Flags().String("format", "csv", "Format of file (csv or xlsx)")
+	exportResultsCmd.Flags().String("layout", "wide", "Layout of results (wide or long)")
+	exportResultsCmd.Flags().String("output", "", "Output file (stdout by default)")
+	exportCmd.AddCommand(&exportResultsCmd)
+	rootCmd.AddCommand(&exportCmd)
+	settingsCmd := cobra.Command{
+		Use:   "settings",
+		Short: "Manages settings of running server",
+	}
+	settingsCmd.AddCommand(&cobra.Command{
+		Use:   "get [key]",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   settingsGetMain,
+		Short: "Prints value of setting or all settings",
+	})
+	settingsCmd.AddCommand(&cobra.Command{
+		Use:   "set <key> <value>",
+		Args:  cobra.ExactArgs(2),
+		Run:   settingsSetMain,
+		Short: "Creates or updates setting",
+	})
+	rootCmd.AddCommand(&settingsCmd)
 	rootCmd.AddCommand(&cobra.Command{
 		Use:   "version",
 		Run:   versionMain,
@@ -0,0 +1,33 @@
+package managers
+
+import (
+	"context"
+	"time"
+
+	"github.com/udovin/goquiz/models"
+)
+
+// cleanupSessions deletes expired sessions.
+//
+// Sessions are deleted one by one through store, so every deletion
+// is saved as event of session.
+func (m *TaskManager) cleanupSessions(
+	ctx context.Context, task models.Task,
+) error {
+	sessions, err := m.Sessions.FindExpired(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.Sessions.Delete(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	if len(sessions) > 0 {
+		m.logger.Info("Deleted ", len(sessions), " expired sessions")
+	}
+	return nil
+}
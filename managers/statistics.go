@@ -0,0 +1,241 @@
+package managers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/udovin/goquiz/analysis"
+	"github.com/udovin/goquiz/core"
+	"github.com/udovin/goquiz/models"
+)
+
+// statisticsInterval contains interval between updates of statistics.
+const statisticsInterval = 5 * time.Minute
+
+// ProblemStatistics represents item statistics of problem.
+type ProblemStatistics struct {
+	analysis.Statistics
+	// ProblemID contains ID of problem.
+	ProblemID int64
+}
+
+// QuizStatistics represents item statistics of quiz problems.
+type QuizStatistics struct {
+	// QuizID contains ID of quiz.
+	QuizID int64
+	// Attempts contains amount of finished attempts.
+	Attempts int
+	// AverageScore contains average score of finished attempts.
+	AverageScore float64
+	// Problems contains statistics of problems computed using only
+	// attempts of quiz.
+	Problems []ProblemStatistics
+}
+
+// StatisticsManager computes item statistics of problems from
+// finished quiz attempts.
+//
+// Statistics are computed periodically in background, so they can
+// be outdated for several minutes.
+type StatisticsManager struct {
+	Quizes       *models.QuizStore
+	QuizAttempts *models.QuizAttemptStore
+	Problems     *models.ProblemStore
+	mutex        sync.RWMutex
+	problems     map[int64]ProblemStatistics
+	quizzes      map[int64]QuizStatistics
+	updateTime   time.Time
+}
+
+// NewStatisticsManager creates a new instance of StatisticsManager.
+func NewStatisticsManager(core *core.Core) *StatisticsManager {
+	return &StatisticsManager{
+		Quizes:       core.Quizes,
+		QuizAttempts: core.QuizAttempts,
+		Problems:     core.Problems,
+		problems:     map[int64]ProblemStatistics{},
+		quizzes:      map[int64]QuizStatistics{},
+	}
+}
+
+// GetProblem returns statistics of problem and time of last update.
+func (m *StatisticsManager) GetProblem(
+	id int64,
+) (ProblemStatistics, time.Time) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	stats, ok := m.problems[id]
+	if !ok {
+		stats.ProblemID = id
+	}
+	return stats, m.updateTime
+}
+
+// GetQuiz returns statistics of quiz and time of last update.
+func (m *StatisticsManager) GetQuiz(id int64) (QuizStatistics, time.Time) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	stats, ok := m.quizzes[id]
+	if !ok {
+		stats.QuizID = id
+	}
+	return stats, m.updateTime
+}
+
+// Run updates statistics periodically until context is done.
+//
+// Run should be started using core.Core.StartTask.
+func (m *StatisticsManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(statisticsInterval)
+	defer ticker.Stop()
+	for {
+		m.Update()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// problemResponses contains responses for problem and maximal
+// amount of options shown for problem.
+type problemResponses struct {
+	responses []analysis.Response
+	options   int
+}
+
+func (r *problemResponses) add(response analysis.Response, options int) {
+	r.responses = append(r.responses, response)
+	if options > r.options {
+		r.options = options
+	}
+}
+
+// Update recomputes statistics using current state of stores.
+func (m *StatisticsManager) Update() {
+	quizzes, err := m.Quizes.All()
+	if err != nil {
+		return
+	}
+	problems := map[int64]*problemResponses{}
+	quizStats := map[int64]QuizStatistics{}
+	for _, quiz := range quizzes {
+		attempts, err := m.QuizAttempts.FindByQuiz(quiz.ID)
+		if err != nil {
+			return
+		}
+		stats := QuizStatistics{QuizID: quiz.ID}
+		quizProblems := map[int64]*problemResponses{}
+		var scoreSum float64
+		for _, attempt := range attempts {
+			if attempt.Status != models.FinishedAttempt {
+				continue
+			}
+			var state models.QuizAttemptState
+			if err := attempt.ScanState(&state); err != nil {
+				continue
+			}
+			stats.Attempts++
+			scoreSum += state.Score
+			durations := getProblemDurations(attempt, state)
+			for i, attemptProblem := range state.Problems {
+				// Answers that are not graded yet are skipped.
+				if attemptProblem.Verdict == models.PendingVerdict {
+					continue
+				}
+				response := analysis.Response{
+					Correct:  attemptProblem.Verdict == models.AcceptedVerdict,
+					Rest:     state.Score - attemptProblem.Score,
+					Duration: durations[i],
+				}
+				options := 0
+				if m.isChoiceProblem(attemptProblem.ProblemID) {
+					options = len(attemptProblem.Options)
+					if answer := attemptProblem.Answer; answer != nil {
+						response.Options = answer.Options
+					}
+				}
+				id := attemptProblem.ProblemID
+				if _, ok := problems[id]; !ok {
+					problems[id] = &problemResponses{}
+				}
+				problems[id].add(response, options)
+				if _, ok := quizProblems[id]; !ok {
+					quizProblems[id] = &problemResponses{}
+				}
+				quizProblems[id].add(response, options)
+			}
+		}
+		if stats.Attempts > 0 {
+			stats.AverageScore = scoreSum / float64(stats.Attempts)
+		}
+		stats.Problems = makeProblemStatistics(quizProblems)
+		quizStats[quiz.ID] = stats
+	}
+	problemStats := map[int64]ProblemStatistics{}
+	for _, stats := range makeProblemStatistics(problems) {
+		problemStats[stats.ProblemID] = stats
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.problems = problemStats
+	m.quizzes = quizStats
+	m.updateTime = time.Now()
+}
+
+func (m *StatisticsManager) isChoiceProblem(id int64) bool {
+	problem, err := m.Problems.Get(id)
+	if err != nil {
+		return false
+	}
+	return problem.Kind == models.SingleChoiceProblem ||
+		problem.Kind == models.MultipleChoiceProblem
+}
+
+func makeProblemStatistics(
+	problems map[int64]*problemResponses,
+) []ProblemStatistics {
+	var stats []ProblemStatistics
+	for id, problem := range problems {
+		stats = append(stats, ProblemStatistics{
+			Statistics: analysis.Analyze(problem.responses, problem.options),
+			ProblemID:  id,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ProblemID < stats[j].ProblemID
+	})
+	return stats
+}
+
+// getProblemDurations returns time spent on every problem of attempt.
+//
+// Time spent on problem is estimated as time between its last answer
+// and previous answer of attempt (or start of attempt).
+func getProblemDurations(
+	attempt models.QuizAttempt, state models.QuizAttemptState,
+) []int64 {
+	var order []int
+	for i, attemptProblem := range state.Problems {
+		if attemptProblem.AnswerTime != 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return state.Problems[order[i]].AnswerTime <
+			state.Problems[order[j]].AnswerTime
+	})
+	durations := make([]int64, len(state.Problems))
+	last := attempt.StartTime
+	for _, i := range order {
+		answerTime := state.Problems[i].AnswerTime
+		if answerTime > last {
+			durations[i] = answerTime - last
+		}
+		last = answerTime
+	}
+	return durations
+}
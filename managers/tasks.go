@@ -0,0 +1,285 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+
+	"github.com/udovin/goquiz/core"
+	"github.com/udovin/goquiz/models"
+)
+
+const (
+	// taskLease contains duration of task lease.
+	//
+	// Lease is extended while task is processed, so task is claimed
+	// again only when worker is crashed or lost database connection.
+	taskLease = 30 * time.Second
+	// taskPingInterval contains interval between extensions of lease.
+	taskPingInterval = 10 * time.Second
+	// taskPollInterval contains interval between polls of empty queue.
+	taskPollInterval = time.Second
+	// minTaskBackoff contains delay before first retry of task.
+	minTaskBackoff = 10 * time.Second
+	// maxTaskBackoff contains maximal delay before retry of task.
+	maxTaskBackoff = time.Hour
+	// taskRetention contains duration for which finished tasks are kept.
+	taskRetention = 7 * 24 * time.Hour
+)
+
+// TaskHandler represents handler of task.
+//
+// Context of handler is cancelled when lease of task is lost or
+// server is stopped.
+type TaskHandler func(ctx context.Context, task models.Task) error
+
+// TaskManager runs background tasks using registered handlers.
+//
+// Failed tasks are retried with exponential backoff. Workers claim
+// only tasks with registered handlers, so servers with different
+// sets of handlers can share the same queue.
+type TaskManager struct {
+	Tasks        *models.TaskStore
+	Sessions     *models.SessionStore
+	Settings     *models.SettingStore
+	Visits       *models.VisitStore
+	VisitRollups *models.VisitRollupStore
+	core         *core.Core
+	logger       *log.Logger
+	mutex        sync.RWMutex
+	handlers     map[models.TaskKind]TaskHandler
+}
+
+// NewTaskManager creates a new instance of TaskManager.
+func NewTaskManager(core *core.Core) *TaskManager {
+	m := TaskManager{
+		Tasks:        core.Tasks,
+		Sessions:     core.Sessions,
+		Settings:     core.Settings,
+		Visits:       core.Visits,
+		VisitRollups: core.VisitRollups,
+		core:         core,
+		logger:       core.Logger(),
+		handlers:     map[models.TaskKind]TaskHandler{},
+	}
+	m.Register(models.CleanupSessionsTask, m.cleanupSessions)
+	m.Register(models.CleanupVisitsTask, m.cleanupVisits)
+	m.Register(models.CleanupTasksTask, m.cleanupTasks)
+	return &m
+}
+
+// Register registers handler for tasks of specified kind.
+func (m *TaskManager) Register(kind models.TaskKind, handler TaskHandler) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.handlers[kind] = handler
+}
+
+// Enqueue creates queued task of specified kind with config.
+func (m *TaskManager) Enqueue(
+	ctx context.Context, kind models.TaskKind, config any,
+) (models.Task, error) {
+	task := models.Task{
+		Status: models.QueuedTask,
+		Kind:   kind,
+	}
+	if err := task.SetConfig(config); err != nil {
+		return models.Task{}, err
+	}
+	if err := m.Tasks.Create(ctx, &task); err != nil {
+		return models.Task{}, err
+	}
+	return task, nil
+}
+
+// Run runs worker that processes tasks until context is cancelled.
+//
+// Several workers can be started for parallel processing of tasks.
+func (m *TaskManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(taskPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if m.Process(ctx) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process claims and processes single task.
+//
+// Returns false if there is no task to process.
+func (m *TaskManager) Process(ctx context.Context) bool {
+	task, err := m.Tasks.PopQueued(ctx, taskLease, m.hasHandler)
+	if err != nil {
+		if err != sql.ErrNoRows && ctx.Err() == nil {
+			m.logger.Error("Unable to claim task: ", err)
+		}
+		return false
+	}
+	m.mutex.RLock()
+	handler := m.handlers[task.Kind]
+	m.mutex.RUnlock()
+	taskCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var waiter sync.WaitGroup
+	waiter.Add(1)
+	go func() {
+		defer waiter.Done()
+		defer cancel()
+		m.pingTask(taskCtx, &task)
+	}()
+	err = m.runHandler(taskCtx, handler, task)
+	cancel()
+	waiter.Wait()
+	if ctx.Err() != nil {
+		// Server is stopped, so task will be claimed again after
+		// lease is expired.
+		return true
+	}
+	if err := m.finishTask(task, err); err != nil {
+		if err == models.ErrTaskLeaseLost {
+			m.logger.Warn("Task lease lost: ", task.ID)
+		} else {
+			m.logger.Error("Unable to finish task: ", err)
+		}
+	}
+	return true
+}
+
+func (m *TaskManager) hasHandler(kind models.TaskKind) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	_, ok := m.handlers[kind]
+	return ok
+}
+
+func (m *TaskManager) runHandler(
+	ctx context.Context, handler TaskHandler, task models.Task,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panic: %v", r)
+		}
+	}()
+	return handler(ctx, task.Clone())
+}
+
+// pingTask extends lease of task until context is cancelled.
+//
+// Returns when lease is lost, so handler of task is cancelled.
+func (m *TaskManager) pingTask(ctx context.Context, task *models.Task) {
+	ticker := time.NewTicker(taskPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if time.Now().After(time.Unix(task.ExpireTime, 0)) {
+			m.logger.Error("Task lease expired: ", task.ID)
+			return
+		}
+		clone := task.Clone()
+		clone.ExpireTime = time.Now().Add(taskLease).Unix()
+		if err := m.Tasks.UpdateLeased(
+			ctx, clone, task.ExpireTime,
+		); err != nil {
+			if err == models.ErrTaskLeaseLost {
+				m.logger.Error("Task lease lost: ", task.ID)
+				return
+			}
+			m.logger.Warn("Unable to extend task lease: ", err)
+			continue
+		}
+		task.ExpireTime = clone.ExpireTime
+	}
+}
+
+// finishTask saves result of task processing.
+//
+// Failed task is queued again with delay until it reaches maximal
+// amount of attempts. For succeeded and failed tasks ExpireTime
+// contains time when task was finished. Result is not saved when
+// lease of task is lost.
+func (m *TaskManager) finishTask(task models.Task, taskErr error) error {
+	var state models.TaskState
+	if err := task.ScanState(&state); err != nil {
+		return err
+	}
+	leaseTime := task.ExpireTime
+	now := time.Now()
+	task.ExpireTime = now.Unix()
+	if taskErr == nil {
+		task.Status = models.SucceededTask
+		state.Error = ""
+	} else {
+		m.logger.Warn("Task ", task.ID, " failed: ", taskErr)
+		state.Error = taskErr.Error()
+		if state.Attempts < models.MaxTaskAttempts {
+			task.Status = models.QueuedTask
+			task.ExpireTime = now.Add(getTaskBackoff(state.Attempts)).Unix()
+		} else {
+			task.Status = models.FailedTask
+		}
+	}
+	if err := task.SetState(state); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), taskLease)
+	defer cancel()
+	return m.Tasks.UpdateLeased(ctx, task, leaseTime)
+}
+
+// cleanupTasks deletes succeeded and failed tasks that were finished
+// before retention period.
+func (m *TaskManager) cleanupTasks(
+	ctx context.Context, task models.Task,
+) error {
+	if err := m.Tasks.Sync(ctx); err != nil {
+		return err
+	}
+	tasks, err := m.Tasks.FindFinished(time.Now().Add(-taskRetention))
+	if err != nil {
+		return err
+	}
+	for _, finished := range tasks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.Tasks.Delete(ctx, finished.ID); err != nil {
+			return err
+		}
+	}
+	if len(tasks) > 0 {
+		m.logger.Info("Deleted ", len(tasks), " finished tasks")
+	}
+	return nil
+}
+
+// getTaskBackoff returns delay before retry of task after specified
+// amount of attempts.
+func getTaskBackoff(attempts int) time.Duration {
+	backoff := minTaskBackoff
+	for i := 1; i < attempts && backoff < maxTaskBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxTaskBackoff {
+		backoff = maxTaskBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,242 @@
+package managers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/udovin/goquiz/config"
+	"github.com/udovin/goquiz/core"
+	"github.com/udovin/goquiz/models"
+	"github.com/udovin/solve/db"
+
+	_ "github.com/udovin/goquiz/migrations"
+)
+
+func testSetupCore(tb testing.TB) *core.Core {
+	c, err := core.NewCore(config.Config{
+		DB: config.DB{
+			Options: config.SQLiteOptions{Path: ":memory:"},
+		},
+		Security: &config.Security{PasswordSalt: "qwerty123"},
+		LogLevel: config.LogLevel(0xff),
+	})
+	if err != nil {
+		tb.Fatal("Error:", err)
+	}
+	c.SetupAllStores()
+	if err := db.ApplyMigrations(context.Background(), c.DB); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	if err := c.Start(); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	return c
+}
+
+func TestTaskManager(t *testing.T) {
+	c := testSetupCore(t)
+	defer c.Stop()
+	manager := NewTaskManager(c)
+	ctx := context.Background()
+	if manager.Process(ctx) {
+		t.Fatal("Expected empty queue")
+	}
+	var calls []int64
+	manager.Register(1, func(ctx context.Context, task models.Task) error {
+		var config struct {
+			Fail bool `json:"fail"`
+		}
+		if err := task.ScanConfig(&config); err != nil {
+			return err
+		}
+		calls = append(calls, task.ID)
+		if config.Fail {
+			return fmt.Errorf("test error")
+		}
+		return nil
+	})
+	manager.Register(2, func(ctx context.Context, task models.Task) error {
+		panic("test panic")
+	})
+	succeeded, err := manager.Enqueue(ctx, 1, map[string]bool{"fail": false})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	failed, err := manager.Enqueue(ctx, 1, map[string]bool{"fail": true})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	panicked, err := manager.Enqueue(ctx, 2, nil)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	unknown, err := manager.Enqueue(ctx, 100, nil)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	for i := 0; i < 3; i++ {
+		if !manager.Process(ctx) {
+			t.Fatal("Expected processed task")
+		}
+	}
+	// Failed tasks are delayed and unknown tasks are not claimed.
+	if manager.Process(ctx) {
+		t.Fatal("Expected empty queue")
+	}
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(calls))
+	}
+	if err := c.Tasks.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	for _, test := range []struct {
+		ID     int64
+		Status models.TaskStatus
+		Error  string
+	}{
+		{succeeded.ID, models.SucceededTask, ""},
+		{failed.ID, models.QueuedTask, "test error"},
+		{panicked.ID, models.QueuedTask, "task panic: test panic"},
+		{unknown.ID, models.QueuedTask, ""},
+	} {
+		task, err := c.Tasks.Get(test.ID)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		if task.Status != test.Status {
+			t.Fatalf("Expected %v, got %v", test.Status, task.Status)
+		}
+		var state models.TaskState
+		if err := task.ScanState(&state); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if state.Error != test.Error {
+			t.Fatalf("Expected %q, got %q", test.Error, state.Error)
+		}
+	}
+	task, err := c.Tasks.Get(failed.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if task.ExpireTime < time.Now().Add(minTaskBackoff/2).Unix() {
+		t.Fatalf("Expected delayed task, got %v", task.ExpireTime)
+	}
+	// Task fails after maximal amount of attempts.
+	task.ExpireTime = 0
+	if err := task.SetState(models.TaskState{
+		Attempts: models.MaxTaskAttempts - 1,
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := c.Tasks.Update(ctx, task); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !manager.Process(ctx) {
+		t.Fatal("Expected processed task")
+	}
+	if err := c.Tasks.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if task, err := c.Tasks.Get(failed.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if task.Status != models.FailedTask {
+		t.Fatalf("Expected %v, got %v", models.FailedTask, task.Status)
+	}
+}
+
+func TestTaskManagerLeaseLost(t *testing.T) {
+	c := testSetupCore(t)
+	defer c.Stop()
+	manager := NewTaskManager(c)
+	ctx := context.Background()
+	// Handler simulates worker that lost lease of task, that was
+	// claimed again by another worker.
+	manager.Register(1, func(ctx context.Context, task models.Task) error {
+		task.ExpireTime += 10
+		return c.Tasks.Update(ctx, task)
+	})
+	task, err := manager.Enqueue(ctx, 1, nil)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !manager.Process(ctx) {
+		t.Fatal("Expected processed task")
+	}
+	if err := c.Tasks.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if task, err := c.Tasks.Get(task.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if task.Status != models.RunningTask {
+		t.Fatalf("Expected %v, got %v", models.RunningTask, task.Status)
+	}
+}
+
+func TestTaskManagerCleanupTasks(t *testing.T) {
+	c := testSetupCore(t)
+	defer c.Stop()
+	manager := NewTaskManager(c)
+	ctx := context.Background()
+	now := time.Now()
+	tasks := []models.Task{
+		{Status: models.SucceededTask, ExpireTime: now.Add(-2 * taskRetention).Unix()},
+		{Status: models.FailedTask, ExpireTime: now.Add(-2 * taskRetention).Unix()},
+		{Status: models.SucceededTask, ExpireTime: now.Unix()},
+		{Status: models.QueuedTask, ExpireTime: now.Add(-2 * taskRetention).Unix()},
+	}
+	for i := range tasks {
+		if err := c.Tasks.Create(ctx, &tasks[i]); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	if err := manager.cleanupTasks(ctx, models.Task{}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := c.Tasks.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	for i, deleted := range []bool{true, true, false, false} {
+		if _, err := c.Tasks.Get(tasks[i].ID); deleted != (err == sql.ErrNoRows) {
+			t.Fatalf("Unexpected result for task %d: %v", tasks[i].ID, err)
+		}
+	}
+}
+
+func TestTaskManagerRun(t *testing.T) {
+	c := testSetupCore(t)
+	defer c.Stop()
+	manager := NewTaskManager(c)
+	done := make(chan struct{})
+	manager.Register(1, func(ctx context.Context, task models.Task) error {
+		close(done)
+		return nil
+	})
+	if _, err := manager.Enqueue(context.Background(), 1, nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+	c.StartTask(manager.Run)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Task is not processed")
+	}
+}
+
+func TestGetTaskBackoff(t *testing.T) {
+	for _, test := range []struct {
+		Attempts int
+		Backoff  time.Duration
+	}{
+		{1, minTaskBackoff},
+		{2, 2 * minTaskBackoff},
+		{3, 4 * minTaskBackoff},
+		{100, maxTaskBackoff},
+	} {
+		if backoff := getTaskBackoff(test.Attempts); backoff != test.Backoff {
+			t.Fatalf("Expected %v, got %v", test.Backoff, backoff)
+		}
+	}
+}
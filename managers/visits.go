@@ -0,0 +1,172 @@
+package managers
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/udovin/goquiz/models"
+)
+
+// visitCleanupBatch contains maximal amount of visits that are
+// aggregated and deleted in single transaction.
+const visitCleanupBatch = 1000
+
+// cleanupVisits aggregates and deletes visits older than retention
+// period.
+//
+// Retention period is configured by setting "visits.retention_days".
+// Visits are kept forever when setting is missing. Old visits are
+// aggregated into hourly rollups per path, status and account, and
+// if archive directory is specified in config, they are also saved
+// to gzipped JSON Lines files in that directory before deletion.
+func (m *TaskManager) cleanupVisits(
+	ctx context.Context, task models.Task,
+) error {
+	days, err := m.getIntSetting("visits.retention_days")
+	if err != nil || days <= 0 {
+		return err
+	}
+	// Archive directory is not configured by setting, because
+	// settings should not be able to write files to arbitrary paths.
+	archiveDir := m.core.Config.VisitArchiveDir
+	// Rollups of the same hour are filled in single run.
+	before := time.Now().Add(-time.Duration(days) * 24 * time.Hour).
+		Truncate(time.Hour)
+	total := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		visits, err := m.Visits.FindBefore(ctx, before, visitCleanupBatch)
+		if err != nil {
+			return err
+		}
+		if len(visits) == 0 {
+			break
+		}
+		if archiveDir != "" {
+			if err := archiveVisits(archiveDir, visits); err != nil {
+				return err
+			}
+		}
+		if err := m.core.WrapTx(ctx, func(ctx context.Context) error {
+			for _, rollup := range rollupVisits(visits) {
+				if err := m.VisitRollups.Add(ctx, rollup); err != nil {
+					return err
+				}
+			}
+			lastID := visits[len(visits)-1].ID
+			return m.Visits.DeleteBefore(ctx, before, lastID)
+		}); err != nil {
+			return err
+		}
+		total += len(visits)
+		if len(visits) < visitCleanupBatch {
+			break
+		}
+	}
+	if total > 0 {
+		m.logger.Info("Deleted ", total, " old visits")
+	}
+	return nil
+}
+
+// rollupVisits aggregates visits into hourly rollups.
+func rollupVisits(visits []models.Visit) []models.VisitRollup {
+	type rollupKey struct {
+		Time      int64
+		AccountID models.NInt64
+		Path      string
+		Status    int
+	}
+	var rollups []models.VisitRollup
+	keys := map[rollupKey]int{}
+	for _, visit := range visits {
+		path, _, _ := strings.Cut(visit.Path, "?")
+		key := rollupKey{
+			Time:      visit.Time - visit.Time%3600,
+			AccountID: visit.AccountID,
+			Path:      path,
+			Status:    visit.Status,
+		}
+		if i, ok := keys[key]; ok {
+			rollups[i].Count++
+			continue
+		}
+		keys[key] = len(rollups)
+		rollups = append(rollups, models.VisitRollup{
+			Time:      key.Time,
+			AccountID: key.AccountID,
+			Path:      key.Path,
+			Status:    key.Status,
+			Count:     1,
+		})
+	}
+	return rollups
+}
+
+// archiveVisits saves visits to gzipped JSON Lines file.
+//
+// Name of file contains IDs of first and last visits, so repeated
+// archivation of the same visits overwrites the same file.
+func archiveVisits(dir string, visits []models.Visit) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	name := filepath.Join(dir, fmt.Sprintf(
+		"visits-%d-%d.jsonl.gz", visits[0].ID, visits[len(visits)-1].ID,
+	))
+	file, err := os.CreateTemp(dir, ".visits-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+	writer := gzip.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, visit := range visits {
+		if err := encoder.Encode(visit); err != nil {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), name)
+}
+
+func (m *TaskManager) getStringSetting(key string) (string, error) {
+	setting, err := m.Settings.GetByKey(key)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return setting.Value, nil
+}
+
+func (m *TaskManager) getIntSetting(key string) (int, error) {
+	value, err := m.getStringSetting(key)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("setting %q: %w", key, err)
+	}
+	return result, nil
+}
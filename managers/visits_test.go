@@ -0,0 +1,133 @@
+package managers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func TestTaskManagerCleanupVisits(t *testing.T) {
+	c := testSetupCore(t)
+	defer c.Stop()
+	manager := NewTaskManager(c)
+	ctx := context.Background()
+	now := time.Now()
+	hour := now.Add(-72 * time.Hour).Truncate(time.Hour)
+	visits := []models.Visit{
+		{Time: hour.Unix(), Path: "/v0/status?q=1", Status: 200},
+		{Time: hour.Unix() + 10, Path: "/v0/status", Status: 200},
+		{Time: hour.Unix() + 20, Path: "/v0/status", Status: 200, AccountID: 1},
+		{Time: hour.Unix() + 3600, Path: "/v0/status", Status: 403},
+		{Time: now.Unix(), Path: "/v0/status", Status: 200},
+	}
+	for i := range visits {
+		if err := c.Visits.Create(ctx, &visits[i]); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	// Visits are kept forever without retention setting.
+	if err := manager.cleanupVisits(ctx, models.Task{}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if old, err := c.Visits.FindBefore(ctx, now, 10); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(old) != 4 {
+		t.Fatalf("Expected 4 visits, got %d", len(old))
+	}
+	archiveDir := filepath.Join(t.TempDir(), "visits")
+	c.Config.VisitArchiveDir = archiveDir
+	if err := c.Settings.Create(ctx, &models.Setting{
+		Key: "visits.retention_days", Value: "1",
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := c.Settings.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := manager.cleanupVisits(ctx, models.Task{}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if old, err := c.Visits.FindBefore(ctx, now.Add(time.Hour), 10); err != nil {
+		t.Fatal("Error:", err)
+	} else if len(old) != 1 || old[0].ID != visits[4].ID {
+		t.Fatalf("Unexpected visits: %v", old)
+	}
+	rollups, err := c.VisitRollups.FindByTime(ctx, 0, now.Unix())
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	expected := []models.VisitRollup{
+		{Time: hour.Unix(), Path: "/v0/status", Status: 200, Count: 2},
+		{Time: hour.Unix(), Path: "/v0/status", Status: 200, AccountID: 1, Count: 1},
+		{Time: hour.Unix() + 3600, Path: "/v0/status", Status: 403, Count: 1},
+	}
+	if len(rollups) != len(expected) {
+		t.Fatalf("Expected %d rollups, got %d", len(expected), len(rollups))
+	}
+	for i := range expected {
+		expected[i].ID = rollups[i].ID
+		if rollups[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected[i], rollups[i])
+		}
+	}
+	// Rollups are merged with existing ones.
+	visit := models.Visit{Time: hour.Unix() + 30, Path: "/v0/status", Status: 200}
+	if err := c.Visits.Create(ctx, &visit); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := manager.cleanupVisits(ctx, models.Task{}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	rollups, err = c.VisitRollups.FindByTime(ctx, 0, now.Unix())
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(rollups) != 3 || rollups[0].Count != 3 {
+		t.Fatalf("Unexpected rollups: %v", rollups)
+	}
+	archived := testReadVisitArchive(t, filepath.Join(
+		archiveDir, "visits-"+strconv.FormatInt(visits[0].ID, 10)+"-"+
+			strconv.FormatInt(visits[3].ID, 10)+".jsonl.gz",
+	))
+	if len(archived) != 4 {
+		t.Fatalf("Expected 4 archived visits, got %d", len(archived))
+	}
+	for i := range archived {
+		if archived[i] != visits[i] {
+			t.Fatalf("Expected %v, got %v", visits[i], archived[i])
+		}
+	}
+}
+
+func testReadVisitArchive(tb testing.TB, name string) []models.Visit {
+	file, err := os.Open(name)
+	if err != nil {
+		tb.Fatal("Error:", err)
+	}
+	defer func() { _ = file.Close() }()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		tb.Fatal("Error:", err)
+	}
+	var visits []models.Visit
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var visit models.Visit
+		if err := json.Unmarshal(scanner.Bytes(), &visit); err != nil {
+			tb.Fatal("Error:", err)
+		}
+		visits = append(visits, visit)
+	}
+	if err := scanner.Err(); err != nil {
+		tb.Fatal("Error:", err)
+	}
+	return visits
+}
@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m002{})
+}
+
+type m002 struct{}
+
+func (m *m002) Name() string {
+	return "002_problem"
+}
+
+// Apply replaces problem tables from 001_initial.
+//
+// Tables from 001_initial contain only IDs, so there is no data
+// that should be preserved.
+func (m *m002) Apply(ctx context.Context, conn *gosql.DB) error {
+	if err := dropTables(ctx, conn, m002OldTables); err != nil {
+		return err
+	}
+	return createTables(ctx, conn, m002Tables)
+}
+
+func (m *m002) Unapply(ctx context.Context, conn *gosql.DB) error {
+	if err := dropTables(ctx, conn, m002Tables); err != nil {
+		return err
+	}
+	return createTables(ctx, conn, m002OldTables)
+}
+
+var m002OldTables = []schema.Table{
+	{
+		Name: "goquiz_problem",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+		},
+	},
+	{
+		Name: "goquiz_problem_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+		},
+	},
+}
+
+var m002Tables = []schema.Table{
+	{
+		Name: "goquiz_problem",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "kind", Type: schema.Int64},
+			{Name: "title", Type: schema.String},
+			{Name: "statement", Type: schema.String},
+			{Name: "config", Type: schema.JSON},
+			{Name: "answer", Type: schema.JSON},
+		},
+	},
+	{
+		Name: "goquiz_problem_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "kind", Type: schema.Int64},
+			{Name: "title", Type: schema.String},
+			{Name: "statement", Type: schema.String},
+			{Name: "config", Type: schema.JSON},
+			{Name: "answer", Type: schema.JSON},
+		},
+	},
+}
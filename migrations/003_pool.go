@@ -0,0 +1,104 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m003{})
+}
+
+type m003 struct{}
+
+func (m *m003) Name() string {
+	return "003_pool"
+}
+
+// Apply replaces pool tables from 001_initial and creates pool
+// problem tables.
+//
+// Pool tables from 001_initial contain only IDs, so there is no data
+// that should be preserved.
+func (m *m003) Apply(ctx context.Context, conn *gosql.DB) error {
+	if err := dropTables(ctx, conn, m003OldTables); err != nil {
+		return err
+	}
+	return createTables(ctx, conn, m003Tables)
+}
+
+func (m *m003) Unapply(ctx context.Context, conn *gosql.DB) error {
+	if err := dropTables(ctx, conn, m003Tables); err != nil {
+		return err
+	}
+	return createTables(ctx, conn, m003OldTables)
+}
+
+var m003OldTables = []schema.Table{
+	{
+		Name: "goquiz_pool",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+		},
+	},
+	{
+		Name: "goquiz_pool_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+		},
+	},
+}
+
+var m003Tables = []schema.Table{
+	{
+		Name: "goquiz_pool",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "name", Type: schema.String},
+			{Name: "description", Type: schema.String},
+		},
+	},
+	{
+		Name: "goquiz_pool_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "name", Type: schema.String},
+			{Name: "description", Type: schema.String},
+		},
+	},
+	{
+		Name: "goquiz_pool_problem",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "pool_id", Type: schema.Int64},
+			{Name: "problem_id", Type: schema.Int64},
+			{Name: "position", Type: schema.Int64},
+		},
+	},
+	{
+		Name: "goquiz_pool_problem_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "pool_id", Type: schema.Int64},
+			{Name: "problem_id", Type: schema.Int64},
+			{Name: "position", Type: schema.Int64},
+		},
+	},
+}
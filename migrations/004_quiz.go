@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m004{})
+}
+
+type m004 struct{}
+
+func (m *m004) Name() string {
+	return "004_quiz"
+}
+
+// Apply replaces quiz tables from 001_initial and creates quiz
+// section tables.
+//
+// Quiz tables from 001_initial contain only IDs, so there is no data
+// that should be preserved.
+func (m *m004) Apply(ctx context.Context, conn *gosql.DB) error {
+	if err := dropTables(ctx, conn, m004OldTables); err != nil {
+		return err
+	}
+	return createTables(ctx, conn, m004Tables)
+}
+
+func (m *m004) Unapply(ctx context.Context, conn *gosql.DB) error {
+	if err := dropTables(ctx, conn, m004Tables); err != nil {
+		return err
+	}
+	return createTables(ctx, conn, m004OldTables)
+}
+
+var m004OldTables = []schema.Table{
+	{
+		Name: "goquiz_quiz",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+		},
+	},
+	{
+		Name: "goquiz_quiz_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+		},
+	},
+}
+
+var m004Tables = []schema.Table{
+	{
+		Name: "goquiz_quiz",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "title", Type: schema.String},
+			{Name: "description", Type: schema.String},
+			{Name: "config", Type: schema.JSON},
+		},
+	},
+	{
+		Name: "goquiz_quiz_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "title", Type: schema.String},
+			{Name: "description", Type: schema.String},
+			{Name: "config", Type: schema.JSON},
+		},
+	},
+	{
+		Name: "goquiz_quiz_section",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "position", Type: schema.Int64},
+			{Name: "title", Type: schema.String},
+			{Name: "points", Type: schema.Int64},
+			{Name: "pool_id", Type: schema.Int64, Nullable: true},
+			{Name: "problem_count", Type: schema.Int64},
+			{Name: "config", Type: schema.JSON},
+		},
+	},
+	{
+		Name: "goquiz_quiz_section_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "position", Type: schema.Int64},
+			{Name: "title", Type: schema.String},
+			{Name: "points", Type: schema.Int64},
+			{Name: "pool_id", Type: schema.Int64, Nullable: true},
+			{Name: "problem_count", Type: schema.Int64},
+			{Name: "config", Type: schema.JSON},
+		},
+	},
+}
@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m005{})
+}
+
+type m005 struct{}
+
+func (m *m005) Name() string {
+	return "005_quiz_attempt"
+}
+
+func (m *m005) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m005Tables)
+}
+
+func (m *m005) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m005Tables)
+}
+
+var m005Tables = []schema.Table{
+	{
+		Name: "goquiz_quiz_attempt",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64},
+			{Name: "status", Type: schema.Int64},
+			{Name: "start_time", Type: schema.Int64},
+			{Name: "deadline", Type: schema.Int64, Nullable: true},
+			{Name: "finish_time", Type: schema.Int64, Nullable: true},
+			{Name: "state", Type: schema.JSON},
+		},
+	},
+	{
+		Name: "goquiz_quiz_attempt_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64},
+			{Name: "status", Type: schema.Int64},
+			{Name: "start_time", Type: schema.Int64},
+			{Name: "deadline", Type: schema.Int64, Nullable: true},
+			{Name: "finish_time", Type: schema.Int64, Nullable: true},
+			{Name: "state", Type: schema.JSON},
+		},
+	},
+}
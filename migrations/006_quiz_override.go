@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m006{})
+}
+
+type m006 struct{}
+
+func (m *m006) Name() string {
+	return "006_quiz_override"
+}
+
+func (m *m006) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m006Tables)
+}
+
+func (m *m006) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m006Tables)
+}
+
+var m006Tables = []schema.Table{
+	{
+		Name: "goquiz_quiz_override",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64},
+			{Name: "open_time", Type: schema.Int64, Nullable: true},
+			{Name: "close_time", Type: schema.Int64, Nullable: true},
+			{Name: "duration", Type: schema.Int64, Nullable: true},
+		},
+	},
+	{
+		Name: "goquiz_quiz_override_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64},
+			{Name: "open_time", Type: schema.Int64, Nullable: true},
+			{Name: "close_time", Type: schema.Int64, Nullable: true},
+			{Name: "duration", Type: schema.Int64, Nullable: true},
+		},
+	},
+}
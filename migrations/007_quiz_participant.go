@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m007{})
+}
+
+type m007 struct{}
+
+func (m *m007) Name() string {
+	return "007_quiz_participant"
+}
+
+func (m *m007) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m007Tables)
+}
+
+func (m *m007) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m007Tables)
+}
+
+var m007Tables = []schema.Table{
+	{
+		Name: "goquiz_quiz_participant",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64},
+			{Name: "role", Type: schema.Int64},
+		},
+	},
+	{
+		Name: "goquiz_quiz_participant_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64},
+			{Name: "role", Type: schema.Int64},
+		},
+	},
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m008{})
+}
+
+type m008 struct{}
+
+func (m *m008) Name() string {
+	return "008_file"
+}
+
+func (m *m008) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m008Tables)
+}
+
+func (m *m008) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m008Tables)
+}
+
+var m008Tables = []schema.Table{
+	{
+		Name: "goquiz_file",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "name", Type: schema.String},
+			{Name: "size", Type: schema.Int64},
+			{Name: "content_type", Type: schema.String},
+			{Name: "sha256", Type: schema.String},
+			{Name: "create_time", Type: schema.Int64},
+		},
+	},
+	{
+		Name: "goquiz_file_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "owner_id", Type: schema.Int64, Nullable: true},
+			{Name: "name", Type: schema.String},
+			{Name: "size", Type: schema.Int64},
+			{Name: "content_type", Type: schema.String},
+			{Name: "sha256", Type: schema.String},
+			{Name: "create_time", Type: schema.Int64},
+		},
+	},
+}
@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m009{})
+}
+
+type m009 struct{}
+
+func (m *m009) Name() string {
+	return "009_quiz_grade"
+}
+
+func (m *m009) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m009Tables)
+}
+
+func (m *m009) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m009Tables)
+}
+
+var m009Tables = []schema.Table{
+	{
+		Name: "goquiz_quiz_grade",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "attempt_id", Type: schema.Int64},
+			{Name: "problem_id", Type: schema.Int64},
+			{Name: "status", Type: schema.Int64},
+			{Name: "grader_id", Type: schema.Int64, Nullable: true},
+			{Name: "result", Type: schema.JSON},
+		},
+	},
+	{
+		Name: "goquiz_quiz_grade_event",
+		Columns: []schema.Column{
+			{Name: "event_id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "event_kind", Type: schema.Int64},
+			{Name: "event_time", Type: schema.Int64},
+			{Name: "event_account_id", Type: schema.Int64, Nullable: true},
+			{Name: "id", Type: schema.Int64},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "attempt_id", Type: schema.Int64},
+			{Name: "problem_id", Type: schema.Int64},
+			{Name: "status", Type: schema.Int64},
+			{Name: "grader_id", Type: schema.Int64, Nullable: true},
+			{Name: "result", Type: schema.JSON},
+		},
+	},
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m010{})
+}
+
+type m010 struct{}
+
+func (m *m010) Name() string {
+	return "010_proctoring_event"
+}
+
+func (m *m010) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m010Tables)
+}
+
+func (m *m010) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m010Tables)
+}
+
+var m010Tables = []schema.Table{
+	{
+		Name: "goquiz_proctoring_event",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "time", Type: schema.Int64},
+			{Name: "client_time", Type: schema.Int64},
+			{Name: "quiz_id", Type: schema.Int64},
+			{Name: "attempt_id", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64},
+			{Name: "kind", Type: schema.Int64},
+			{Name: "data", Type: schema.JSON},
+		},
+	},
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m011{})
+}
+
+type m011 struct{}
+
+func (m *m011) Name() string {
+	return "011_scheduled_job"
+}
+
+func (m *m011) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m011Tables)
+}
+
+func (m *m011) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m011Tables)
+}
+
+var m011Tables = []schema.Table{
+	{
+		Name: "goquiz_scheduled_job",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "name", Type: schema.String},
+			{Name: "last_time", Type: schema.Int64},
+		},
+	},
+}
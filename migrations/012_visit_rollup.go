@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+func init() {
+	db.RegisterMigration(&m012{})
+}
+
+type m012 struct{}
+
+func (m *m012) Name() string {
+	return "012_visit_rollup"
+}
+
+func (m *m012) Apply(ctx context.Context, conn *gosql.DB) error {
+	return createTables(ctx, conn, m012Tables)
+}
+
+func (m *m012) Unapply(ctx context.Context, conn *gosql.DB) error {
+	return dropTables(ctx, conn, m012Tables)
+}
+
+var m012Tables = []schema.Table{
+	{
+		Name: "goquiz_visit_rollup",
+		Columns: []schema.Column{
+			{Name: "id", Type: schema.Int64, PrimaryKey: true, AutoIncrement: true},
+			{Name: "time", Type: schema.Int64},
+			{Name: "account_id", Type: schema.Int64, Nullable: true},
+			{Name: "path", Type: schema.String},
+			{Name: "status", Type: schema.Int64},
+			{Name: "count", Type: schema.Int64},
+		},
+	},
+}
@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+	"github.com/udovin/solve/db/schema"
+)
+
+// createTables creates specified tables in order.
+func createTables(
+	ctx context.Context, conn *gosql.DB, tables []schema.Table,
+) error {
+	tx := db.GetRunner(ctx, conn)
+	for _, table := range tables {
+		query, err := table.BuildCreateSQL(conn.Dialect(), false)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropTables drops specified tables in reverse order.
+func dropTables(
+	ctx context.Context, conn *gosql.DB, tables []schema.Table,
+) error {
+	tx := db.GetRunner(ctx, conn)
+	for i := 0; i < len(tables); i++ {
+		table := tables[len(tables)-i-1]
+		query, err := table.BuildDropSQL(conn.Dialect(), false)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
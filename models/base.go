@@ -6,6 +6,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -176,13 +178,14 @@ type Store interface {
 type baseStore[
 	T any, E any, TPtr db.ObjectPtr[T], EPtr ObjectEventPtr[T, E],
 ] struct {
-	db       *gosql.DB
-	table    string
-	objects  db.ObjectStore[T, TPtr]
-	events   db.EventStore[E, EPtr]
-	consumer db.EventConsumer[E, EPtr]
-	impl     baseStoreImpl[T]
-	mutex    sync.RWMutex
+	db         *gosql.DB
+	table      string
+	eventTable string
+	objects    db.ObjectStore[T, TPtr]
+	events     db.EventStore[E, EPtr]
+	consumer   db.EventConsumer[E, EPtr]
+	impl       baseStoreImpl[T]
+	mutex      sync.RWMutex
 }
 
 // DB returns store database.
@@ -317,6 +320,74 @@ func (s *baseStore[T, E, TPtr, EPtr]) createObjectEvent(
 	return s.events.CreateEvent(ctx, eventPtr)
 }
 
+// FindObjectEvents returns all events of object with specified ID
+// ordered by event ID.
+//
+// Events are loaded from database directly, so this method should
+// not be used in frequently called code.
+func (s *baseStore[T, E, TPtr, EPtr]) FindObjectEvents(
+	ctx context.Context, id int64,
+) ([]E, error) {
+	builder := s.db.Select(s.eventTable)
+	builder.SetNames(getEventColumns[E]()...)
+	builder.SetWhere(gosql.Column("id").Equal(id))
+	builder.SetOrderBy(gosql.Ascending("event_id"))
+	query, values := builder.Build()
+	rows, err := db.GetRunner(ctx, s.db).QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var events []E
+	for rows.Next() {
+		var event E
+		if err := rows.Scan(getEventFields(&event)...); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// getEventColumns returns names of columns of event.
+func getEventColumns[E any]() []string {
+	var columns []string
+	var recursive func(reflect.Type)
+	recursive = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			if tag, ok := t.Field(i).Tag.Lookup("db"); ok {
+				columns = append(columns, strings.Split(tag, ",")[0])
+			} else if t.Field(i).Anonymous {
+				recursive(t.Field(i).Type)
+			}
+		}
+	}
+	var event E
+	recursive(reflect.TypeOf(event))
+	return columns
+}
+
+// getEventFields returns pointers to fields of event in the same order
+// as getEventColumns returns names of columns.
+func getEventFields[E any](event *E) []any {
+	var fields []any
+	var recursive func(reflect.Value)
+	recursive = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := t.Field(i).Tag.Lookup("db"); ok {
+				fields = append(fields, v.Field(i).Addr().Interface())
+			} else if t.Field(i).Anonymous {
+				recursive(v.Field(i))
+			}
+		}
+	}
+	recursive(reflect.ValueOf(event).Elem())
+	return fields
+}
+
 func (s *baseStore[T, E, TPtr, EPtr]) lockStore(tx *sql.Tx) error {
 	switch s.db.Dialect() {
 	case gosql.SQLiteDialect:
@@ -353,10 +424,11 @@ func makeBaseStore[T any, E any, TPtr db.ObjectPtr[T], EPtr ObjectEventPtr[T, E]
 	impl baseStoreImpl[T],
 ) baseStore[T, E, TPtr, EPtr] {
 	return baseStore[T, E, TPtr, EPtr]{
-		db:      conn,
-		table:   table,
-		objects: db.NewObjectStore[T, TPtr]("id", table, conn),
-		events:  db.NewEventStore[E, EPtr]("event_id", eventTable, conn),
-		impl:    impl,
+		db:         conn,
+		table:      table,
+		eventTable: eventTable,
+		objects:    db.NewObjectStore[T, TPtr]("id", table, conn),
+		events:     db.NewEventStore[E, EPtr]("event_id", eventTable, conn),
+		impl:       impl,
 	}
 }
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+)
+
+// File represents uploaded file.
+//
+// Content of file is kept in file storage under key derived from
+// SHA256, so files with the same content share stored content.
+type File struct {
+	baseObject
+	// OwnerID contains ID of account that uploaded file.
+	OwnerID NInt64 `db:"owner_id"`
+	// Name contains original name of file.
+	Name string `db:"name"`
+	// Size contains size of file in bytes.
+	Size int64 `db:"size"`
+	// ContentType contains MIME type of file.
+	ContentType string `db:"content_type"`
+	// SHA256 contains hex encoded SHA-256 hash of file content.
+	SHA256 string `db:"sha256"`
+	// CreateTime contains time of file upload.
+	CreateTime int64 `db:"create_time"`
+}
+
+// Clone creates copy of file.
+func (o File) Clone() File {
+	return o
+}
+
+// FileEvent represents a file event.
+type FileEvent struct {
+	baseEvent
+	File
+}
+
+// Object returns event file.
+func (e FileEvent) Object() File {
+	return e.File
+}
+
+// SetObject sets event file.
+func (e *FileEvent) SetObject(o File) {
+	e.File = o
+}
+
+// FileStore represents store for files.
+type FileStore struct {
+	baseStore[File, FileEvent, *File, *FileEvent]
+	files  map[int64]File
+	byHash index[string]
+}
+
+// Get returns file by ID.
+//
+// If there is no file with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *FileStore) Get(id int64) (File, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if file, ok := s.files[id]; ok {
+		return file.Clone(), nil
+	}
+	return File{}, sql.ErrNoRows
+}
+
+// FindByHash returns files with specified content hash.
+func (s *FileStore) FindByHash(hash string) ([]File, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var files []File
+	for id := range s.byHash[hash] {
+		if file, ok := s.files[id]; ok {
+			files = append(files, file.Clone())
+		}
+	}
+	return files, nil
+}
+
+// CreateWithContent creates a new file and writes its content.
+//
+// Content is written only when there are no other files with the
+// same hash. Store is locked until end of transaction, so content
+// can not be deleted by concurrent DeleteWithContent.
+func (s *FileStore) CreateWithContent(
+	ctx context.Context, file *File, write func(ctx context.Context) error,
+) error {
+	tx := db.GetTx(ctx)
+	if tx == nil {
+		return gosql.WrapTx(ctx, s.db, func(tx *sql.Tx) error {
+			return s.CreateWithContent(db.WithTx(ctx, tx), file, write)
+		}, sqlRepeatableRead)
+	}
+	if err := s.lockStore(tx); err != nil {
+		return err
+	}
+	if err := s.Sync(ctx); err != nil {
+		return err
+	}
+	sameFiles, err := s.FindByHash(file.SHA256)
+	if err != nil {
+		return err
+	}
+	if len(sameFiles) == 0 {
+		if err := write(ctx); err != nil {
+			return err
+		}
+	}
+	return s.Create(ctx, file)
+}
+
+// DeleteWithContent deletes file and its content.
+//
+// Content is deleted only when there are no other files with the
+// same hash. Store is locked until end of transaction, so content
+// can not be shared by concurrent CreateWithContent.
+func (s *FileStore) DeleteWithContent(
+	ctx context.Context, id int64, remove func(ctx context.Context) error,
+) error {
+	tx := db.GetTx(ctx)
+	if tx == nil {
+		return gosql.WrapTx(ctx, s.db, func(tx *sql.Tx) error {
+			return s.DeleteWithContent(db.WithTx(ctx, tx), id, remove)
+		}, sqlRepeatableRead)
+	}
+	if err := s.lockStore(tx); err != nil {
+		return err
+	}
+	if err := s.Sync(ctx); err != nil {
+		return err
+	}
+	file, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	sameFiles, err := s.FindByHash(file.SHA256)
+	if err != nil {
+		return err
+	}
+	if err := s.Delete(ctx, id); err != nil {
+		return err
+	}
+	if len(sameFiles) > 1 {
+		return nil
+	}
+	return remove(ctx)
+}
+
+func (s *FileStore) reset() {
+	s.files = map[int64]File{}
+	s.byHash = index[string]{}
+}
+
+func (s *FileStore) onCreateObject(file File) {
+	s.files[file.ID] = file
+	s.byHash.Create(file.SHA256, file.ID)
+}
+
+func (s *FileStore) onDeleteObject(id int64) {
+	if file, ok := s.files[id]; ok {
+		s.byHash.Delete(file.SHA256, file.ID)
+		delete(s.files, file.ID)
+	}
+}
+
+var _ baseStoreImpl[File] = (*FileStore)(nil)
+
+// NewFileStore creates a new instance of FileStore.
+func NewFileStore(
+	db *gosql.DB, table, eventTable string,
+) *FileStore {
+	impl := &FileStore{}
+	impl.baseStore = makeBaseStore[File, FileEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+type fileStoreTest struct{}
+
+func (t *fileStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "file" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"owner_id" integer NULL,` +
+			`"name" varchar(255) NOT NULL,` +
+			`"size" integer NOT NULL,` +
+			`"content_type" varchar(255) NOT NULL,` +
+			`"sha256" varchar(64) NOT NULL,` +
+			`"create_time" bigint NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "file_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"owner_id" integer NULL,` +
+			`"name" varchar(255) NOT NULL,` +
+			`"size" integer NOT NULL,` +
+			`"content_type" varchar(255) NOT NULL,` +
+			`"sha256" varchar(64) NOT NULL,` +
+			`"create_time" bigint NOT NULL)`,
+	)
+	return err
+}
+
+func (t *fileStoreTest) newStore() Store {
+	return NewFileStore(testDB, "file", "file_event")
+}
+
+func (t *fileStoreTest) newObject() Object {
+	return File{
+		Name:        "image.png",
+		Size:        3,
+		ContentType: "image/png",
+		SHA256:      "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		CreateTime:  1,
+	}
+}
+
+func (t *fileStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(File)
+	err := s.(*FileStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *fileStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*FileStore).Update(wrapContext(tx), o.(File))
+}
+
+func (t *fileStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*FileStore).Delete(wrapContext(tx), id)
+}
+
+func TestFileStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&fileStoreTest{}}
+	tester.Test(t)
+}
+
+func TestFileStoreContent(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := (&fileStoreTest{}).prepareDB(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal("Error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := NewFileStore(testDB, "file", "file_event")
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	writes, removes := 0, 0
+	write := func(context.Context) error {
+		writes++
+		return nil
+	}
+	remove := func(context.Context) error {
+		removes++
+		return nil
+	}
+	files := []File{{SHA256: "hash"}, {SHA256: "hash"}}
+	for i := range files {
+		if err := store.CreateWithContent(ctx, &files[i], write); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	if writes != 1 {
+		t.Fatalf("Expected 1 write, got %d", writes)
+	}
+	for i, expected := range []int{0, 1} {
+		if err := store.DeleteWithContent(ctx, files[i].ID, remove); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if removes != expected {
+			t.Fatalf("Expected %d removes, got %d", expected, removes)
+		}
+	}
+	if err := store.DeleteWithContent(
+		ctx, files[0].ID, remove,
+	); err != sql.ErrNoRows {
+		t.Fatalf("Expected %v, got %v", sql.ErrNoRows, err)
+	}
+}
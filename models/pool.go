@@ -1,31 +1,73 @@
 package models
 
 import (
+	"database/sql"
+
 	"github.com/udovin/gosql"
 )
 
+// Pool represents a pool of problems.
 type Pool struct {
 	baseObject
+	// OwnerID contains ID of account that owns pool.
+	OwnerID NInt64 `db:"owner_id"`
+	// Name contains pool name.
+	Name string `db:"name"`
+	// Description contains pool description.
+	Description string `db:"description"`
+}
+
+// Clone creates copy of pool.
+func (o Pool) Clone() Pool {
+	return o
 }
 
+// PoolEvent represents a pool event.
 type PoolEvent struct {
 	baseEvent
 	Pool
 }
 
+// Object returns event pool.
 func (e PoolEvent) Object() Pool {
 	return e.Pool
 }
 
+// SetObject sets event pool.
 func (e *PoolEvent) SetObject(o Pool) {
 	e.Pool = o
 }
 
+// PoolStore represents store for pools.
 type PoolStore struct {
 	baseStore[Pool, PoolEvent, *Pool, *PoolEvent]
 	pools map[int64]Pool
 }
 
+// Get returns pool by ID.
+//
+// If there is no pool with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *PoolStore) Get(id int64) (Pool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if pool, ok := s.pools[id]; ok {
+		return pool.Clone(), nil
+	}
+	return Pool{}, sql.ErrNoRows
+}
+
+// All returns all pools.
+func (s *PoolStore) All() ([]Pool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var pools []Pool
+	for _, pool := range s.pools {
+		pools = append(pools, pool.Clone())
+	}
+	return pools, nil
+}
+
 func (s *PoolStore) reset() {
 	s.pools = map[int64]Pool{}
 }
@@ -40,6 +82,8 @@ func (s *PoolStore) onDeleteObject(id int64) {
 	}
 }
 
+var _ baseStoreImpl[Pool] = (*PoolStore)(nil)
+
 // NewPoolStore creates a new instance of PoolStore.
 func NewPoolStore(
 	db *gosql.DB, table, eventTable string,
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/udovin/gosql"
+)
+
+// PoolProblem represents membership of problem in pool.
+type PoolProblem struct {
+	baseObject
+	// PoolID contains ID of pool.
+	PoolID int64 `db:"pool_id"`
+	// ProblemID contains ID of problem.
+	ProblemID int64 `db:"problem_id"`
+	// Position contains position of problem in pool.
+	Position int64 `db:"position"`
+}
+
+// Clone creates copy of pool problem.
+func (o PoolProblem) Clone() PoolProblem {
+	return o
+}
+
+// PoolProblemEvent represents pool problem event.
+type PoolProblemEvent struct {
+	baseEvent
+	PoolProblem
+}
+
+// Object returns event pool problem.
+func (e PoolProblemEvent) Object() PoolProblem {
+	return e.PoolProblem
+}
+
+// SetObject sets event pool problem.
+func (e *PoolProblemEvent) SetObject(o PoolProblem) {
+	e.PoolProblem = o
+}
+
+// PoolProblemStore represents store for pool problems.
+type PoolProblemStore struct {
+	baseStore[PoolProblem, PoolProblemEvent, *PoolProblem, *PoolProblemEvent]
+	problems  map[int64]PoolProblem
+	byPool    index[int64]
+	byProblem index[int64]
+}
+
+// Get returns pool problem by ID.
+//
+// If there is no pool problem with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *PoolProblemStore) Get(id int64) (PoolProblem, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if problem, ok := s.problems[id]; ok {
+		return problem.Clone(), nil
+	}
+	return PoolProblem{}, sql.ErrNoRows
+}
+
+// FindByPool returns problems of pool ordered by position.
+func (s *PoolProblemStore) FindByPool(poolID int64) ([]PoolProblem, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var problems []PoolProblem
+	for id := range s.byPool[poolID] {
+		if problem, ok := s.problems[id]; ok {
+			problems = append(problems, problem.Clone())
+		}
+	}
+	sortPoolProblems(problems)
+	return problems, nil
+}
+
+// FindByProblem returns pool memberships of problem.
+func (s *PoolProblemStore) FindByProblem(problemID int64) ([]PoolProblem, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var problems []PoolProblem
+	for id := range s.byProblem[problemID] {
+		if problem, ok := s.problems[id]; ok {
+			problems = append(problems, problem.Clone())
+		}
+	}
+	return problems, nil
+}
+
+// Sample draws count distinct problems from pool using seed.
+func (s *PoolProblemStore) Sample(
+	poolID int64, count int, seed int64,
+) ([]PoolProblem, error) {
+	problems, err := s.FindByPool(poolID)
+	if err != nil {
+		return nil, err
+	}
+	return SamplePoolProblems(problems, count, seed)
+}
+
+// SamplePoolProblems draws count distinct problems using seed.
+//
+// Result depends only on set of problems, count and seed, so the same
+// seed always produces the same sample for unchanged pool.
+func SamplePoolProblems(
+	problems []PoolProblem, count int, seed int64,
+) ([]PoolProblem, error) {
+	if count < 0 || count > len(problems) {
+		return nil, fmt.Errorf(
+			"unable to draw %d problems from %d", count, len(problems),
+		)
+	}
+	sample := make([]PoolProblem, len(problems))
+	copy(sample, problems)
+	sortPoolProblems(sample)
+	random := rand.New(rand.NewSource(seed))
+	for i := 0; i < count; i++ {
+		j := i + random.Intn(len(sample)-i)
+		sample[i], sample[j] = sample[j], sample[i]
+	}
+	return sample[:count], nil
+}
+
+func sortPoolProblems(problems []PoolProblem) {
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Position != problems[j].Position {
+			return problems[i].Position < problems[j].Position
+		}
+		return problems[i].ID < problems[j].ID
+	})
+}
+
+func (s *PoolProblemStore) reset() {
+	s.problems = map[int64]PoolProblem{}
+	s.byPool = index[int64]{}
+	s.byProblem = index[int64]{}
+}
+
+func (s *PoolProblemStore) onCreateObject(problem PoolProblem) {
+	s.problems[problem.ID] = problem
+	s.byPool.Create(problem.PoolID, problem.ID)
+	s.byProblem.Create(problem.ProblemID, problem.ID)
+}
+
+func (s *PoolProblemStore) onDeleteObject(id int64) {
+	if problem, ok := s.problems[id]; ok {
+		s.byPool.Delete(problem.PoolID, problem.ID)
+		s.byProblem.Delete(problem.ProblemID, problem.ID)
+		delete(s.problems, problem.ID)
+	}
+}
+
+var _ baseStoreImpl[PoolProblem] = (*PoolProblemStore)(nil)
+
+// NewPoolProblemStore creates a new instance of PoolProblemStore.
+func NewPoolProblemStore(
+	db *gosql.DB, table, eventTable string,
+) *PoolProblemStore {
+	impl := &PoolProblemStore{}
+	impl.baseStore = makeBaseStore[PoolProblem, PoolProblemEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
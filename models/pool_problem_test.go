@@ -0,0 +1,118 @@
+package models
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type poolProblemStoreTest struct{}
+
+func (t *poolProblemStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "pool_problem" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"pool_id" integer NOT NULL,` +
+			`"problem_id" integer NOT NULL,` +
+			`"position" integer NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "pool_problem_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"pool_id" integer NOT NULL,` +
+			`"problem_id" integer NOT NULL,` +
+			`"position" integer NOT NULL)`,
+	)
+	return err
+}
+
+func (t *poolProblemStoreTest) newStore() Store {
+	return NewPoolProblemStore(testDB, "pool_problem", "pool_problem_event")
+}
+
+func (t *poolProblemStoreTest) newObject() Object {
+	return PoolProblem{PoolID: 1, ProblemID: 2, Position: 1}
+}
+
+func (t *poolProblemStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(PoolProblem)
+	err := s.(*PoolProblemStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *poolProblemStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*PoolProblemStore).Update(wrapContext(tx), o.(PoolProblem))
+}
+
+func (t *poolProblemStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*PoolProblemStore).Delete(wrapContext(tx), id)
+}
+
+func TestPoolProblemStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&poolProblemStoreTest{}}
+	tester.Test(t)
+}
+
+func TestSamplePoolProblems(t *testing.T) {
+	var problems []PoolProblem
+	for i := 1; i <= 10; i++ {
+		problems = append(problems, PoolProblem{
+			baseObject: baseObject{ID: int64(i)},
+			ProblemID:  int64(100 + i),
+			Position:   int64(11 - i),
+		})
+	}
+	sample, err := SamplePoolProblems(problems, 4, 42)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(sample) != 4 {
+		t.Fatalf("Expected %d problems, got %d", 4, len(sample))
+	}
+	seen := map[int64]struct{}{}
+	for _, problem := range sample {
+		if _, ok := seen[problem.ProblemID]; ok {
+			t.Fatalf("Problem %d drawn twice", problem.ProblemID)
+		}
+		seen[problem.ProblemID] = struct{}{}
+	}
+	// Order of input should not affect sample.
+	reversed := make([]PoolProblem, len(problems))
+	for i, problem := range problems {
+		reversed[len(problems)-i-1] = problem
+	}
+	other, err := SamplePoolProblems(reversed, 4, 42)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !reflect.DeepEqual(sample, other) {
+		t.Fatalf("Expected %v, got %v", sample, other)
+	}
+	all, err := SamplePoolProblems(problems, len(problems), 7)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(all) != len(problems) {
+		t.Fatalf("Expected %d problems, got %d", len(problems), len(all))
+	}
+	if _, err := SamplePoolProblems(problems, 11, 42); err == nil {
+		t.Fatal("Expected error")
+	}
+	if _, err := SamplePoolProblems(problems, -1, 42); err == nil {
+		t.Fatal("Expected error")
+	}
+}
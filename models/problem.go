@@ -1,45 +1,439 @@
 package models
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
 	"github.com/udovin/gosql"
 )
 
+// ProblemKind represents kind of problem.
+type ProblemKind int
+
+const (
+	// SingleChoiceProblem represents problem with exactly one
+	// correct option.
+	SingleChoiceProblem ProblemKind = 1
+	// MultipleChoiceProblem represents problem with several
+	// correct options.
+	MultipleChoiceProblem ProblemKind = 2
+	// TextProblem represents problem with free text answer.
+	TextProblem ProblemKind = 3
+	// NumericProblem represents problem with numeric answer.
+	NumericProblem ProblemKind = 4
+	// MatchingProblem represents problem where every prompt should
+	// be matched with one of options.
+	MatchingProblem ProblemKind = 5
+	// EssayProblem represents problem with free text answer that
+	// should be graded manually.
+	EssayProblem ProblemKind = 6
+)
+
+// String returns string representation.
+func (k ProblemKind) String() string {
+	switch k {
+	case SingleChoiceProblem:
+		return "single_choice"
+	case MultipleChoiceProblem:
+		return "multiple_choice"
+	case TextProblem:
+		return "text"
+	case NumericProblem:
+		return "numeric"
+	case MatchingProblem:
+		return "matching"
+	case EssayProblem:
+		return "essay"
+	default:
+		return fmt.Sprintf("ProblemKind(%d)", k)
+	}
+}
+
+// MarshalText marshals kind to text.
+func (k ProblemKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText unmarshals kind from text.
+func (k *ProblemKind) UnmarshalText(data []byte) error {
+	switch s := string(data); s {
+	case "single_choice":
+		*k = SingleChoiceProblem
+	case "multiple_choice":
+		*k = MultipleChoiceProblem
+	case "text":
+		*k = TextProblem
+	case "numeric":
+		*k = NumericProblem
+	case "matching":
+		*k = MatchingProblem
+	case "essay":
+		*k = EssayProblem
+	default:
+		return fmt.Errorf("unsupported kind: %q", s)
+	}
+	return nil
+}
+
+// HasOptions returns flag that problem of this kind has options.
+func (k ProblemKind) HasOptions() bool {
+	return k == SingleChoiceProblem || k == MultipleChoiceProblem ||
+		k == MatchingProblem
+}
+
+// IsManual returns flag that answers for problem of this kind
+// should be graded manually.
+func (k ProblemKind) IsManual() bool {
+	return k == EssayProblem
+}
+
+// ProblemOption represents option of choice problem.
+type ProblemOption struct {
+	// Text contains option text.
+	Text string `json:"text"`
+}
+
+// ProblemVariable represents variable of parameterized problem.
+//
+// Variable takes one of values Min, Min+Step, ..., not greater than Max.
+type ProblemVariable struct {
+	// Name contains name of variable.
+	Name string `json:"name"`
+	// Min contains minimal value of variable.
+	Min float64 `json:"min"`
+	// Max contains maximal value of variable.
+	Max float64 `json:"max"`
+	// Step contains difference between adjacent values.
+	//
+	// Zero step means that variable takes integer values.
+	Step float64 `json:"step,omitempty"`
+}
+
+// GetStep returns difference between adjacent values.
+func (v ProblemVariable) GetStep() float64 {
+	if v.Step <= 0 {
+		return 1
+	}
+	return v.Step
+}
+
+// Count returns amount of values of variable.
+func (v ProblemVariable) Count() int64 {
+	if v.Max < v.Min {
+		return 0
+	}
+	// Small epsilon protects from losing the last value because of
+	// rounding errors, for example for range [0, 0.3] with step 0.1.
+	return int64(math.Floor((v.Max-v.Min)/v.GetStep()+1e-9)) + 1
+}
+
+// Value returns i-th value of variable.
+//
+// Value is rounded to precision of Min and Step, so values
+// do not contain artifacts of floating point arithmetic.
+func (v ProblemVariable) Value(i int64) float64 {
+	value := v.Min + float64(i)*v.GetStep()
+	digits := getDecimalDigits(v.Min)
+	if stepDigits := getDecimalDigits(v.GetStep()); stepDigits > digits {
+		digits = stepDigits
+	}
+	rounded, err := strconv.ParseFloat(
+		strconv.FormatFloat(value, 'f', digits, 64), 64,
+	)
+	if err != nil {
+		return value
+	}
+	return rounded
+}
+
+func getDecimalDigits(value float64) int {
+	text := strconv.FormatFloat(value, 'f', -1, 64)
+	if pos := strings.IndexByte(text, '.'); pos >= 0 {
+		return len(text) - pos - 1
+	}
+	return 0
+}
+
+// SampleProblemValues draws values of variables using seed.
+//
+// Result depends only on variables and seed.
+func SampleProblemValues(
+	variables []ProblemVariable, seed int64,
+) map[string]float64 {
+	if len(variables) == 0 {
+		return nil
+	}
+	random := mathrand.New(mathrand.NewSource(seed))
+	values := map[string]float64{}
+	for _, variable := range variables {
+		count := variable.Count()
+		if count <= 0 {
+			continue
+		}
+		values[variable.Name] = variable.Value(random.Int63n(count))
+	}
+	return values
+}
+
+var problemPlaceholderRegexp = regexp.MustCompile(
+	`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`,
+)
+
+// GetProblemPlaceholders returns names of variables used in text
+// as {{name}} placeholders.
+func GetProblemPlaceholders(text string) []string {
+	var names []string
+	for _, match := range problemPlaceholderRegexp.FindAllStringSubmatch(
+		text, -1,
+	) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// FormatProblemText replaces {{name}} placeholders in text with
+// values of variables.
+//
+// Placeholders of unknown variables are left as is.
+func FormatProblemText(text string, values map[string]float64) string {
+	if len(values) == 0 {
+		return text
+	}
+	return problemPlaceholderRegexp.ReplaceAllStringFunc(
+		text, func(placeholder string) string {
+			name := problemPlaceholderRegexp.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				return placeholder
+			}
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		},
+	)
+}
+
+// ProblemConfig represents problem config.
+type ProblemConfig struct {
+	// Options contains options for choice and matching problems.
+	Options []ProblemOption `json:"options,omitempty"`
+	// Prompts contains prompts for matching problems.
+	Prompts []ProblemOption `json:"prompts,omitempty"`
+	// Variables contains variables of parameterized problem.
+	//
+	// Values of variables are drawn for every attempt and substituted
+	// into {{name}} placeholders of statement, options and prompts.
+	Variables []ProblemVariable `json:"variables,omitempty"`
+}
+
+// ProblemAnswer represents answer key of problem.
+type ProblemAnswer struct {
+	// Options contains indexes of correct options for choice problems.
+	//
+	// For matching problems i-th element contains index of option
+	// that is matched with i-th prompt.
+	Options []int `json:"options,omitempty"`
+	// Texts contains accepted answers for text problems.
+	Texts []string `json:"texts,omitempty"`
+	// Value contains correct answer for numeric problems.
+	Value float64 `json:"value,omitempty"`
+	// Tolerance contains allowed absolute error for numeric problems.
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// Formula contains expression over problem variables that is
+	// used instead of Value for parameterized numeric problems.
+	Formula string `json:"formula,omitempty"`
+}
+
+// Problem represents a problem.
 type Problem struct {
 	baseObject
+	// OwnerID contains ID of account that owns problem.
+	OwnerID NInt64 `db:"owner_id"`
+	// Kind contains kind of problem.
+	Kind ProblemKind `db:"kind"`
+	// Title contains problem title.
+	Title string `db:"title"`
+	// Statement contains problem statement.
+	Statement string `db:"statement"`
+	// Config contains problem config.
+	Config JSON `db:"config"`
+	// Answer contains answer key of problem.
+	Answer JSON `db:"answer"`
+}
+
+// Clone creates copy of problem.
+func (o Problem) Clone() Problem {
+	o.Config = o.Config.Clone()
+	o.Answer = o.Answer.Clone()
+	return o
+}
+
+// ScanConfig scans problem config.
+func (o Problem) ScanConfig(config *ProblemConfig) error {
+	if len(o.Config) == 0 {
+		*config = ProblemConfig{}
+		return nil
+	}
+	return json.Unmarshal(o.Config, config)
+}
+
+// SetConfig updates problem config.
+func (o *Problem) SetConfig(config ProblemConfig) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	o.Config = raw
+	return nil
+}
+
+// ScanAnswer scans problem answer key.
+func (o Problem) ScanAnswer(answer *ProblemAnswer) error {
+	if len(o.Answer) == 0 {
+		*answer = ProblemAnswer{}
+		return nil
+	}
+	return json.Unmarshal(o.Answer, answer)
+}
+
+// SetAnswer updates problem answer key.
+func (o *Problem) SetAnswer(answer ProblemAnswer) error {
+	raw, err := json.Marshal(answer)
+	if err != nil {
+		return err
+	}
+	o.Answer = raw
+	return nil
 }
 
+// ProblemEvent represents a problem event.
 type ProblemEvent struct {
 	baseEvent
 	Problem
 }
 
+// Object returns event problem.
 func (e ProblemEvent) Object() Problem {
 	return e.Problem
 }
 
+// SetObject sets event problem.
 func (e *ProblemEvent) SetObject(o Problem) {
 	e.Problem = o
 }
 
+// ProblemStore represents store for problems.
 type ProblemStore struct {
 	baseStore[Problem, ProblemEvent, *Problem, *ProblemEvent]
 	problems map[int64]Problem
+	byOwner  index[int64]
+}
+
+// Get returns problem by ID.
+//
+// If there is no problem with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *ProblemStore) Get(id int64) (Problem, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if problem, ok := s.problems[id]; ok {
+		return problem.Clone(), nil
+	}
+	return Problem{}, sql.ErrNoRows
+}
+
+// All returns all problems.
+func (s *ProblemStore) All() ([]Problem, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var problems []Problem
+	for _, problem := range s.problems {
+		problems = append(problems, problem.Clone())
+	}
+	return problems, nil
+}
+
+// GetRevision returns problem as of event with specified ID.
+//
+// Latest event of problem that is not after specified event is used.
+// If problem did not exist at that moment then sql.ErrNoRows
+// will be returned.
+func (s *ProblemStore) GetRevision(
+	ctx context.Context, id, eventID int64,
+) (Problem, error) {
+	events, err := s.FindObjectEvents(ctx, id)
+	if err != nil {
+		return Problem{}, err
+	}
+	var problem *Problem
+	for _, event := range events {
+		if event.EventID() > eventID {
+			break
+		}
+		if event.EventKind() == DeleteEvent {
+			problem = nil
+		} else {
+			object := event.Object()
+			problem = &object
+		}
+	}
+	if problem == nil {
+		return Problem{}, sql.ErrNoRows
+	}
+	return *problem, nil
+}
+
+// LastRevision returns ID of last event of problem.
+//
+// If there are no events of problem then sql.ErrNoRows will be returned.
+func (s *ProblemStore) LastRevision(ctx context.Context, id int64) (int64, error) {
+	events, err := s.FindObjectEvents(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return events[len(events)-1].EventID(), nil
+}
+
+// FindByOwner returns problems by owner account ID.
+func (s *ProblemStore) FindByOwner(id int64) ([]Problem, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var problems []Problem
+	for id := range s.byOwner[id] {
+		if problem, ok := s.problems[id]; ok {
+			problems = append(problems, problem.Clone())
+		}
+	}
+	return problems, nil
 }
 
 func (s *ProblemStore) reset() {
 	s.problems = map[int64]Problem{}
+	s.byOwner = index[int64]{}
 }
 
 func (s *ProblemStore) onCreateObject(problem Problem) {
 	s.problems[problem.ID] = problem
+	s.byOwner.Create(int64(problem.OwnerID), problem.ID)
 }
 
 func (s *ProblemStore) onDeleteObject(id int64) {
 	if problem, ok := s.problems[id]; ok {
+		s.byOwner.Delete(int64(problem.OwnerID), problem.ID)
 		delete(s.problems, problem.ID)
 	}
 }
 
+var _ baseStoreImpl[Problem] = (*ProblemStore)(nil)
+
 // NewProblemStore creates a new instance of ProblemStore.
 func NewProblemStore(
 	db *gosql.DB, table, eventTable string,
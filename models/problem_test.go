@@ -0,0 +1,237 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type problemStoreTest struct{}
+
+func (t *problemStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "problem" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"owner_id" integer NULL,` +
+			`"kind" integer NOT NULL,` +
+			`"title" varchar(255) NOT NULL,` +
+			`"statement" text NOT NULL,` +
+			`"config" blob NOT NULL,` +
+			`"answer" blob NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "problem_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"owner_id" integer NULL,` +
+			`"kind" integer NOT NULL,` +
+			`"title" varchar(255) NOT NULL,` +
+			`"statement" text NOT NULL,` +
+			`"config" blob NOT NULL,` +
+			`"answer" blob NOT NULL)`,
+	)
+	return err
+}
+
+func (t *problemStoreTest) newStore() Store {
+	return NewProblemStore(testDB, "problem", "problem_event")
+}
+
+func (t *problemStoreTest) newObject() Object {
+	return Problem{
+		Kind:      SingleChoiceProblem,
+		Title:     "Test",
+		Statement: "Test statement",
+		Config:    JSON(`{"options":[{"text":"A"},{"text":"B"}]}`),
+		Answer:    JSON(`{"options":[1]}`),
+	}
+}
+
+func (t *problemStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(Problem)
+	err := s.(*ProblemStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *problemStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*ProblemStore).Update(wrapContext(tx), o.(Problem))
+}
+
+func (t *problemStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*ProblemStore).Delete(wrapContext(tx), id)
+}
+
+func TestProblemStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&problemStoreTest{}}
+	tester.Test(t)
+}
+
+func TestProblemKind(t *testing.T) {
+	for _, kind := range []ProblemKind{
+		SingleChoiceProblem, MultipleChoiceProblem,
+		TextProblem, NumericProblem,
+	} {
+		text, err := kind.MarshalText()
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		var parsed ProblemKind
+		if err := parsed.UnmarshalText(text); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if parsed != kind {
+			t.Fatalf("Expected %v, got %v", kind, parsed)
+		}
+	}
+	var kind ProblemKind
+	if err := kind.UnmarshalText([]byte("unknown")); err == nil {
+		t.Fatal("Expected error")
+	}
+	if s := ProblemKind(-1).String(); s != "ProblemKind(-1)" {
+		t.Fatalf("Expected %q, got %q", "ProblemKind(-1)", s)
+	}
+}
+
+func TestProblem_Answer(t *testing.T) {
+	var problem Problem
+	var answer ProblemAnswer
+	if err := problem.ScanAnswer(&answer); err != nil {
+		t.Fatal("Error:", err)
+	}
+	expected := ProblemAnswer{Options: []int{0, 2}}
+	if err := problem.SetAnswer(expected); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := problem.Clone().ScanAnswer(&answer); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !reflect.DeepEqual(answer, expected) {
+		t.Fatalf("Expected %v, got %v", expected, answer)
+	}
+}
+
+func TestProblemStore_Revisions(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := problemStoreTest{}
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := tester.prepareDB(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal("Error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := tester.newStore().(*ProblemStore)
+	ctx := context.Background()
+	problem := tester.newObject().(Problem)
+	if err := store.Create(ctx, &problem); err != nil {
+		t.Fatal("Error:", err)
+	}
+	first, err := store.LastRevision(ctx, problem.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	problem.Title = "Updated"
+	if err := store.Update(ctx, problem); err != nil {
+		t.Fatal("Error:", err)
+	}
+	second, err := store.LastRevision(ctx, problem.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if second <= first {
+		t.Fatalf("Expected revision greater than %d, got %d", first, second)
+	}
+	if revision, err := store.GetRevision(ctx, problem.ID, first); err != nil {
+		t.Fatal("Error:", err)
+	} else if revision.Title != "Test" {
+		t.Fatalf("Expected title %q, got %q", "Test", revision.Title)
+	}
+	if revision, err := store.GetRevision(ctx, problem.ID, second); err != nil {
+		t.Fatal("Error:", err)
+	} else if revision.Title != "Updated" {
+		t.Fatalf("Expected title %q, got %q", "Updated", revision.Title)
+	}
+	if err := store.Delete(ctx, problem.ID); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := store.GetRevision(ctx, problem.ID, second+1); err != sql.ErrNoRows {
+		t.Fatalf("Expected %v, got %v", sql.ErrNoRows, err)
+	}
+	if _, err := store.GetRevision(ctx, problem.ID, first-1); err != sql.ErrNoRows {
+		t.Fatalf("Expected %v, got %v", sql.ErrNoRows, err)
+	}
+	events, err := store.FindObjectEvents(ctx, problem.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected %d events, got %d", 3, len(events))
+	}
+}
+
+func TestProblemVariable(t *testing.T) {
+	variable := ProblemVariable{Name: "x", Min: 0, Max: 0.3, Step: 0.1}
+	if count := variable.Count(); count != 4 {
+		t.Fatalf("Expected %d values, got %d", 4, count)
+	}
+	for i, expected := range []float64{0, 0.1, 0.2, 0.3} {
+		if value := variable.Value(int64(i)); value != expected {
+			t.Fatalf("Expected %v, got %v", expected, value)
+		}
+	}
+	integer := ProblemVariable{Name: "n", Min: -2, Max: 2}
+	if count := integer.Count(); count != 5 {
+		t.Fatalf("Expected %d values, got %d", 5, count)
+	}
+	if count := (ProblemVariable{Min: 1, Max: 0}).Count(); count != 0 {
+		t.Fatalf("Expected %d values, got %d", 0, count)
+	}
+	variables := []ProblemVariable{variable, integer}
+	values := SampleProblemValues(variables, 42)
+	if len(values) != 2 {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+	if !reflect.DeepEqual(values, SampleProblemValues(variables, 42)) {
+		t.Fatal("Expected the same values for the same seed")
+	}
+	if values := SampleProblemValues(nil, 42); values != nil {
+		t.Fatalf("Unexpected values: %v", values)
+	}
+}
+
+func TestFormatProblemText(t *testing.T) {
+	text := "Find {{a}} + {{ b }} and {{c}}"
+	if names := GetProblemPlaceholders(text); !reflect.DeepEqual(
+		names, []string{"a", "b", "c"},
+	) {
+		t.Fatalf("Unexpected placeholders: %v", names)
+	}
+	values := map[string]float64{"a": 1.5, "b": -2}
+	if formatted := FormatProblemText(
+		text, values,
+	); formatted != "Find 1.5 + -2 and {{c}}" {
+		t.Fatalf("Unexpected text: %q", formatted)
+	}
+	if formatted := FormatProblemText(text, nil); formatted != text {
+		t.Fatalf("Unexpected text: %q", formatted)
+	}
+}
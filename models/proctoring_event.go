@@ -0,0 +1,179 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+)
+
+// ProctoringEventKind represents kind of integrity event reported by
+// client during attempt.
+type ProctoringEventKind int
+
+const (
+	// TabHiddenEvent represents event when tab with quiz became hidden.
+	TabHiddenEvent ProctoringEventKind = 1
+	// WindowBlurEvent represents event when window lost focus.
+	WindowBlurEvent ProctoringEventKind = 2
+	// CopyEvent represents copying of text from quiz.
+	CopyEvent ProctoringEventKind = 3
+	// PasteEvent represents pasting of text to quiz.
+	PasteEvent ProctoringEventKind = 4
+	// ReconnectEvent represents reconnect of client to network.
+	ReconnectEvent ProctoringEventKind = 5
+	// FullscreenExitEvent represents exit from fullscreen mode.
+	FullscreenExitEvent ProctoringEventKind = 6
+)
+
+// String returns string representation.
+func (k ProctoringEventKind) String() string {
+	switch k {
+	case TabHiddenEvent:
+		return "tab_hidden"
+	case WindowBlurEvent:
+		return "window_blur"
+	case CopyEvent:
+		return "copy"
+	case PasteEvent:
+		return "paste"
+	case ReconnectEvent:
+		return "reconnect"
+	case FullscreenExitEvent:
+		return "fullscreen_exit"
+	default:
+		return fmt.Sprintf("ProctoringEventKind(%d)", k)
+	}
+}
+
+// MarshalText marshals kind to text.
+func (k ProctoringEventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText unmarshals kind from text.
+func (k *ProctoringEventKind) UnmarshalText(data []byte) error {
+	switch s := string(data); s {
+	case "tab_hidden":
+		*k = TabHiddenEvent
+	case "window_blur":
+		*k = WindowBlurEvent
+	case "copy":
+		*k = CopyEvent
+	case "paste":
+		*k = PasteEvent
+	case "reconnect":
+		*k = ReconnectEvent
+	case "fullscreen_exit":
+		*k = FullscreenExitEvent
+	default:
+		return fmt.Errorf("unsupported kind: %q", s)
+	}
+	return nil
+}
+
+// ProctoringEvent represents integrity event of quiz attempt.
+type ProctoringEvent struct {
+	ID int64 `db:"id"`
+	// Time contains time when event was received by server.
+	Time int64 `db:"time"`
+	// ClientTime contains time when event occurred on client.
+	ClientTime int64 `db:"client_time"`
+	// QuizID contains ID of quiz.
+	QuizID int64 `db:"quiz_id"`
+	// AttemptID contains ID of quiz attempt.
+	AttemptID int64 `db:"attempt_id"`
+	// AccountID contains ID of account that reported event.
+	AccountID int64 `db:"account_id"`
+	// Kind contains kind of event.
+	Kind ProctoringEventKind `db:"kind"`
+	// Data contains additional details of event.
+	Data JSON `db:"data"`
+}
+
+// EventID returns ID of proctoring event.
+func (o ProctoringEvent) EventID() int64 {
+	return o.ID
+}
+
+// SetEventID sets ID of proctoring event.
+func (o *ProctoringEvent) SetEventID(id int64) {
+	o.ID = id
+}
+
+// EventTime return time of proctoring event.
+func (o ProctoringEvent) EventTime() time.Time {
+	return time.Unix(o.Time, 0)
+}
+
+// ProctoringEventStore represents append-only store for proctoring
+// events.
+//
+// Events are not cached in memory and are loaded from database on
+// every request.
+type ProctoringEventStore struct {
+	db     *gosql.DB
+	table  string
+	events db.EventStore[ProctoringEvent, *ProctoringEvent]
+}
+
+// Create creates a new proctoring event in the events.
+func (s *ProctoringEventStore) Create(
+	ctx context.Context, event *ProctoringEvent,
+) error {
+	return s.events.CreateEvent(ctx, event)
+}
+
+// FindByAttempt returns proctoring events of attempt ordered by ID.
+func (s *ProctoringEventStore) FindByAttempt(
+	ctx context.Context, attemptID int64,
+) ([]ProctoringEvent, error) {
+	return s.find(ctx, gosql.Column("attempt_id").Equal(attemptID))
+}
+
+// FindByQuiz returns proctoring events of quiz ordered by ID.
+func (s *ProctoringEventStore) FindByQuiz(
+	ctx context.Context, quizID int64,
+) ([]ProctoringEvent, error) {
+	return s.find(ctx, gosql.Column("quiz_id").Equal(quizID))
+}
+
+func (s *ProctoringEventStore) find(
+	ctx context.Context, where gosql.BoolExpression,
+) ([]ProctoringEvent, error) {
+	builder := s.db.Select(s.table)
+	builder.SetNames(getEventColumns[ProctoringEvent]()...)
+	builder.SetWhere(where)
+	builder.SetOrderBy(gosql.Ascending("id"))
+	query, values := builder.Build()
+	rows, err := db.GetRunner(ctx, s.db).QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var events []ProctoringEvent
+	for rows.Next() {
+		var event ProctoringEvent
+		if err := rows.Scan(getEventFields(&event)...); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// NewProctoringEventStore creates a new instance of
+// ProctoringEventStore.
+func NewProctoringEventStore(
+	dbConn *gosql.DB, table string,
+) *ProctoringEventStore {
+	return &ProctoringEventStore{
+		db:     dbConn,
+		table:  table,
+		events: db.NewEventStore[ProctoringEvent]("id", table, dbConn),
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProctoringEventStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	if _, err := testDB.Exec(
+		`CREATE TABLE "proctoring_event" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"time" bigint NOT NULL,` +
+			`"client_time" bigint NOT NULL,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"attempt_id" integer NOT NULL,` +
+			`"account_id" integer NOT NULL,` +
+			`"kind" integer NOT NULL,` +
+			`"data" blob NOT NULL)`,
+	); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := NewProctoringEventStore(testDB, "proctoring_event")
+	ctx := context.Background()
+	events := []ProctoringEvent{
+		{Time: 1, QuizID: 1, AttemptID: 1, Kind: TabHiddenEvent},
+		{Time: 2, QuizID: 1, AttemptID: 2, Kind: PasteEvent, Data: JSON(`{"length":10}`)},
+		{Time: 3, QuizID: 1, AttemptID: 1, Kind: ReconnectEvent},
+	}
+	for i := range events {
+		if err := store.Create(ctx, &events[i]); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if events[i].ID == 0 {
+			t.Fatal("Expected non-zero ID")
+		}
+	}
+	found, err := store.FindByAttempt(ctx, 1)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(found) != 2 || found[0].ID != events[0].ID ||
+		found[1].Kind != ReconnectEvent {
+		t.Fatalf("Unexpected events: %v", found)
+	}
+	found, err = store.FindByQuiz(ctx, 1)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(found) != 3 || string(found[1].Data) != `{"length":10}` {
+		t.Fatalf("Unexpected events: %v", found)
+	}
+}
+
+func TestProctoringEventKind(t *testing.T) {
+	for _, kind := range []ProctoringEventKind{
+		TabHiddenEvent, WindowBlurEvent, CopyEvent, PasteEvent,
+		ReconnectEvent, FullscreenExitEvent,
+	} {
+		data, err := kind.MarshalText()
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		var value ProctoringEventKind
+		if err := value.UnmarshalText(data); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if value != kind {
+			t.Fatalf("Expected %v, got %v", kind, value)
+		}
+	}
+	var kind ProctoringEventKind
+	if err := kind.UnmarshalText([]byte("unknown")); err == nil {
+		t.Fatal("Expected error")
+	}
+	if s := ProctoringEventKind(-1).String(); s != "ProctoringEventKind(-1)" {
+		t.Fatalf("Expected %q, got %q", "ProctoringEventKind(-1)", s)
+	}
+}
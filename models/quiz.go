@@ -1,45 +1,310 @@
 package models
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
 	"github.com/udovin/gosql"
 )
 
+// ScoringPolicy represents policy of scoring answers.
+type ScoringPolicy int
+
+const (
+	// AllOrNothingScoring gives points only for completely
+	// correct answers.
+	AllOrNothingScoring ScoringPolicy = 1
+	// ProportionalScoring gives part of points proportional to
+	// amount of selected correct options minus share of points for
+	// every selected wrong option, but never less than zero.
+	ProportionalScoring ScoringPolicy = 2
+	// PenaltyScoring gives part of points for correct options minus
+	// wrong options, but never less than zero.
+	PenaltyScoring ScoringPolicy = 3
+	// NegativeMarkingScoring takes points for wrong answers of
+	// single choice problems to compensate guessing.
+	NegativeMarkingScoring ScoringPolicy = 4
+)
+
+// String returns string representation.
+func (p ScoringPolicy) String() string {
+	switch p {
+	case AllOrNothingScoring:
+		return "all_or_nothing"
+	case ProportionalScoring:
+		return "proportional"
+	case PenaltyScoring:
+		return "penalty"
+	case NegativeMarkingScoring:
+		return "negative_marking"
+	default:
+		return fmt.Sprintf("ScoringPolicy(%d)", p)
+	}
+}
+
+// MarshalText marshals policy to text.
+func (p ScoringPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText unmarshals policy from text.
+func (p *ScoringPolicy) UnmarshalText(data []byte) error {
+	switch s := string(data); s {
+	case "all_or_nothing":
+		*p = AllOrNothingScoring
+	case "proportional":
+		*p = ProportionalScoring
+	case "penalty":
+		*p = PenaltyScoring
+	case "negative_marking":
+		*p = NegativeMarkingScoring
+	default:
+		return fmt.Errorf("unsupported scoring policy: %q", s)
+	}
+	return nil
+}
+
+// LeaderboardVisibility represents what is shown to participants
+// on public leaderboard of quiz.
+type LeaderboardVisibility int
+
+const (
+	// HiddenLeaderboard means that leaderboard is not shown.
+	HiddenLeaderboard LeaderboardVisibility = 1
+	// ScoresLeaderboard means that only ranks and scores are shown.
+	ScoresLeaderboard LeaderboardVisibility = 2
+	// NamesLeaderboard means that names are shown with scores.
+	NamesLeaderboard LeaderboardVisibility = 3
+)
+
+// String returns string representation.
+func (v LeaderboardVisibility) String() string {
+	switch v {
+	case HiddenLeaderboard:
+		return "hidden"
+	case ScoresLeaderboard:
+		return "scores"
+	case NamesLeaderboard:
+		return "names"
+	default:
+		return fmt.Sprintf("LeaderboardVisibility(%d)", v)
+	}
+}
+
+// MarshalText marshals visibility to text.
+func (v LeaderboardVisibility) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText unmarshals visibility from text.
+func (v *LeaderboardVisibility) UnmarshalText(data []byte) error {
+	switch s := string(data); s {
+	case "hidden":
+		*v = HiddenLeaderboard
+	case "scores":
+		*v = ScoresLeaderboard
+	case "names":
+		*v = NamesLeaderboard
+	default:
+		return fmt.Errorf("unsupported leaderboard visibility: %q", s)
+	}
+	return nil
+}
+
+// QuizAccess represents who can take quiz.
+type QuizAccess int
+
+const (
+	// OpenQuizAccess means that quiz is available for everyone.
+	OpenQuizAccess QuizAccess = 1
+	// RegisteredQuizAccess means that quiz is available only for
+	// registered users.
+	RegisteredQuizAccess QuizAccess = 2
+	// InviteQuizAccess means that quiz is available only for
+	// invited participants.
+	InviteQuizAccess QuizAccess = 3
+)
+
+// String returns string representation.
+func (a QuizAccess) String() string {
+	switch a {
+	case OpenQuizAccess:
+		return "open"
+	case RegisteredQuizAccess:
+		return "registered"
+	case InviteQuizAccess:
+		return "invite"
+	default:
+		return fmt.Sprintf("QuizAccess(%d)", a)
+	}
+}
+
+// MarshalText marshals access to text.
+func (a QuizAccess) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText unmarshals access from text.
+func (a *QuizAccess) UnmarshalText(data []byte) error {
+	switch s := string(data); s {
+	case "open":
+		*a = OpenQuizAccess
+	case "registered":
+		*a = RegisteredQuizAccess
+	case "invite":
+		*a = InviteQuizAccess
+	default:
+		return fmt.Errorf("unsupported quiz access: %q", s)
+	}
+	return nil
+}
+
+// QuizConfig represents quiz config.
+type QuizConfig struct {
+	// Duration contains time limit of attempt in seconds.
+	//
+	// Zero duration means that attempt is not limited by time.
+	Duration int64 `json:"duration,omitempty"`
+	// OpenTime contains time when quiz opens for new attempts.
+	//
+	// Zero value means that quiz is open from the beginning.
+	OpenTime int64 `json:"open_time,omitempty"`
+	// CloseTime contains time when quiz closes.
+	//
+	// Zero value means that quiz is never closed.
+	CloseTime int64 `json:"close_time,omitempty"`
+	// Scoring contains default scoring policy for quiz sections.
+	//
+	// Zero policy means AllOrNothingScoring.
+	Scoring ScoringPolicy `json:"scoring,omitempty"`
+	// Leaderboard contains visibility of public leaderboard.
+	//
+	// Zero visibility means HiddenLeaderboard.
+	Leaderboard LeaderboardVisibility `json:"leaderboard,omitempty"`
+	// Access contains who can take quiz.
+	//
+	// Zero access means OpenQuizAccess.
+	Access QuizAccess `json:"access,omitempty"`
+}
+
+// Quiz represents a quiz.
 type Quiz struct {
 	baseObject
+	// OwnerID contains ID of account that owns quiz.
+	OwnerID NInt64 `db:"owner_id"`
+	// Title contains quiz title.
+	Title string `db:"title"`
+	// Description contains quiz description.
+	Description string `db:"description"`
+	// Config contains quiz config.
+	Config JSON `db:"config"`
+}
+
+// Clone creates copy of quiz.
+func (o Quiz) Clone() Quiz {
+	o.Config = o.Config.Clone()
+	return o
 }
 
+// ScanConfig scans quiz config.
+func (o Quiz) ScanConfig(config *QuizConfig) error {
+	if len(o.Config) == 0 {
+		*config = QuizConfig{}
+		return nil
+	}
+	return json.Unmarshal(o.Config, config)
+}
+
+// SetConfig updates quiz config.
+func (o *Quiz) SetConfig(config QuizConfig) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	o.Config = raw
+	return nil
+}
+
+// QuizEvent represents a quiz event.
 type QuizEvent struct {
 	baseEvent
 	Quiz
 }
 
+// Object returns event quiz.
 func (e QuizEvent) Object() Quiz {
 	return e.Quiz
 }
 
+// SetObject sets event quiz.
 func (e *QuizEvent) SetObject(o Quiz) {
 	e.Quiz = o
 }
 
+// QuizStore represents store for quizzes.
 type QuizStore struct {
 	baseStore[Quiz, QuizEvent, *Quiz, *QuizEvent]
-	quizes map[int64]Quiz
+	quizes  map[int64]Quiz
+	byOwner index[int64]
+}
+
+// Get returns quiz by ID.
+//
+// If there is no quiz with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *QuizStore) Get(id int64) (Quiz, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if quiz, ok := s.quizes[id]; ok {
+		return quiz.Clone(), nil
+	}
+	return Quiz{}, sql.ErrNoRows
+}
+
+// All returns all quizzes.
+func (s *QuizStore) All() ([]Quiz, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var quizes []Quiz
+	for _, quiz := range s.quizes {
+		quizes = append(quizes, quiz.Clone())
+	}
+	return quizes, nil
+}
+
+// FindByOwner returns quizzes by owner ID.
+func (s *QuizStore) FindByOwner(ownerID int64) ([]Quiz, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var quizes []Quiz
+	for id := range s.byOwner[ownerID] {
+		if quiz, ok := s.quizes[id]; ok {
+			quizes = append(quizes, quiz.Clone())
+		}
+	}
+	return quizes, nil
 }
 
 func (s *QuizStore) reset() {
 	s.quizes = map[int64]Quiz{}
+	s.byOwner = index[int64]{}
 }
 
 func (s *QuizStore) onCreateObject(quiz Quiz) {
 	s.quizes[quiz.ID] = quiz
+	s.byOwner.Create(int64(quiz.OwnerID), quiz.ID)
 }
 
 func (s *QuizStore) onDeleteObject(id int64) {
 	if quiz, ok := s.quizes[id]; ok {
+		s.byOwner.Delete(int64(quiz.OwnerID), quiz.ID)
 		delete(s.quizes, quiz.ID)
 	}
 }
 
+var _ baseStoreImpl[Quiz] = (*QuizStore)(nil)
+
 // NewQuizStore creates a new instance of QuizStore.
 func NewQuizStore(
 	db *gosql.DB, table, eventTable string,
@@ -0,0 +1,459 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	mathrand "math/rand"
+	"time"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+)
+
+// QuizAttemptStatus represents status of quiz attempt.
+type QuizAttemptStatus int
+
+const (
+	// StartedAttempt represents attempt that is in progress.
+	StartedAttempt QuizAttemptStatus = 1
+	// FinishedAttempt represents attempt that is finished.
+	FinishedAttempt QuizAttemptStatus = 2
+)
+
+// String returns string representation.
+func (s QuizAttemptStatus) String() string {
+	switch s {
+	case StartedAttempt:
+		return "started"
+	case FinishedAttempt:
+		return "finished"
+	default:
+		return fmt.Sprintf("QuizAttemptStatus(%d)", s)
+	}
+}
+
+// MarshalText marshals status to text.
+func (s QuizAttemptStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText unmarshals status from text.
+func (s *QuizAttemptStatus) UnmarshalText(data []byte) error {
+	switch v := string(data); v {
+	case "started":
+		*s = StartedAttempt
+	case "finished":
+		*s = FinishedAttempt
+	default:
+		return fmt.Errorf("unsupported status: %q", v)
+	}
+	return nil
+}
+
+// Verdict represents verdict of graded answer.
+type Verdict int
+
+const (
+	// AcceptedVerdict represents correct answer.
+	AcceptedVerdict Verdict = 1
+	// RejectedVerdict represents wrong answer.
+	RejectedVerdict Verdict = 2
+	// NotAnsweredVerdict represents absence of answer.
+	NotAnsweredVerdict Verdict = 3
+	// PartiallyAcceptedVerdict represents partially correct answer.
+	PartiallyAcceptedVerdict Verdict = 4
+	// PendingVerdict represents answer that waits for manual grading.
+	PendingVerdict Verdict = 5
+)
+
+// String returns string representation.
+func (v Verdict) String() string {
+	switch v {
+	case AcceptedVerdict:
+		return "accepted"
+	case RejectedVerdict:
+		return "rejected"
+	case NotAnsweredVerdict:
+		return "not_answered"
+	case PartiallyAcceptedVerdict:
+		return "partially_accepted"
+	case PendingVerdict:
+		return "pending"
+	default:
+		return fmt.Sprintf("Verdict(%d)", v)
+	}
+}
+
+// MarshalText marshals verdict to text.
+func (v Verdict) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText unmarshals verdict from text.
+func (v *Verdict) UnmarshalText(data []byte) error {
+	switch s := string(data); s {
+	case "accepted":
+		*v = AcceptedVerdict
+	case "rejected":
+		*v = RejectedVerdict
+	case "not_answered":
+		*v = NotAnsweredVerdict
+	case "partially_accepted":
+		*v = PartiallyAcceptedVerdict
+	case "pending":
+		*v = PendingVerdict
+	default:
+		return fmt.Errorf("unsupported verdict: %q", s)
+	}
+	return nil
+}
+
+// QuizAttemptAnswer represents answer given for problem.
+type QuizAttemptAnswer struct {
+	// Options contains original indexes of selected options.
+	//
+	// For matching problems i-th element contains original index of
+	// option that is matched with i-th prompt or -1 if prompt is not
+	// matched.
+	Options []int `json:"options,omitempty"`
+	// Text contains text answer.
+	Text string `json:"text,omitempty"`
+	// Value contains numeric answer.
+	Value *float64 `json:"value,omitempty"`
+}
+
+// IsEmpty returns flag that answer does not contain anything.
+func (a QuizAttemptAnswer) IsEmpty() bool {
+	return len(a.Options) == 0 && len(a.Text) == 0 && a.Value == nil
+}
+
+// QuizAttemptProblem represents problem drawn for attempt.
+type QuizAttemptProblem struct {
+	// SectionID contains ID of quiz section.
+	SectionID int64 `json:"section_id"`
+	// ProblemID contains ID of problem.
+	ProblemID int64 `json:"problem_id"`
+	// Revision contains ID of problem event that is used by attempt.
+	Revision int64 `json:"revision,omitempty"`
+	// Points contains maximal amount of points for problem.
+	Points int64 `json:"points"`
+	// Options contains original indexes of options in shown order.
+	Options []int `json:"options,omitempty"`
+	// Values contains drawn values of variables for parameterized
+	// problems.
+	Values map[string]float64 `json:"values,omitempty"`
+	// Answer contains last given answer.
+	Answer *QuizAttemptAnswer `json:"answer,omitempty"`
+	// AnswerTime contains time of last given answer.
+	AnswerTime int64 `json:"answer_time,omitempty"`
+	// Verdict contains verdict of graded answer.
+	Verdict Verdict `json:"verdict,omitempty"`
+	// Score contains amount of points for graded answer.
+	Score float64 `json:"score,omitempty"`
+	// Comment contains comment of grader for manually graded answer.
+	Comment string `json:"comment,omitempty"`
+}
+
+// QuizAttemptState represents frozen state of attempt.
+type QuizAttemptState struct {
+	// Seed contains seed that was used for drawing problems.
+	Seed int64 `json:"seed"`
+	// Problems contains drawn problems in shown order.
+	Problems []QuizAttemptProblem `json:"problems"`
+	// Score contains total amount of points for graded attempt.
+	Score float64 `json:"score,omitempty"`
+}
+
+// GenerateSeed generates a new value for state seed.
+func (s *QuizAttemptState) GenerateSeed() error {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return err
+	}
+	s.Seed = int64(binary.BigEndian.Uint64(bytes) >> 1)
+	return nil
+}
+
+// DrawQuizAttemptProblems draws problems of attempt from quiz sections.
+//
+// Fixed problems of section are used as is, problems of pool sections
+// are sampled from pool, options of choice problems are shuffled and
+// values of variables of parameterized problems are drawn.
+// Result depends only on seed and contents of sections, pools
+// and problems.
+func DrawQuizAttemptProblems(
+	seed int64, sections []QuizSection,
+	getPool func(int64) ([]PoolProblem, error),
+	getProblem func(int64) (Problem, error),
+) ([]QuizAttemptProblem, error) {
+	random := mathrand.New(mathrand.NewSource(seed))
+	var problems []QuizAttemptProblem
+	for _, section := range sections {
+		var ids []int64
+		if section.PoolID != 0 {
+			poolProblems, err := getPool(int64(section.PoolID))
+			if err != nil {
+				return nil, err
+			}
+			sample, err := SamplePoolProblems(
+				poolProblems, int(section.ProblemCount), random.Int63(),
+			)
+			if err != nil {
+				return nil, err
+			}
+			for _, problem := range sample {
+				ids = append(ids, problem.ProblemID)
+			}
+		} else {
+			var config QuizSectionConfig
+			if err := section.ScanConfig(&config); err != nil {
+				return nil, err
+			}
+			ids = config.Problems
+		}
+		for _, id := range ids {
+			problem, err := getProblem(id)
+			if err != nil {
+				return nil, err
+			}
+			attemptProblem := QuizAttemptProblem{
+				SectionID: section.ID,
+				ProblemID: problem.ID,
+				Points:    section.Points,
+			}
+			var config ProblemConfig
+			if err := problem.ScanConfig(&config); err != nil {
+				return nil, err
+			}
+			if problem.Kind.HasOptions() {
+				attemptProblem.Options = random.Perm(len(config.Options))
+			}
+			if len(config.Variables) > 0 {
+				attemptProblem.Values = SampleProblemValues(
+					config.Variables, random.Int63(),
+				)
+			}
+			problems = append(problems, attemptProblem)
+		}
+	}
+	return problems, nil
+}
+
+// QuizAttempt represents attempt of account to take quiz.
+type QuizAttempt struct {
+	baseObject
+	// QuizID contains ID of quiz.
+	QuizID int64 `db:"quiz_id"`
+	// AccountID contains ID of account.
+	AccountID int64 `db:"account_id"`
+	// Status contains status of attempt.
+	Status QuizAttemptStatus `db:"status"`
+	// StartTime contains time when attempt was started.
+	StartTime int64 `db:"start_time"`
+	// Deadline contains time when attempt should be finished.
+	//
+	// Zero deadline means that attempt is not limited by time.
+	Deadline NInt64 `db:"deadline"`
+	// FinishTime contains time when attempt was finished.
+	FinishTime NInt64 `db:"finish_time"`
+	// State contains frozen state of attempt.
+	State JSON `db:"state"`
+}
+
+// Clone creates copy of quiz attempt.
+func (o QuizAttempt) Clone() QuizAttempt {
+	o.State = o.State.Clone()
+	return o
+}
+
+// IsExpired returns flag that started attempt exceeded deadline.
+func (o QuizAttempt) IsExpired(now time.Time) bool {
+	return o.Status == StartedAttempt && o.Deadline != 0 &&
+		now.Unix() >= int64(o.Deadline)
+}
+
+// IsActive returns flag that answers can be given for attempt.
+func (o QuizAttempt) IsActive(now time.Time) bool {
+	return o.Status == StartedAttempt && !o.IsExpired(now)
+}
+
+// ScanState scans quiz attempt state.
+func (o QuizAttempt) ScanState(state *QuizAttemptState) error {
+	if len(o.State) == 0 {
+		*state = QuizAttemptState{}
+		return nil
+	}
+	return json.Unmarshal(o.State, state)
+}
+
+// SetState updates quiz attempt state.
+func (o *QuizAttempt) SetState(state QuizAttemptState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	o.State = raw
+	return nil
+}
+
+// QuizAttemptEvent represents a quiz attempt event.
+type QuizAttemptEvent struct {
+	baseEvent
+	QuizAttempt
+}
+
+// Object returns event quiz attempt.
+func (e QuizAttemptEvent) Object() QuizAttempt {
+	return e.QuizAttempt
+}
+
+// SetObject sets event quiz attempt.
+func (e *QuizAttemptEvent) SetObject(o QuizAttempt) {
+	e.QuizAttempt = o
+}
+
+// QuizAttemptStore represents store for quiz attempts.
+type QuizAttemptStore struct {
+	baseStore[QuizAttempt, QuizAttemptEvent, *QuizAttempt, *QuizAttemptEvent]
+	attempts  map[int64]QuizAttempt
+	byQuiz    index[int64]
+	byAccount index[int64]
+}
+
+// Get returns quiz attempt by ID.
+//
+// If there is no quiz attempt with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *QuizAttemptStore) Get(id int64) (QuizAttempt, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if attempt, ok := s.attempts[id]; ok {
+		return attempt.Clone(), nil
+	}
+	return QuizAttempt{}, sql.ErrNoRows
+}
+
+// FindByQuiz returns attempts by quiz ID.
+func (s *QuizAttemptStore) FindByQuiz(quizID int64) ([]QuizAttempt, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var attempts []QuizAttempt
+	for id := range s.byQuiz[quizID] {
+		if attempt, ok := s.attempts[id]; ok {
+			attempts = append(attempts, attempt.Clone())
+		}
+	}
+	return attempts, nil
+}
+
+// FindByAccount returns attempts by account ID.
+func (s *QuizAttemptStore) FindByAccount(
+	accountID int64,
+) ([]QuizAttempt, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var attempts []QuizAttempt
+	for id := range s.byAccount[accountID] {
+		if attempt, ok := s.attempts[id]; ok {
+			attempts = append(attempts, attempt.Clone())
+		}
+	}
+	return attempts, nil
+}
+
+// FindByQuizAccount returns attempts of account for quiz.
+func (s *QuizAttemptStore) FindByQuizAccount(
+	quizID, accountID int64,
+) ([]QuizAttempt, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var attempts []QuizAttempt
+	for id := range s.byAccount[accountID] {
+		if attempt, ok := s.attempts[id]; ok && attempt.QuizID == quizID {
+			attempts = append(attempts, attempt.Clone())
+		}
+	}
+	return attempts, nil
+}
+
+// ActiveQuizAttemptError represents error of creating attempt when
+// account already has active attempt of quiz.
+type ActiveQuizAttemptError struct {
+	// ID contains ID of active attempt.
+	ID int64
+}
+
+// Error returns error message.
+func (e ActiveQuizAttemptError) Error() string {
+	return fmt.Sprintf("attempt %d is already active", e.ID)
+}
+
+// CreateActive creates attempt if account does not have active
+// attempts of the same quiz.
+//
+// Store is locked until end of transaction, so concurrent calls can
+// not create several active attempts. If there is active attempt then
+// ActiveQuizAttemptError will be returned.
+func (s *QuizAttemptStore) CreateActive(
+	ctx context.Context, attempt *QuizAttempt, now time.Time,
+) error {
+	tx := db.GetTx(ctx)
+	if tx == nil {
+		return gosql.WrapTx(ctx, s.db, func(tx *sql.Tx) error {
+			return s.CreateActive(db.WithTx(ctx, tx), attempt, now)
+		}, sqlRepeatableRead)
+	}
+	if err := s.lockStore(tx); err != nil {
+		return err
+	}
+	if err := s.Sync(ctx); err != nil {
+		return err
+	}
+	attempts, err := s.FindByQuizAccount(attempt.QuizID, attempt.AccountID)
+	if err != nil {
+		return err
+	}
+	for _, other := range attempts {
+		if other.IsActive(now) {
+			return ActiveQuizAttemptError{ID: other.ID}
+		}
+	}
+	return s.Create(ctx, attempt)
+}
+
+func (s *QuizAttemptStore) reset() {
+	s.attempts = map[int64]QuizAttempt{}
+	s.byQuiz = index[int64]{}
+	s.byAccount = index[int64]{}
+}
+
+func (s *QuizAttemptStore) onCreateObject(attempt QuizAttempt) {
+	s.attempts[attempt.ID] = attempt
+	s.byQuiz.Create(attempt.QuizID, attempt.ID)
+	s.byAccount.Create(attempt.AccountID, attempt.ID)
+}
+
+func (s *QuizAttemptStore) onDeleteObject(id int64) {
+	if attempt, ok := s.attempts[id]; ok {
+		s.byQuiz.Delete(attempt.QuizID, attempt.ID)
+		s.byAccount.Delete(attempt.AccountID, attempt.ID)
+		delete(s.attempts, attempt.ID)
+	}
+}
+
+var _ baseStoreImpl[QuizAttempt] = (*QuizAttemptStore)(nil)
+
+// NewQuizAttemptStore creates a new instance of QuizAttemptStore.
+func NewQuizAttemptStore(
+	db *gosql.DB, table, eventTable string,
+) *QuizAttemptStore {
+	impl := &QuizAttemptStore{}
+	impl.baseStore = makeBaseStore[QuizAttempt, QuizAttemptEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
@@ -0,0 +1,246 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type quizAttemptStoreTest struct{}
+
+func (t *quizAttemptStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "quiz_attempt" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"account_id" integer NOT NULL,` +
+			`"status" integer NOT NULL,` +
+			`"start_time" bigint NOT NULL,` +
+			`"deadline" bigint NULL,` +
+			`"finish_time" bigint NULL,` +
+			`"state" blob NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "quiz_attempt_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"account_id" integer NOT NULL,` +
+			`"status" integer NOT NULL,` +
+			`"start_time" bigint NOT NULL,` +
+			`"deadline" bigint NULL,` +
+			`"finish_time" bigint NULL,` +
+			`"state" blob NOT NULL)`,
+	)
+	return err
+}
+
+func (t *quizAttemptStoreTest) newStore() Store {
+	return NewQuizAttemptStore(testDB, "quiz_attempt", "quiz_attempt_event")
+}
+
+func (t *quizAttemptStoreTest) newObject() Object {
+	return QuizAttempt{
+		QuizID:    1,
+		AccountID: 2,
+		Status:    StartedAttempt,
+		StartTime: 1000,
+		Deadline:  4600,
+		State:     JSON(`{"seed":1,"problems":[]}`),
+	}
+}
+
+func (t *quizAttemptStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(QuizAttempt)
+	err := s.(*QuizAttemptStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *quizAttemptStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*QuizAttemptStore).Update(wrapContext(tx), o.(QuizAttempt))
+}
+
+func (t *quizAttemptStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*QuizAttemptStore).Delete(wrapContext(tx), id)
+}
+
+func TestQuizAttemptStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&quizAttemptStoreTest{}}
+	tester.Test(t)
+}
+
+func TestQuizAttemptStoreCreateActive(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := (&quizAttemptStoreTest{}).prepareDB(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal("Error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := NewQuizAttemptStore(testDB, "quiz_attempt", "quiz_attempt_event")
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	newAttempt := func() QuizAttempt {
+		return (&quizAttemptStoreTest{}).newObject().(QuizAttempt)
+	}
+	now := time.Unix(2000, 0)
+	attempt := newAttempt()
+	if err := store.CreateActive(ctx, &attempt, now); err != nil {
+		t.Fatal("Error:", err)
+	}
+	other := newAttempt()
+	if err := store.CreateActive(ctx, &other, now); err != (ActiveQuizAttemptError{
+		ID: attempt.ID,
+	}) {
+		t.Fatalf("Expected active attempt %d, got %v", attempt.ID, err)
+	}
+	// Expired attempts are not active.
+	if err := store.CreateActive(ctx, &other, time.Unix(5000, 0)); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if other.ID == attempt.ID {
+		t.Fatal("Expected new attempt")
+	}
+}
+
+func TestQuizAttempt_IsActive(t *testing.T) {
+	attempt := QuizAttempt{Status: StartedAttempt, Deadline: 100}
+	if !attempt.IsActive(time.Unix(99, 0)) {
+		t.Fatal("Attempt should be active")
+	}
+	if attempt.IsActive(time.Unix(100, 0)) {
+		t.Fatal("Attempt should not be active")
+	}
+	if !attempt.IsExpired(time.Unix(100, 0)) {
+		t.Fatal("Attempt should be expired")
+	}
+	attempt.Deadline = 0
+	if !attempt.IsActive(time.Unix(1000000, 0)) {
+		t.Fatal("Attempt should be active")
+	}
+	attempt.Status = FinishedAttempt
+	if attempt.IsActive(time.Unix(99, 0)) || attempt.IsExpired(time.Unix(99, 0)) {
+		t.Fatal("Finished attempt should not be active or expired")
+	}
+}
+
+func TestDrawQuizAttemptProblems(t *testing.T) {
+	problems := map[int64]Problem{
+		1: {
+			baseObject: baseObject{ID: 1},
+			Kind:       SingleChoiceProblem,
+			Config:     JSON(`{"options":[{"text":"A"},{"text":"B"},{"text":"C"}]}`),
+		},
+		2: {
+			baseObject: baseObject{ID: 2},
+			Kind:       NumericProblem,
+			Config:     JSON(`{"variables":[{"name":"a","min":1,"max":9}]}`),
+		},
+		3: {
+			baseObject: baseObject{ID: 3},
+			Kind:       NumericProblem,
+			Config:     JSON(`{"variables":[{"name":"a","min":1,"max":9}]}`),
+		},
+		4: {
+			baseObject: baseObject{ID: 4},
+			Kind:       NumericProblem,
+			Config:     JSON(`{"variables":[{"name":"a","min":1,"max":9}]}`),
+		},
+	}
+	pools := map[int64][]PoolProblem{
+		5: {
+			{baseObject: baseObject{ID: 1}, ProblemID: 2, Position: 1},
+			{baseObject: baseObject{ID: 2}, ProblemID: 3, Position: 2},
+			{baseObject: baseObject{ID: 3}, ProblemID: 4, Position: 3},
+		},
+	}
+	sections := []QuizSection{
+		{
+			baseObject: baseObject{ID: 1},
+			Points:     1,
+			Config:     JSON(`{"problems":[1]}`),
+		},
+		{
+			baseObject:   baseObject{ID: 2},
+			Points:       2,
+			PoolID:       5,
+			ProblemCount: 2,
+		},
+	}
+	getPool := func(id int64) ([]PoolProblem, error) {
+		return pools[id], nil
+	}
+	getProblem := func(id int64) (Problem, error) {
+		if problem, ok := problems[id]; ok {
+			return problem, nil
+		}
+		return Problem{}, sql.ErrNoRows
+	}
+	drawn, err := DrawQuizAttemptProblems(42, sections, getPool, getProblem)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(drawn) != 3 {
+		t.Fatalf("Expected %d problems, got %d", 3, len(drawn))
+	}
+	if drawn[0].ProblemID != 1 || drawn[0].Points != 1 {
+		t.Fatalf("Unexpected fixed problem: %v", drawn[0])
+	}
+	if len(drawn[0].Options) != 3 {
+		t.Fatalf("Expected %d options, got %v", 3, drawn[0].Options)
+	}
+	for _, problem := range drawn[1:] {
+		if problem.SectionID != 2 || problem.Points != 2 {
+			t.Fatalf("Unexpected pool problem: %v", problem)
+		}
+		if problem.Options != nil {
+			t.Fatalf("Unexpected options: %v", problem.Options)
+		}
+		if value, ok := problem.Values["a"]; !ok || len(problem.Values) != 1 ||
+			value < 1 || value > 9 {
+			t.Fatalf("Unexpected values: %v", problem.Values)
+		}
+	}
+	if drawn[0].Values != nil {
+		t.Fatalf("Unexpected values: %v", drawn[0].Values)
+	}
+	if drawn[1].ProblemID == drawn[2].ProblemID {
+		t.Fatal("Problem drawn twice")
+	}
+	other, err := DrawQuizAttemptProblems(42, sections, getPool, getProblem)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !reflect.DeepEqual(drawn, other) {
+		t.Fatalf("Expected %v, got %v", drawn, other)
+	}
+	sections[1].ProblemCount = 4
+	if _, err := DrawQuizAttemptProblems(
+		42, sections, getPool, getProblem,
+	); err == nil {
+		t.Fatal("Expected error")
+	}
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/udovin/gosql"
+)
+
+// QuizGradeStatus represents status of manual grading of answer.
+type QuizGradeStatus int
+
+const (
+	// PendingGrade represents answer that waits for grader.
+	PendingGrade QuizGradeStatus = 1
+	// ClaimedGrade represents answer that is claimed by grader.
+	ClaimedGrade QuizGradeStatus = 2
+	// GradedGrade represents answer that is graded.
+	GradedGrade QuizGradeStatus = 3
+)
+
+// String returns string representation.
+func (s QuizGradeStatus) String() string {
+	switch s {
+	case PendingGrade:
+		return "pending"
+	case ClaimedGrade:
+		return "claimed"
+	case GradedGrade:
+		return "graded"
+	default:
+		return fmt.Sprintf("QuizGradeStatus(%d)", s)
+	}
+}
+
+// MarshalText marshals status to text.
+func (s QuizGradeStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText unmarshals status from text.
+func (s *QuizGradeStatus) UnmarshalText(data []byte) error {
+	switch v := string(data); v {
+	case "pending":
+		*s = PendingGrade
+	case "claimed":
+		*s = ClaimedGrade
+	case "graded":
+		*s = GradedGrade
+	default:
+		return fmt.Errorf("unsupported status: %q", v)
+	}
+	return nil
+}
+
+// QuizGradeResult represents result of manual grading.
+type QuizGradeResult struct {
+	// Score contains amount of points for answer.
+	Score float64 `json:"score"`
+	// Comment contains comment of grader.
+	Comment string `json:"comment,omitempty"`
+	// GraderID contains ID of account that graded answer.
+	GraderID int64 `json:"grader_id"`
+}
+
+// QuizGrade represents manual grading of answer for problem of
+// quiz attempt.
+//
+// Every claim, release and grading of answer is saved as event,
+// so history of grading can be observed using events of grade.
+type QuizGrade struct {
+	baseObject
+	// QuizID contains ID of quiz.
+	QuizID int64 `db:"quiz_id"`
+	// AttemptID contains ID of quiz attempt.
+	AttemptID int64 `db:"attempt_id"`
+	// ProblemID contains ID of problem.
+	ProblemID int64 `db:"problem_id"`
+	// Status contains status of grading.
+	Status QuizGradeStatus `db:"status"`
+	// GraderID contains ID of account that claimed or graded answer.
+	GraderID NInt64 `db:"grader_id"`
+	// Result contains result of grading.
+	Result JSON `db:"result"`
+}
+
+// ScanResult scans result of grading.
+//
+// Result is not scanned for answers that were never graded.
+func (o QuizGrade) ScanResult(result *QuizGradeResult) error {
+	if len(o.Result) == 0 {
+		*result = QuizGradeResult{}
+		return nil
+	}
+	return json.Unmarshal(o.Result, result)
+}
+
+// SetResult updates result of grading.
+func (o *QuizGrade) SetResult(result QuizGradeResult) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	o.Result = raw
+	return nil
+}
+
+// Clone creates copy of quiz grade.
+func (o QuizGrade) Clone() QuizGrade {
+	o.Result = o.Result.Clone()
+	return o
+}
+
+// QuizGradeEvent represents a quiz grade event.
+type QuizGradeEvent struct {
+	baseEvent
+	QuizGrade
+}
+
+// Object returns event quiz grade.
+func (e QuizGradeEvent) Object() QuizGrade {
+	return e.QuizGrade
+}
+
+// SetObject sets event quiz grade.
+func (e *QuizGradeEvent) SetObject(o QuizGrade) {
+	e.QuizGrade = o
+}
+
+// QuizGradeStore represents store for quiz grades.
+type QuizGradeStore struct {
+	baseStore[QuizGrade, QuizGradeEvent, *QuizGrade, *QuizGradeEvent]
+	grades    map[int64]QuizGrade
+	byQuiz    index[int64]
+	byAttempt index[int64]
+}
+
+// Get returns quiz grade by ID.
+//
+// If there is no quiz grade with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *QuizGradeStore) Get(id int64) (QuizGrade, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if grade, ok := s.grades[id]; ok {
+		return grade.Clone(), nil
+	}
+	return QuizGrade{}, sql.ErrNoRows
+}
+
+// FindByQuiz returns grades by quiz ID.
+func (s *QuizGradeStore) FindByQuiz(quizID int64) ([]QuizGrade, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var grades []QuizGrade
+	for id := range s.byQuiz[quizID] {
+		if grade, ok := s.grades[id]; ok {
+			grades = append(grades, grade.Clone())
+		}
+	}
+	return grades, nil
+}
+
+// FindByAttempt returns grades by attempt ID.
+func (s *QuizGradeStore) FindByAttempt(attemptID int64) ([]QuizGrade, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var grades []QuizGrade
+	for id := range s.byAttempt[attemptID] {
+		if grade, ok := s.grades[id]; ok {
+			grades = append(grades, grade.Clone())
+		}
+	}
+	return grades, nil
+}
+
+func (s *QuizGradeStore) reset() {
+	s.grades = map[int64]QuizGrade{}
+	s.byQuiz = index[int64]{}
+	s.byAttempt = index[int64]{}
+}
+
+func (s *QuizGradeStore) onCreateObject(grade QuizGrade) {
+	s.grades[grade.ID] = grade
+	s.byQuiz.Create(grade.QuizID, grade.ID)
+	s.byAttempt.Create(grade.AttemptID, grade.ID)
+}
+
+func (s *QuizGradeStore) onDeleteObject(id int64) {
+	if grade, ok := s.grades[id]; ok {
+		s.byQuiz.Delete(grade.QuizID, grade.ID)
+		s.byAttempt.Delete(grade.AttemptID, grade.ID)
+		delete(s.grades, grade.ID)
+	}
+}
+
+var _ baseStoreImpl[QuizGrade] = (*QuizGradeStore)(nil)
+
+// NewQuizGradeStore creates a new instance of QuizGradeStore.
+func NewQuizGradeStore(
+	db *gosql.DB, table, eventTable string,
+) *QuizGradeStore {
+	impl := &QuizGradeStore{}
+	impl.baseStore = makeBaseStore[QuizGrade, QuizGradeEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type quizGradeStoreTest struct{}
+
+func (t *quizGradeStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "quiz_grade" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"attempt_id" integer NOT NULL,` +
+			`"problem_id" integer NOT NULL,` +
+			`"status" integer NOT NULL,` +
+			`"grader_id" integer NULL,` +
+			`"result" blob NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "quiz_grade_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"attempt_id" integer NOT NULL,` +
+			`"problem_id" integer NOT NULL,` +
+			`"status" integer NOT NULL,` +
+			`"grader_id" integer NULL,` +
+			`"result" blob NOT NULL)`,
+	)
+	return err
+}
+
+func (t *quizGradeStoreTest) newStore() Store {
+	return NewQuizGradeStore(testDB, "quiz_grade", "quiz_grade_event")
+}
+
+func (t *quizGradeStoreTest) newObject() Object {
+	return QuizGrade{
+		QuizID:    1,
+		AttemptID: 2,
+		ProblemID: 3,
+		Status:    GradedGrade,
+		GraderID:  4,
+		Result:    JSON(`{"score":1.5,"comment":"Good","grader_id":4}`),
+	}
+}
+
+func (t *quizGradeStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(QuizGrade)
+	err := s.(*QuizGradeStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *quizGradeStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*QuizGradeStore).Update(wrapContext(tx), o.(QuizGrade))
+}
+
+func (t *quizGradeStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*QuizGradeStore).Delete(wrapContext(tx), id)
+}
+
+func TestQuizGradeStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&quizGradeStoreTest{}}
+	tester.Test(t)
+}
+
+func TestQuizGradeResult(t *testing.T) {
+	grade := QuizGrade{}
+	var result QuizGradeResult
+	if err := grade.ScanResult(&result); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if result != (QuizGradeResult{}) {
+		t.Fatalf("Expected empty result, got %+v", result)
+	}
+	if err := grade.SetResult(QuizGradeResult{
+		Score: 2, Comment: "Good",
+	}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := grade.ScanResult(&result); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if result.Score != 2 || result.Comment != "Good" {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+}
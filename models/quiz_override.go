@@ -0,0 +1,196 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/udovin/gosql"
+)
+
+// QuizOverride represents schedule override of quiz for participant.
+//
+// Overrides are used for accommodations like extended time or
+// different availability window.
+type QuizOverride struct {
+	baseObject
+	// QuizID contains ID of quiz.
+	QuizID int64 `db:"quiz_id"`
+	// AccountID contains ID of participant account.
+	AccountID int64 `db:"account_id"`
+	// OpenTime contains overridden open time of quiz.
+	OpenTime NInt64 `db:"open_time"`
+	// CloseTime contains overridden close time of quiz.
+	CloseTime NInt64 `db:"close_time"`
+	// Duration contains overridden time limit of attempt in seconds.
+	Duration NInt64 `db:"duration"`
+}
+
+// Clone creates copy of quiz override.
+func (o QuizOverride) Clone() QuizOverride {
+	return o
+}
+
+// QuizOverrideEvent represents a quiz override event.
+type QuizOverrideEvent struct {
+	baseEvent
+	QuizOverride
+}
+
+// Object returns event quiz override.
+func (e QuizOverrideEvent) Object() QuizOverride {
+	return e.QuizOverride
+}
+
+// SetObject sets event quiz override.
+func (e *QuizOverrideEvent) SetObject(o QuizOverride) {
+	e.QuizOverride = o
+}
+
+// QuizOverrideStore represents store for quiz overrides.
+type QuizOverrideStore struct {
+	baseStore[QuizOverride, QuizOverrideEvent, *QuizOverride, *QuizOverrideEvent]
+	overrides map[int64]QuizOverride
+	byQuiz    index[int64]
+	byAccount index[int64]
+}
+
+// Get returns quiz override by ID.
+//
+// If there is no quiz override with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *QuizOverrideStore) Get(id int64) (QuizOverride, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if override, ok := s.overrides[id]; ok {
+		return override.Clone(), nil
+	}
+	return QuizOverride{}, sql.ErrNoRows
+}
+
+// FindByQuiz returns overrides by quiz ID.
+func (s *QuizOverrideStore) FindByQuiz(quizID int64) ([]QuizOverride, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var overrides []QuizOverride
+	for id := range s.byQuiz[quizID] {
+		if override, ok := s.overrides[id]; ok {
+			overrides = append(overrides, override.Clone())
+		}
+	}
+	return overrides, nil
+}
+
+// GetByQuizAccount returns override of account for quiz.
+//
+// If there is no such override then sql.ErrNoRows will be returned.
+func (s *QuizOverrideStore) GetByQuizAccount(
+	quizID, accountID int64,
+) (QuizOverride, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for id := range s.byAccount[accountID] {
+		if override, ok := s.overrides[id]; ok && override.QuizID == quizID {
+			return override.Clone(), nil
+		}
+	}
+	return QuizOverride{}, sql.ErrNoRows
+}
+
+func (s *QuizOverrideStore) reset() {
+	s.overrides = map[int64]QuizOverride{}
+	s.byQuiz = index[int64]{}
+	s.byAccount = index[int64]{}
+}
+
+func (s *QuizOverrideStore) onCreateObject(override QuizOverride) {
+	s.overrides[override.ID] = override
+	s.byQuiz.Create(override.QuizID, override.ID)
+	s.byAccount.Create(override.AccountID, override.ID)
+}
+
+func (s *QuizOverrideStore) onDeleteObject(id int64) {
+	if override, ok := s.overrides[id]; ok {
+		s.byQuiz.Delete(override.QuizID, override.ID)
+		s.byAccount.Delete(override.AccountID, override.ID)
+		delete(s.overrides, override.ID)
+	}
+}
+
+var _ baseStoreImpl[QuizOverride] = (*QuizOverrideStore)(nil)
+
+// NewQuizOverrideStore creates a new instance of QuizOverrideStore.
+func NewQuizOverrideStore(
+	db *gosql.DB, table, eventTable string,
+) *QuizOverrideStore {
+	impl := &QuizOverrideStore{}
+	impl.baseStore = makeBaseStore[QuizOverride, QuizOverrideEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
+
+// QuizSchedule represents effective schedule of quiz for participant.
+type QuizSchedule struct {
+	// OpenTime contains time when quiz opens.
+	//
+	// Zero value means that quiz is open from the beginning.
+	OpenTime int64
+	// CloseTime contains time when quiz closes.
+	//
+	// Zero value means that quiz is never closed.
+	CloseTime int64
+	// Duration contains time limit of attempt in seconds.
+	//
+	// Zero value means that attempt is not limited by time.
+	Duration int64
+}
+
+// MakeQuizSchedule creates schedule from quiz config and
+// optional participant override.
+func MakeQuizSchedule(config QuizConfig, override *QuizOverride) QuizSchedule {
+	schedule := QuizSchedule{
+		OpenTime:  config.OpenTime,
+		CloseTime: config.CloseTime,
+		Duration:  config.Duration,
+	}
+	if override != nil {
+		if override.OpenTime != 0 {
+			schedule.OpenTime = int64(override.OpenTime)
+		}
+		if override.CloseTime != 0 {
+			schedule.CloseTime = int64(override.CloseTime)
+		}
+		if override.Duration != 0 {
+			schedule.Duration = int64(override.Duration)
+		}
+	}
+	return schedule
+}
+
+// IsOpen returns true if quiz is open at specified time.
+func (s QuizSchedule) IsOpen(now time.Time) bool {
+	if s.OpenTime != 0 && now.Unix() < s.OpenTime {
+		return false
+	}
+	return s.CloseTime == 0 || now.Unix() < s.CloseTime
+}
+
+// IsClosed returns true if quiz is closed at specified time.
+func (s QuizSchedule) IsClosed(now time.Time) bool {
+	return s.CloseTime != 0 && now.Unix() >= s.CloseTime
+}
+
+// Deadline returns deadline of attempt started at specified time.
+//
+// Attempt is cut off by time limit or by close time of quiz,
+// whichever comes first. Zero value means that there is no deadline.
+func (s QuizSchedule) Deadline(start time.Time) int64 {
+	var deadline int64
+	if s.Duration > 0 {
+		deadline = start.Unix() + s.Duration
+	}
+	if s.CloseTime != 0 && (deadline == 0 || s.CloseTime < deadline) {
+		deadline = s.CloseTime
+	}
+	return deadline
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type quizOverrideStoreTest struct{}
+
+func (t *quizOverrideStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "quiz_override" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"account_id" integer NOT NULL,` +
+			`"open_time" bigint NULL,` +
+			`"close_time" bigint NULL,` +
+			`"duration" bigint NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "quiz_override_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"account_id" integer NOT NULL,` +
+			`"open_time" bigint NULL,` +
+			`"close_time" bigint NULL,` +
+			`"duration" bigint NULL)`,
+	)
+	return err
+}
+
+func (t *quizOverrideStoreTest) newStore() Store {
+	return NewQuizOverrideStore(testDB, "quiz_override", "quiz_override_event")
+}
+
+func (t *quizOverrideStoreTest) newObject() Object {
+	return QuizOverride{
+		QuizID:    1,
+		AccountID: 2,
+		Duration:  7200,
+	}
+}
+
+func (t *quizOverrideStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(QuizOverride)
+	err := s.(*QuizOverrideStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *quizOverrideStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*QuizOverrideStore).Update(wrapContext(tx), o.(QuizOverride))
+}
+
+func (t *quizOverrideStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*QuizOverrideStore).Delete(wrapContext(tx), id)
+}
+
+func TestQuizOverrideStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&quizOverrideStoreTest{}}
+	tester.Test(t)
+}
+
+func TestQuizSchedule(t *testing.T) {
+	config := QuizConfig{OpenTime: 100, CloseTime: 1000, Duration: 300}
+	schedule := MakeQuizSchedule(config, nil)
+	if schedule.IsOpen(time.Unix(99, 0)) {
+		t.Fatal("Quiz should not be open before open time")
+	}
+	if !schedule.IsOpen(time.Unix(100, 0)) {
+		t.Fatal("Quiz should be open")
+	}
+	if schedule.IsOpen(time.Unix(1000, 0)) || !schedule.IsClosed(time.Unix(1000, 0)) {
+		t.Fatal("Quiz should be closed")
+	}
+	if deadline := schedule.Deadline(time.Unix(200, 0)); deadline != 500 {
+		t.Fatalf("Expected deadline %d, got %d", 500, deadline)
+	}
+	if deadline := schedule.Deadline(time.Unix(900, 0)); deadline != 1000 {
+		t.Fatalf("Expected deadline %d, got %d", 1000, deadline)
+	}
+	schedule = MakeQuizSchedule(config, &QuizOverride{
+		CloseTime: 2000, Duration: 600,
+	})
+	if schedule.OpenTime != 100 {
+		t.Fatalf("Expected open time %d, got %d", 100, schedule.OpenTime)
+	}
+	if !schedule.IsOpen(time.Unix(1500, 0)) {
+		t.Fatal("Quiz should be open")
+	}
+	if deadline := schedule.Deadline(time.Unix(1500, 0)); deadline != 2000 {
+		t.Fatalf("Expected deadline %d, got %d", 2000, deadline)
+	}
+	if deadline := (QuizSchedule{}).Deadline(time.Unix(1500, 0)); deadline != 0 {
+		t.Fatalf("Expected deadline %d, got %d", 0, deadline)
+	}
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/udovin/gosql"
+)
+
+// QuizParticipantRole represents role of participant in quiz.
+type QuizParticipantRole int
+
+const (
+	// RegularParticipant represents participant that can take quiz.
+	RegularParticipant QuizParticipantRole = 1
+	// ObserverParticipant represents participant that can observe
+	// quiz attempts and results.
+	ObserverParticipant QuizParticipantRole = 2
+	// ManagerParticipant represents participant that can manage quiz.
+	ManagerParticipant QuizParticipantRole = 3
+	// GraderParticipant represents participant that can grade
+	// answers manually.
+	GraderParticipant QuizParticipantRole = 4
+)
+
+// String returns string representation.
+func (r QuizParticipantRole) String() string {
+	switch r {
+	case RegularParticipant:
+		return "participant"
+	case ObserverParticipant:
+		return "observer"
+	case ManagerParticipant:
+		return "manager"
+	case GraderParticipant:
+		return "grader"
+	default:
+		return fmt.Sprintf("QuizParticipantRole(%d)", r)
+	}
+}
+
+// MarshalText marshals role to text.
+func (r QuizParticipantRole) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText unmarshals role from text.
+func (r *QuizParticipantRole) UnmarshalText(data []byte) error {
+	switch s := string(data); s {
+	case "participant":
+		*r = RegularParticipant
+	case "observer":
+		*r = ObserverParticipant
+	case "manager":
+		*r = ManagerParticipant
+	case "grader":
+		*r = GraderParticipant
+	default:
+		return fmt.Errorf("unsupported participant role: %q", s)
+	}
+	return nil
+}
+
+// QuizParticipant represents participant of quiz.
+type QuizParticipant struct {
+	baseObject
+	// QuizID contains ID of quiz.
+	QuizID int64 `db:"quiz_id"`
+	// AccountID contains ID of participant account.
+	AccountID int64 `db:"account_id"`
+	// Role contains role of participant.
+	Role QuizParticipantRole `db:"role"`
+}
+
+// Clone creates copy of quiz participant.
+func (o QuizParticipant) Clone() QuizParticipant {
+	return o
+}
+
+// QuizParticipantEvent represents a quiz participant event.
+type QuizParticipantEvent struct {
+	baseEvent
+	QuizParticipant
+}
+
+// Object returns event quiz participant.
+func (e QuizParticipantEvent) Object() QuizParticipant {
+	return e.QuizParticipant
+}
+
+// SetObject sets event quiz participant.
+func (e *QuizParticipantEvent) SetObject(o QuizParticipant) {
+	e.QuizParticipant = o
+}
+
+// QuizParticipantStore represents store for quiz participants.
+type QuizParticipantStore struct {
+	baseStore[
+		QuizParticipant, QuizParticipantEvent,
+		*QuizParticipant, *QuizParticipantEvent,
+	]
+	participants map[int64]QuizParticipant
+	byQuiz       index[int64]
+	byAccount    index[int64]
+}
+
+// Get returns quiz participant by ID.
+//
+// If there is no quiz participant with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *QuizParticipantStore) Get(id int64) (QuizParticipant, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if participant, ok := s.participants[id]; ok {
+		return participant.Clone(), nil
+	}
+	return QuizParticipant{}, sql.ErrNoRows
+}
+
+// FindByQuiz returns participants by quiz ID.
+func (s *QuizParticipantStore) FindByQuiz(
+	quizID int64,
+) ([]QuizParticipant, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var participants []QuizParticipant
+	for id := range s.byQuiz[quizID] {
+		if participant, ok := s.participants[id]; ok {
+			participants = append(participants, participant.Clone())
+		}
+	}
+	return participants, nil
+}
+
+// FindByAccount returns participants by account ID.
+func (s *QuizParticipantStore) FindByAccount(
+	accountID int64,
+) ([]QuizParticipant, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var participants []QuizParticipant
+	for id := range s.byAccount[accountID] {
+		if participant, ok := s.participants[id]; ok {
+			participants = append(participants, participant.Clone())
+		}
+	}
+	return participants, nil
+}
+
+// GetByQuizAccount returns participant of quiz by account ID.
+//
+// If there is no such participant then sql.ErrNoRows will be returned.
+func (s *QuizParticipantStore) GetByQuizAccount(
+	quizID, accountID int64,
+) (QuizParticipant, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for id := range s.byAccount[accountID] {
+		if participant, ok := s.participants[id]; ok &&
+			participant.QuizID == quizID {
+			return participant.Clone(), nil
+		}
+	}
+	return QuizParticipant{}, sql.ErrNoRows
+}
+
+func (s *QuizParticipantStore) reset() {
+	s.participants = map[int64]QuizParticipant{}
+	s.byQuiz = index[int64]{}
+	s.byAccount = index[int64]{}
+}
+
+func (s *QuizParticipantStore) onCreateObject(participant QuizParticipant) {
+	s.participants[participant.ID] = participant
+	s.byQuiz.Create(participant.QuizID, participant.ID)
+	s.byAccount.Create(participant.AccountID, participant.ID)
+}
+
+func (s *QuizParticipantStore) onDeleteObject(id int64) {
+	if participant, ok := s.participants[id]; ok {
+		s.byQuiz.Delete(participant.QuizID, participant.ID)
+		s.byAccount.Delete(participant.AccountID, participant.ID)
+		delete(s.participants, participant.ID)
+	}
+}
+
+var _ baseStoreImpl[QuizParticipant] = (*QuizParticipantStore)(nil)
+
+// NewQuizParticipantStore creates a new instance of QuizParticipantStore.
+func NewQuizParticipantStore(
+	db *gosql.DB, table, eventTable string,
+) *QuizParticipantStore {
+	impl := &QuizParticipantStore{}
+	impl.baseStore = makeBaseStore[QuizParticipant, QuizParticipantEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
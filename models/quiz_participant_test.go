@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type quizParticipantStoreTest struct{}
+
+func (t *quizParticipantStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "quiz_participant" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"account_id" integer NOT NULL,` +
+			`"role" integer NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "quiz_participant_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"account_id" integer NOT NULL,` +
+			`"role" integer NOT NULL)`,
+	)
+	return err
+}
+
+func (t *quizParticipantStoreTest) newStore() Store {
+	return NewQuizParticipantStore(
+		testDB, "quiz_participant", "quiz_participant_event",
+	)
+}
+
+func (t *quizParticipantStoreTest) newObject() Object {
+	return QuizParticipant{
+		QuizID:    1,
+		AccountID: 2,
+		Role:      RegularParticipant,
+	}
+}
+
+func (t *quizParticipantStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(QuizParticipant)
+	err := s.(*QuizParticipantStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *quizParticipantStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*QuizParticipantStore).Update(
+		wrapContext(tx), o.(QuizParticipant),
+	)
+}
+
+func (t *quizParticipantStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*QuizParticipantStore).Delete(wrapContext(tx), id)
+}
+
+func TestQuizParticipantStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&quizParticipantStoreTest{}}
+	tester.Test(t)
+}
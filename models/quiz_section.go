@@ -0,0 +1,151 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"github.com/udovin/gosql"
+)
+
+// QuizSectionConfig represents config of quiz section.
+type QuizSectionConfig struct {
+	// Problems contains IDs of fixed problems of section.
+	Problems []int64 `json:"problems,omitempty"`
+	// Scoring contains scoring policy of section.
+	//
+	// Zero policy means that scoring policy of quiz is used.
+	Scoring ScoringPolicy `json:"scoring,omitempty"`
+}
+
+// QuizSection represents a section of quiz.
+//
+// Section either contains list of fixed problems in config or
+// draws ProblemCount problems from pool with PoolID.
+type QuizSection struct {
+	baseObject
+	// QuizID contains ID of quiz.
+	QuizID int64 `db:"quiz_id"`
+	// Position contains position of section in quiz.
+	Position int64 `db:"position"`
+	// Title contains section title.
+	Title string `db:"title"`
+	// Points contains amount of points for every problem of section.
+	Points int64 `db:"points"`
+	// PoolID contains ID of pool for drawing problems.
+	PoolID NInt64 `db:"pool_id"`
+	// ProblemCount contains amount of problems drawn from pool.
+	ProblemCount int64 `db:"problem_count"`
+	// Config contains section config.
+	Config JSON `db:"config"`
+}
+
+// Clone creates copy of quiz section.
+func (o QuizSection) Clone() QuizSection {
+	o.Config = o.Config.Clone()
+	return o
+}
+
+// ScanConfig scans quiz section config.
+func (o QuizSection) ScanConfig(config *QuizSectionConfig) error {
+	if len(o.Config) == 0 {
+		*config = QuizSectionConfig{}
+		return nil
+	}
+	return json.Unmarshal(o.Config, config)
+}
+
+// SetConfig updates quiz section config.
+func (o *QuizSection) SetConfig(config QuizSectionConfig) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	o.Config = raw
+	return nil
+}
+
+// QuizSectionEvent represents a quiz section event.
+type QuizSectionEvent struct {
+	baseEvent
+	QuizSection
+}
+
+// Object returns event quiz section.
+func (e QuizSectionEvent) Object() QuizSection {
+	return e.QuizSection
+}
+
+// SetObject sets event quiz section.
+func (e *QuizSectionEvent) SetObject(o QuizSection) {
+	e.QuizSection = o
+}
+
+// QuizSectionStore represents store for quiz sections.
+type QuizSectionStore struct {
+	baseStore[QuizSection, QuizSectionEvent, *QuizSection, *QuizSectionEvent]
+	sections map[int64]QuizSection
+	byQuiz   index[int64]
+}
+
+// Get returns quiz section by ID.
+//
+// If there is no quiz section with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *QuizSectionStore) Get(id int64) (QuizSection, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if section, ok := s.sections[id]; ok {
+		return section.Clone(), nil
+	}
+	return QuizSection{}, sql.ErrNoRows
+}
+
+// FindByQuiz returns sections of quiz ordered by position.
+func (s *QuizSectionStore) FindByQuiz(quizID int64) ([]QuizSection, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var sections []QuizSection
+	for id := range s.byQuiz[quizID] {
+		if section, ok := s.sections[id]; ok {
+			sections = append(sections, section.Clone())
+		}
+	}
+	sort.Slice(sections, func(i, j int) bool {
+		if sections[i].Position != sections[j].Position {
+			return sections[i].Position < sections[j].Position
+		}
+		return sections[i].ID < sections[j].ID
+	})
+	return sections, nil
+}
+
+func (s *QuizSectionStore) reset() {
+	s.sections = map[int64]QuizSection{}
+	s.byQuiz = index[int64]{}
+}
+
+func (s *QuizSectionStore) onCreateObject(section QuizSection) {
+	s.sections[section.ID] = section
+	s.byQuiz.Create(section.QuizID, section.ID)
+}
+
+func (s *QuizSectionStore) onDeleteObject(id int64) {
+	if section, ok := s.sections[id]; ok {
+		s.byQuiz.Delete(section.QuizID, section.ID)
+		delete(s.sections, section.ID)
+	}
+}
+
+var _ baseStoreImpl[QuizSection] = (*QuizSectionStore)(nil)
+
+// NewQuizSectionStore creates a new instance of QuizSectionStore.
+func NewQuizSectionStore(
+	db *gosql.DB, table, eventTable string,
+) *QuizSectionStore {
+	impl := &QuizSectionStore{}
+	impl.baseStore = makeBaseStore[QuizSection, QuizSectionEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
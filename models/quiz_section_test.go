@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type quizSectionStoreTest struct{}
+
+func (t *quizSectionStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "quiz_section" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"position" integer NOT NULL,` +
+			`"title" varchar(255) NOT NULL,` +
+			`"points" integer NOT NULL,` +
+			`"pool_id" integer NULL,` +
+			`"problem_count" integer NOT NULL,` +
+			`"config" blob NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "quiz_section_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"quiz_id" integer NOT NULL,` +
+			`"position" integer NOT NULL,` +
+			`"title" varchar(255) NOT NULL,` +
+			`"points" integer NOT NULL,` +
+			`"pool_id" integer NULL,` +
+			`"problem_count" integer NOT NULL,` +
+			`"config" blob NOT NULL)`,
+	)
+	return err
+}
+
+func (t *quizSectionStoreTest) newStore() Store {
+	return NewQuizSectionStore(testDB, "quiz_section", "quiz_section_event")
+}
+
+func (t *quizSectionStoreTest) newObject() Object {
+	return QuizSection{
+		QuizID:   1,
+		Position: 1,
+		Title:    "Test",
+		Points:   2,
+		Config:   JSON(`{"problems":[1,2]}`),
+	}
+}
+
+func (t *quizSectionStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(QuizSection)
+	err := s.(*QuizSectionStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *quizSectionStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*QuizSectionStore).Update(wrapContext(tx), o.(QuizSection))
+}
+
+func (t *quizSectionStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*QuizSectionStore).Delete(wrapContext(tx), id)
+}
+
+func TestQuizSectionStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&quizSectionStoreTest{}}
+	tester.Test(t)
+}
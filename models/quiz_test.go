@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type quizStoreTest struct{}
+
+func (t *quizStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "quiz" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"owner_id" integer NULL,` +
+			`"title" varchar(255) NOT NULL,` +
+			`"description" text NOT NULL,` +
+			`"config" blob NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "quiz_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"owner_id" integer NULL,` +
+			`"title" varchar(255) NOT NULL,` +
+			`"description" text NOT NULL,` +
+			`"config" blob NOT NULL)`,
+	)
+	return err
+}
+
+func (t *quizStoreTest) newStore() Store {
+	return NewQuizStore(testDB, "quiz", "quiz_event")
+}
+
+func (t *quizStoreTest) newObject() Object {
+	return Quiz{
+		Title:       "Test",
+		Description: "Test description",
+		Config:      JSON(`{}`),
+	}
+}
+
+func (t *quizStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(Quiz)
+	err := s.(*QuizStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *quizStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*QuizStore).Update(wrapContext(tx), o.(Quiz))
+}
+
+func (t *quizStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*QuizStore).Delete(wrapContext(tx), id)
+}
+
+func TestQuizStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&quizStoreTest{}}
+	tester.Test(t)
+}
@@ -96,6 +96,99 @@ const (
 	UpdateProblemRole = "update_problem"
 	// DeleteProblemRole represents role for deleting problem.
 	DeleteProblemRole = "delete_problem"
+	// ObserveProblemRevisionsRole represents role for observing
+	// revision history of problem.
+	ObserveProblemRevisionsRole = "observe_problem_revisions"
+	// ObserveProblemStatisticsRole represents role for observing
+	// item statistics of problem.
+	ObserveProblemStatisticsRole = "observe_problem_statistics"
+	// ObservePoolsRole represents role for observing pool list.
+	ObservePoolsRole = "observe_pools"
+	// ObservePoolRole represents role for observing pool.
+	ObservePoolRole = "observe_pool"
+	// CreatePoolRole represents role for creating pool.
+	CreatePoolRole = "create_pool"
+	// UpdatePoolRole represents role for updating pool.
+	UpdatePoolRole = "update_pool"
+	// DeletePoolRole represents role for deleting pool.
+	DeletePoolRole = "delete_pool"
+	// ObservePoolProblemsRole represents role for observing
+	// pool problem list.
+	ObservePoolProblemsRole = "observe_pool_problems"
+	// CreatePoolProblemRole represents role for adding problem to pool.
+	CreatePoolProblemRole = "create_pool_problem"
+	// UpdatePoolProblemsRole represents role for reordering
+	// pool problems.
+	UpdatePoolProblemsRole = "update_pool_problems"
+	// DeletePoolProblemRole represents role for removing problem
+	// from pool.
+	DeletePoolProblemRole = "delete_pool_problem"
+	// ObserveQuizzesRole represents role for observing quiz list.
+	ObserveQuizzesRole = "observe_quizzes"
+	// ObserveQuizRole represents role for observing quiz.
+	ObserveQuizRole = "observe_quiz"
+	// ObserveQuizSectionsRole represents role for observing
+	// quiz sections.
+	ObserveQuizSectionsRole = "observe_quiz_sections"
+	// CreateQuizRole represents role for creating quiz.
+	CreateQuizRole = "create_quiz"
+	// UpdateQuizRole represents role for updating quiz.
+	UpdateQuizRole = "update_quiz"
+	// DeleteQuizRole represents role for deleting quiz.
+	DeleteQuizRole = "delete_quiz"
+	// ObserveQuizAttemptsRole represents role for observing
+	// all attempts of quiz.
+	ObserveQuizAttemptsRole = "observe_quiz_attempts"
+	// ObserveQuizAttemptRole represents role for observing
+	// quiz attempt.
+	ObserveQuizAttemptRole = "observe_quiz_attempt"
+	// CreateQuizAttemptRole represents role for starting quiz attempt.
+	CreateQuizAttemptRole = "create_quiz_attempt"
+	// UpdateQuizAttemptRole represents role for answering and
+	// finishing quiz attempt.
+	UpdateQuizAttemptRole = "update_quiz_attempt"
+	// ObserveQuizResultsRole represents role for observing
+	// full results of quiz.
+	ObserveQuizResultsRole = "observe_quiz_results"
+	// ObserveQuizLeaderboardRole represents role for observing
+	// public leaderboard of quiz.
+	ObserveQuizLeaderboardRole = "observe_quiz_leaderboard"
+	// ObserveQuizOverridesRole represents role for observing
+	// schedule overrides of quiz.
+	ObserveQuizOverridesRole = "observe_quiz_overrides"
+	// CreateQuizOverrideRole represents role for creating
+	// schedule override of quiz.
+	CreateQuizOverrideRole = "create_quiz_override"
+	// UpdateQuizOverrideRole represents role for updating
+	// schedule override of quiz.
+	UpdateQuizOverrideRole = "update_quiz_override"
+	// DeleteQuizOverrideRole represents role for deleting
+	// schedule override of quiz.
+	DeleteQuizOverrideRole = "delete_quiz_override"
+	// ObserveQuizParticipantsRole represents role for observing
+	// participants of quiz.
+	ObserveQuizParticipantsRole = "observe_quiz_participants"
+	// CreateQuizParticipantRole represents role for inviting
+	// participant to quiz.
+	CreateQuizParticipantRole = "create_quiz_participant"
+	// DeleteQuizParticipantRole represents role for removing
+	// participant from quiz.
+	DeleteQuizParticipantRole = "delete_quiz_participant"
+	// GradeQuizAttemptsRole represents role for manual grading of
+	// answers of quiz attempts.
+	GradeQuizAttemptsRole = "grade_quiz_attempts"
+	// ObserveQuizStatisticsRole represents role for observing
+	// item statistics of quiz problems.
+	ObserveQuizStatisticsRole = "observe_quiz_statistics"
+	// ObserveQuizProctoringRole represents role for observing
+	// proctoring events of quiz attempts.
+	ObserveQuizProctoringRole = "observe_quiz_proctoring"
+	// ObserveFileRole represents role for observing file.
+	ObserveFileRole = "observe_file"
+	// CreateFileRole represents role for uploading file.
+	CreateFileRole = "create_file"
+	// DeleteFileRole represents role for deleting file.
+	DeleteFileRole = "delete_file"
 	// ObserveCompilersRole represents role for observing compiler list.
 	ObserveCompilersRole = "observe_compilers"
 	// ObserveCompilerRole represents role for observing compiler.
@@ -200,6 +293,42 @@ var builtInRoles = map[string]struct{}{
 	CreateProblemRole:              {},
 	UpdateProblemRole:              {},
 	DeleteProblemRole:              {},
+	ObserveProblemRevisionsRole:    {},
+	ObserveProblemStatisticsRole:   {},
+	ObservePoolsRole:               {},
+	ObservePoolRole:                {},
+	CreatePoolRole:                 {},
+	UpdatePoolRole:                 {},
+	DeletePoolRole:                 {},
+	ObservePoolProblemsRole:        {},
+	CreatePoolProblemRole:          {},
+	UpdatePoolProblemsRole:         {},
+	DeletePoolProblemRole:          {},
+	ObserveQuizzesRole:             {},
+	ObserveQuizRole:                {},
+	ObserveQuizSectionsRole:        {},
+	CreateQuizRole:                 {},
+	UpdateQuizRole:                 {},
+	DeleteQuizRole:                 {},
+	ObserveQuizAttemptsRole:        {},
+	ObserveQuizAttemptRole:         {},
+	CreateQuizAttemptRole:          {},
+	UpdateQuizAttemptRole:          {},
+	ObserveQuizResultsRole:         {},
+	ObserveQuizLeaderboardRole:     {},
+	ObserveQuizOverridesRole:       {},
+	CreateQuizOverrideRole:         {},
+	UpdateQuizOverrideRole:         {},
+	DeleteQuizOverrideRole:         {},
+	ObserveQuizParticipantsRole:    {},
+	CreateQuizParticipantRole:      {},
+	DeleteQuizParticipantRole:      {},
+	GradeQuizAttemptsRole:          {},
+	ObserveQuizStatisticsRole:      {},
+	ObserveQuizProctoringRole:      {},
+	ObserveFileRole:                {},
+	CreateFileRole:                 {},
+	DeleteFileRole:                 {},
 	ObserveCompilersRole:           {},
 	ObserveCompilerRole:            {},
 	CreateCompilerRole:             {},
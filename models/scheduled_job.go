@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+)
+
+// ScheduledJob represents last run of recurring job.
+type ScheduledJob struct {
+	baseObject
+	// Name contains unique name of job.
+	Name string `db:"name"`
+	// LastTime contains time of last claimed tick of job schedule.
+	LastTime int64 `db:"last_time"`
+}
+
+// ScheduledJobStore represents store for runs of recurring jobs.
+//
+// Runs are not cached in memory, since they are shared between
+// servers and should be always up to date.
+type ScheduledJobStore struct {
+	db      *gosql.DB
+	table   string
+	objects db.ObjectStore[ScheduledJob, *ScheduledJob]
+}
+
+// GetByName returns run of job with specified name.
+//
+// If there is no run of job with specified name then
+// sql.ErrNoRows will be returned.
+func (s *ScheduledJobStore) GetByName(
+	ctx context.Context, name string,
+) (ScheduledJob, error) {
+	rows, err := s.objects.FindObjects(ctx, gosql.Column("name").Equal(name))
+	if err != nil {
+		return ScheduledJob{}, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return ScheduledJob{}, err
+		}
+		return ScheduledJob{}, sql.ErrNoRows
+	}
+	return rows.Row(), nil
+}
+
+// Create creates a new run of job.
+func (s *ScheduledJobStore) Create(
+	ctx context.Context, job *ScheduledJob,
+) error {
+	return s.objects.CreateObject(ctx, job)
+}
+
+// Claim sets time of last run of job to specified tick.
+//
+// Tick is claimed only when it is after last run of job, so among
+// several servers sharing database only one will claim each tick.
+// Returns false if tick is already claimed.
+func (s *ScheduledJobStore) Claim(
+	ctx context.Context, name string, tick int64,
+) (bool, error) {
+	builder := s.db.Update(s.table)
+	builder.SetNames("last_time")
+	builder.SetValues(tick)
+	builder.SetWhere(gosql.Column("name").Equal(name).And(
+		gosql.Column("last_time").Less(tick),
+	))
+	query, values := builder.Build()
+	result, err := db.GetRunner(ctx, s.db).ExecContext(ctx, query, values...)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// NewScheduledJobStore creates a new instance of ScheduledJobStore.
+func NewScheduledJobStore(
+	dbConn *gosql.DB, table string,
+) *ScheduledJobStore {
+	return &ScheduledJobStore{
+		db:      dbConn,
+		table:   table,
+		objects: db.NewObjectStore[ScheduledJob]("id", table, dbConn),
+	}
+}
@@ -35,6 +35,11 @@ func (o Session) Clone() Session {
 	return o
 }
 
+// IsExpired returns true if session is expired at specified time.
+func (o Session) IsExpired(now time.Time) bool {
+	return now.Unix() >= o.ExpireTime
+}
+
 // GenerateSecret generates a new value for session secret.
 func (o *Session) GenerateSecret() error {
 	bytes := make([]byte, 40)
@@ -100,6 +105,8 @@ func (s *SessionStore) FindByAccount(id int64) ([]Session, error) {
 }
 
 // GetByCookie returns session for specified cookie value.
+//
+// Expired sessions are not returned, even if they are not deleted yet.
 func (s *SessionStore) GetByCookie(cookie string) (Session, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -109,12 +116,25 @@ func (s *SessionStore) GetByCookie(cookie string) (Session, error) {
 		return Session{}, err
 	}
 	session, ok := s.sessions[id]
-	if !ok || session.Secret != parts[1] {
+	if !ok || session.Secret != parts[1] || session.IsExpired(time.Now()) {
 		return Session{}, sql.ErrNoRows
 	}
 	return session.Clone(), nil
 }
 
+// FindExpired returns sessions that are expired at specified time.
+func (s *SessionStore) FindExpired(now time.Time) ([]Session, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var sessions []Session
+	for _, session := range s.sessions {
+		if session.IsExpired(now) {
+			sessions = append(sessions, session.Clone())
+		}
+	}
+	return sessions, nil
+}
+
 func (s *SessionStore) reset() {
 	s.sessions = map[int64]Session{}
 	s.byAccount = index[int64]{}
@@ -1,8 +1,10 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"testing"
+	"time"
 )
 
 type sessionStoreTest struct{}
@@ -71,3 +73,52 @@ func TestSessionStore(t *testing.T) {
 	tester := StoreTester{&sessionStoreTest{}}
 	tester.Test(t)
 }
+
+func TestSessionStoreExpired(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := (&sessionStoreTest{}).prepareDB(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal("Error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := NewSessionStore(testDB, "session", "session_event")
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	now := time.Now()
+	sessions := []Session{
+		{Secret: "active", ExpireTime: now.Add(time.Hour).Unix()},
+		{Secret: "expired", ExpireTime: now.Add(-time.Hour).Unix()},
+	}
+	for i := range sessions {
+		if err := store.Create(ctx, &sessions[i]); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	if err := store.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := store.GetByCookie(sessions[0].Cookie().Value); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if _, err := store.GetByCookie(
+		sessions[1].Cookie().Value,
+	); err != sql.ErrNoRows {
+		t.Fatalf("Expected %v, got %v", sql.ErrNoRows, err)
+	}
+	expired, err := store.FindExpired(now)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(expired) != 1 || expired[0].ID != sessions[1].ID {
+		t.Fatalf("Unexpected sessions: %v", expired)
+	}
+}
@@ -0,0 +1,425 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+)
+
+// TaskStatus represents status of task.
+type TaskStatus int
+
+const (
+	// QueuedTask represents task that waits for worker.
+	QueuedTask TaskStatus = 1
+	// RunningTask represents task that is processed by worker.
+	RunningTask TaskStatus = 2
+	// SucceededTask represents task that is processed with success.
+	SucceededTask TaskStatus = 3
+	// FailedTask represents task that is failed after all retries.
+	FailedTask TaskStatus = 4
+)
+
+// String returns string representation.
+func (s TaskStatus) String() string {
+	switch s {
+	case QueuedTask:
+		return "queued"
+	case RunningTask:
+		return "running"
+	case SucceededTask:
+		return "succeeded"
+	case FailedTask:
+		return "failed"
+	default:
+		return fmt.Sprintf("TaskStatus(%d)", s)
+	}
+}
+
+// MarshalText marshals status to text.
+func (s TaskStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText unmarshals status from text.
+func (s *TaskStatus) UnmarshalText(data []byte) error {
+	switch v := string(data); v {
+	case "queued":
+		*s = QueuedTask
+	case "running":
+		*s = RunningTask
+	case "succeeded":
+		*s = SucceededTask
+	case "failed":
+		*s = FailedTask
+	default:
+		return fmt.Errorf("unsupported status: %q", v)
+	}
+	return nil
+}
+
+// TaskKind represents kind of task.
+//
+// Every kind of task is processed by handler registered for it.
+type TaskKind int
+
+const (
+	// CleanupSessionsTask represents task that deletes expired sessions.
+	CleanupSessionsTask TaskKind = 1
+	// CleanupVisitsTask represents task that aggregates and deletes
+	// old visits.
+	CleanupVisitsTask TaskKind = 2
+	// CleanupTasksTask represents task that deletes old finished tasks.
+	CleanupTasksTask TaskKind = 3
+)
+
+// String returns string representation.
+func (k TaskKind) String() string {
+	switch k {
+	case CleanupSessionsTask:
+		return "cleanup_sessions"
+	case CleanupVisitsTask:
+		return "cleanup_visits"
+	case CleanupTasksTask:
+		return "cleanup_tasks"
+	default:
+		return fmt.Sprintf("TaskKind(%d)", k)
+	}
+}
+
+// MarshalText marshals kind to text.
+func (k TaskKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText unmarshals kind from text.
+func (k *TaskKind) UnmarshalText(data []byte) error {
+	switch v := string(data); v {
+	case "cleanup_sessions":
+		*k = CleanupSessionsTask
+	case "cleanup_visits":
+		*k = CleanupVisitsTask
+	case "cleanup_tasks":
+		*k = CleanupTasksTask
+	default:
+		return fmt.Errorf("unsupported kind: %q", v)
+	}
+	return nil
+}
+
+// MaxTaskAttempts contains maximal amount of attempts of task.
+const MaxTaskAttempts = 5
+
+// TaskState represents state of task processing.
+type TaskState struct {
+	// Attempts contains amount of times when task was claimed by
+	// workers.
+	Attempts int `json:"attempts,omitempty"`
+	// Error contains error of last failed attempt.
+	Error string `json:"error,omitempty"`
+}
+
+// Task represents background task.
+//
+// Meaning of ExpireTime depends on status of task. For queued tasks
+// it contains time before which task should not be started, that is
+// used for delaying retries. For running tasks it contains time when
+// lease of worker expires, after that task can be claimed again. For
+// succeeded and failed tasks it contains time when task was finished.
+type Task struct {
+	baseObject
+	// Status contains status of task.
+	Status TaskStatus `db:"status"`
+	// Kind contains kind of task.
+	Kind TaskKind `db:"kind"`
+	// Config contains config of task.
+	Config JSON `db:"config"`
+	// State contains state of task processing.
+	State JSON `db:"state"`
+	// ExpireTime contains time of task lease or retry.
+	ExpireTime int64 `db:"expire_time"`
+}
+
+// Clone creates copy of task.
+func (o Task) Clone() Task {
+	o.Config = o.Config.Clone()
+	o.State = o.State.Clone()
+	return o
+}
+
+// ScanConfig scans config of task.
+func (o Task) ScanConfig(config any) error {
+	return json.Unmarshal(o.Config, config)
+}
+
+// SetConfig updates config of task.
+func (o *Task) SetConfig(config any) error {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	o.Config = raw
+	return nil
+}
+
+// ScanState scans state of task.
+//
+// State is not scanned for tasks that were never claimed.
+func (o Task) ScanState(state *TaskState) error {
+	if len(o.State) == 0 {
+		*state = TaskState{}
+		return nil
+	}
+	return json.Unmarshal(o.State, state)
+}
+
+// SetState updates state of task.
+func (o *Task) SetState(state TaskState) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	o.State = raw
+	return nil
+}
+
+// TaskEvent represents task event.
+type TaskEvent struct {
+	baseEvent
+	Task
+}
+
+// Object returns event task.
+func (e TaskEvent) Object() Task {
+	return e.Task
+}
+
+// SetObject sets event task.
+func (e *TaskEvent) SetObject(o Task) {
+	e.Task = o
+}
+
+// TaskStore represents store for tasks.
+type TaskStore struct {
+	baseStore[Task, TaskEvent, *Task, *TaskEvent]
+	tasks    map[int64]Task
+	byStatus index[TaskStatus]
+}
+
+// Get returns task by ID.
+//
+// If there is no task with specified ID then
+// sql.ErrNoRows will be returned.
+func (s *TaskStore) Get(id int64) (Task, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if task, ok := s.tasks[id]; ok {
+		return task.Clone(), nil
+	}
+	return Task{}, sql.ErrNoRows
+}
+
+// FindByStatus returns tasks with specified status.
+func (s *TaskStore) FindByStatus(status TaskStatus) ([]Task, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var tasks []Task
+	for id := range s.byStatus[status] {
+		if task, ok := s.tasks[id]; ok {
+			tasks = append(tasks, task.Clone())
+		}
+	}
+	return tasks, nil
+}
+
+// FindFinished returns succeeded and failed tasks that were finished
+// before specified time.
+func (s *TaskStore) FindFinished(before time.Time) ([]Task, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var tasks []Task
+	for _, status := range []TaskStatus{SucceededTask, FailedTask} {
+		for id := range s.byStatus[status] {
+			if task := s.tasks[id]; task.ExpireTime < before.Unix() {
+				tasks = append(tasks, task.Clone())
+			}
+		}
+	}
+	return tasks, nil
+}
+
+// PopQueued claims task for processing and sets running status.
+//
+// Queued tasks that are not delayed and running tasks with expired
+// lease are claimed in order of creation. Lease of claimed task
+// expires after specified duration, so workers should extend it
+// while task is processed. Attempts of task is incremented on every
+// claim, and running tasks with expired lease that reached maximal
+// amount of attempts are marked as failed instead of being claimed,
+// so tasks that crash workers are not retried forever.
+//
+// Store is locked until end of transaction, so the same task can not
+// be claimed by several workers. If there is no task to claim then
+// sql.ErrNoRows will be returned.
+func (s *TaskStore) PopQueued(
+	ctx context.Context, lease time.Duration, filter func(TaskKind) bool,
+) (Task, error) {
+	tx := db.GetTx(ctx)
+	if tx == nil {
+		var task Task
+		err := gosql.WrapTx(ctx, s.db, func(tx *sql.Tx) (err error) {
+			task, err = s.PopQueued(db.WithTx(ctx, tx), lease, filter)
+			return err
+		}, sqlRepeatableRead)
+		return task, err
+	}
+	if err := s.lockStore(tx); err != nil {
+		return Task{}, err
+	}
+	if err := s.Sync(ctx); err != nil {
+		return Task{}, err
+	}
+	now := time.Now()
+	for {
+		task, ok := s.findClaimable(now, filter)
+		if !ok {
+			return Task{}, sql.ErrNoRows
+		}
+		var state TaskState
+		if err := task.ScanState(&state); err != nil {
+			return Task{}, err
+		}
+		if task.Status != RunningTask || state.Attempts < MaxTaskAttempts {
+			return s.claimTask(ctx, task, state, now.Add(lease))
+		}
+		// For failed tasks ExpireTime contains finish time.
+		state.Error = "task lease expired"
+		if err := task.SetState(state); err != nil {
+			return Task{}, err
+		}
+		task.Status = FailedTask
+		task.ExpireTime = now.Unix()
+		if err := s.Update(ctx, task); err != nil {
+			return Task{}, err
+		}
+		if err := s.Sync(ctx); err != nil {
+			return Task{}, err
+		}
+	}
+}
+
+func (s *TaskStore) claimTask(
+	ctx context.Context, task Task, state TaskState, expire time.Time,
+) (Task, error) {
+	state.Attempts++
+	if err := task.SetState(state); err != nil {
+		return Task{}, err
+	}
+	task.Status = RunningTask
+	task.ExpireTime = expire.Unix()
+	if err := s.Update(ctx, task); err != nil {
+		return Task{}, err
+	}
+	return task, nil
+}
+
+// ErrTaskLeaseLost represents error of updating task with lost lease.
+var ErrTaskLeaseLost = fmt.Errorf("task lease lost")
+
+// UpdateLeased updates running task if its lease is still held.
+//
+// Lease is held when task is still running and its ExpireTime equals
+// to leaseTime that was set by holder of lease. Otherwise task was
+// claimed again by another worker and ErrTaskLeaseLost is returned.
+// Store is locked until end of transaction, so lease can not be lost
+// while task is updated.
+func (s *TaskStore) UpdateLeased(
+	ctx context.Context, task Task, leaseTime int64,
+) error {
+	tx := db.GetTx(ctx)
+	if tx == nil {
+		return gosql.WrapTx(ctx, s.db, func(tx *sql.Tx) error {
+			return s.UpdateLeased(db.WithTx(ctx, tx), task, leaseTime)
+		}, sqlRepeatableRead)
+	}
+	if err := s.lockStore(tx); err != nil {
+		return err
+	}
+	if err := s.Sync(ctx); err != nil {
+		return err
+	}
+	current, err := s.Get(task.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrTaskLeaseLost
+		}
+		return err
+	}
+	if current.Status != RunningTask || current.ExpireTime != leaseTime {
+		return ErrTaskLeaseLost
+	}
+	return s.Update(ctx, task)
+}
+
+func (s *TaskStore) findClaimable(
+	now time.Time, filter func(TaskKind) bool,
+) (Task, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var tasks []Task
+	for id := range s.byStatus[QueuedTask] {
+		if task := s.tasks[id]; task.ExpireTime <= now.Unix() {
+			tasks = append(tasks, task)
+		}
+	}
+	for id := range s.byStatus[RunningTask] {
+		if task := s.tasks[id]; task.ExpireTime < now.Unix() {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	for _, task := range tasks {
+		if filter(task.Kind) {
+			return task.Clone(), true
+		}
+	}
+	return Task{}, false
+}
+
+func (s *TaskStore) reset() {
+	s.tasks = map[int64]Task{}
+	s.byStatus = index[TaskStatus]{}
+}
+
+func (s *TaskStore) onCreateObject(task Task) {
+	s.tasks[task.ID] = task
+	s.byStatus.Create(task.Status, task.ID)
+}
+
+func (s *TaskStore) onDeleteObject(id int64) {
+	if task, ok := s.tasks[id]; ok {
+		s.byStatus.Delete(task.Status, task.ID)
+		delete(s.tasks, task.ID)
+	}
+}
+
+var _ baseStoreImpl[Task] = (*TaskStore)(nil)
+
+// NewTaskStore creates a new instance of TaskStore.
+func NewTaskStore(
+	db *gosql.DB, table, eventTable string,
+) *TaskStore {
+	impl := &TaskStore{}
+	impl.baseStore = makeBaseStore[Task, TaskEvent](
+		db, table, eventTable, impl,
+	)
+	return impl
+}
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+type taskStoreTest struct{}
+
+func (t *taskStoreTest) prepareDB(tx *sql.Tx) error {
+	if _, err := tx.Exec(
+		`CREATE TABLE "task" (` +
+			`"id" integer PRIMARY KEY,` +
+			`"status" integer NOT NULL,` +
+			`"kind" integer NOT NULL,` +
+			`"config" blob NOT NULL,` +
+			`"state" blob NOT NULL,` +
+			`"expire_time" integer NOT NULL)`,
+	); err != nil {
+		return err
+	}
+	_, err := tx.Exec(
+		`CREATE TABLE "task_event" (` +
+			`"event_id" integer PRIMARY KEY,` +
+			`"event_kind" int8 NOT NULL,` +
+			`"event_time" bigint NOT NULL,` +
+			`"event_account_id" integer NULL,` +
+			`"id" integer NOT NULL,` +
+			`"status" integer NOT NULL,` +
+			`"kind" integer NOT NULL,` +
+			`"config" blob NOT NULL,` +
+			`"state" blob NOT NULL,` +
+			`"expire_time" integer NOT NULL)`,
+	)
+	return err
+}
+
+func (t *taskStoreTest) newStore() Store {
+	return NewTaskStore(testDB, "task", "task_event")
+}
+
+func (t *taskStoreTest) newObject() Object {
+	return Task{
+		Status: QueuedTask,
+		Kind:   1,
+		Config: JSON(`{"quiz_id":1}`),
+		State:  JSON(`{"attempts":1}`),
+	}
+}
+
+func (t *taskStoreTest) createObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	object := o.(Task)
+	err := s.(*TaskStore).Create(wrapContext(tx), &object)
+	return object, err
+}
+
+func (t *taskStoreTest) updateObject(
+	s Store, tx *sql.Tx, o Object,
+) (Object, error) {
+	return o, s.(*TaskStore).Update(wrapContext(tx), o.(Task))
+}
+
+func (t *taskStoreTest) deleteObject(
+	s Store, tx *sql.Tx, id int64,
+) error {
+	return s.(*TaskStore).Delete(wrapContext(tx), id)
+}
+
+func TestTaskStore(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tester := StoreTester{&taskStoreTest{}}
+	tester.Test(t)
+}
+
+func TestTaskStorePopQueued(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := (&taskStoreTest{}).prepareDB(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal("Error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := NewTaskStore(testDB, "task", "task_event")
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	now := time.Now()
+	tasks := []Task{
+		{Status: QueuedTask, Kind: 1, ExpireTime: now.Add(time.Hour).Unix()},
+		{Status: RunningTask, Kind: 1, ExpireTime: now.Add(time.Hour).Unix()},
+		{Status: QueuedTask, Kind: 2},
+		{Status: RunningTask, Kind: 1, ExpireTime: now.Add(-time.Minute).Unix()},
+		{Status: SucceededTask, Kind: 1},
+	}
+	for i := range tasks {
+		if err := store.Create(ctx, &tasks[i]); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	all := func(TaskKind) bool { return true }
+	// Delayed tasks and tasks with active lease are skipped.
+	task, err := store.PopQueued(ctx, time.Minute, all)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if task.ID != tasks[2].ID || task.Status != RunningTask {
+		t.Fatalf("Unexpected task: %+v", task)
+	}
+	// Tasks with expired lease are claimed again.
+	task, err = store.PopQueued(ctx, time.Minute, func(kind TaskKind) bool {
+		return kind == 1
+	})
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if task.ID != tasks[3].ID || task.ExpireTime < now.Unix() {
+		t.Fatalf("Unexpected task: %+v", task)
+	}
+	var state TaskState
+	if err := task.ScanState(&state); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if state.Attempts != 1 {
+		t.Fatalf("Expected 1 attempt, got %d", state.Attempts)
+	}
+	if _, err := store.PopQueued(ctx, time.Minute, all); err != sql.ErrNoRows {
+		t.Fatalf("Expected %v, got %v", sql.ErrNoRows, err)
+	}
+	if err := store.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	running, err := store.FindByStatus(RunningTask)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(running) != 3 {
+		t.Fatalf("Expected 3 running tasks, got %d", len(running))
+	}
+}
+
+func TestTaskStorePopQueuedExpired(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := (&taskStoreTest{}).prepareDB(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal("Error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := NewTaskStore(testDB, "task", "task_event")
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	task := Task{Status: QueuedTask, Kind: 1}
+	if err := store.Create(ctx, &task); err != nil {
+		t.Fatal("Error:", err)
+	}
+	all := func(TaskKind) bool { return true }
+	// Lease of every claim is already expired, as if worker crashed.
+	for i := 0; i < MaxTaskAttempts; i++ {
+		claimed, err := store.PopQueued(ctx, -time.Minute, all)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		if claimed.ID != task.ID {
+			t.Fatalf("Unexpected task: %+v", claimed)
+		}
+	}
+	if _, err := store.PopQueued(ctx, -time.Minute, all); err != sql.ErrNoRows {
+		t.Fatalf("Expected %v, got %v", sql.ErrNoRows, err)
+	}
+	if err := store.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	failed, err := store.Get(task.ID)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if failed.Status != FailedTask {
+		t.Fatalf("Expected %v, got %v", FailedTask, failed.Status)
+	}
+	var state TaskState
+	if err := failed.ScanState(&state); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if state.Attempts != MaxTaskAttempts || state.Error == "" {
+		t.Fatalf("Unexpected state: %+v", state)
+	}
+}
+
+func TestTaskStatus(t *testing.T) {
+	for _, status := range []TaskStatus{
+		QueuedTask, RunningTask, SucceededTask, FailedTask,
+	} {
+		data, err := status.MarshalText()
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		var parsed TaskStatus
+		if err := parsed.UnmarshalText(data); err != nil {
+			t.Fatal("Error:", err)
+		}
+		if parsed != status {
+			t.Fatalf("Expected %v, got %v", status, parsed)
+		}
+	}
+	if s := TaskStatus(-1).String(); s != "TaskStatus(-1)" {
+		t.Fatalf("Expected %q, got %q", "TaskStatus(-1)", s)
+	}
+	var status TaskStatus
+	if err := status.UnmarshalText([]byte("unknown")); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestTaskStoreUpdateLeased(t *testing.T) {
+	testSetup(t)
+	defer testTeardown(t)
+	tx, err := testDB.Begin()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := (&taskStoreTest{}).prepareDB(tx); err != nil {
+		_ = tx.Rollback()
+		t.Fatal("Error:", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	store := NewTaskStore(testDB, "task", "task_event")
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := store.Create(ctx, &Task{Status: QueuedTask, Kind: 1}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	all := func(TaskKind) bool { return true }
+	task, err := store.PopQueued(ctx, time.Minute, all)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	leaseTime := task.ExpireTime
+	task.ExpireTime += 10
+	if err := store.UpdateLeased(ctx, task, leaseTime); err != nil {
+		t.Fatal("Error:", err)
+	}
+	// Lease is lost after it is extended by another holder.
+	task.Status = SucceededTask
+	if err := store.UpdateLeased(ctx, task, leaseTime); err != ErrTaskLeaseLost {
+		t.Fatalf("Expected %v, got %v", ErrTaskLeaseLost, err)
+	}
+	if err := store.Sync(ctx); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if task, err := store.Get(task.ID); err != nil {
+		t.Fatal("Error:", err)
+	} else if task.Status != RunningTask {
+		t.Fatalf("Expected %v, got %v", RunningTask, task.Status)
+	}
+}
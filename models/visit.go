@@ -12,18 +12,18 @@ import (
 
 // Visit represents user visit.
 type Visit struct {
-	ID         int64  `db:"id"`
-	Time       int64  `db:"time"`
-	AccountID  NInt64 `db:"account_id"`
-	SessionID  NInt64 `db:"session_id"`
-	Host       string `db:"host"`
-	Protocol   string `db:"protocol"`
-	Method     string `db:"method" `
-	RemoteAddr string `db:"remote_addr"`
-	UserAgent  string `db:"user_agent"`
-	Path       string `db:"path"`
-	RealIP     string `db:"real_ip"`
-	Status     int    `db:"status"`
+	ID         int64  `db:"id" json:"id"`
+	Time       int64  `db:"time" json:"time"`
+	AccountID  NInt64 `db:"account_id" json:"account_id,omitempty"`
+	SessionID  NInt64 `db:"session_id" json:"session_id,omitempty"`
+	Host       string `db:"host" json:"host"`
+	Protocol   string `db:"protocol" json:"protocol"`
+	Method     string `db:"method" json:"method"`
+	RemoteAddr string `db:"remote_addr" json:"remote_addr"`
+	UserAgent  string `db:"user_agent" json:"user_agent"`
+	Path       string `db:"path" json:"path"`
+	RealIP     string `db:"real_ip" json:"real_ip"`
+	Status     int    `db:"status" json:"status"`
 }
 
 // EventID returns ID of visit.
@@ -44,6 +44,7 @@ func (o Visit) EventTime() time.Time {
 // VisitStore represents visit store.
 type VisitStore struct {
 	db     *gosql.DB
+	table  string
 	events db.EventStore[Visit, *Visit]
 }
 
@@ -66,10 +67,59 @@ func (s *VisitStore) Create(ctx context.Context, visit *Visit) error {
 	return s.events.CreateEvent(ctx, visit)
 }
 
+// FindBefore returns visits that occurred before specified time
+// ordered by ID.
+//
+// At most limit visits are returned.
+func (s *VisitStore) FindBefore(
+	ctx context.Context, before time.Time, limit int,
+) ([]Visit, error) {
+	builder := s.db.Select(s.table)
+	builder.SetNames(getEventColumns[Visit]()...)
+	builder.SetWhere(gosql.Column("time").Less(before.Unix()))
+	builder.SetOrderBy(gosql.Ascending("id"))
+	builder.SetLimit(limit)
+	query, values := builder.Build()
+	rows, err := db.GetRunner(ctx, s.db).QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var visits []Visit
+	for rows.Next() {
+		var visit Visit
+		if err := rows.Scan(getEventFields(&visit)...); err != nil {
+			return nil, err
+		}
+		visits = append(visits, visit)
+	}
+	return visits, rows.Err()
+}
+
+// DeleteBefore deletes visits that occurred before specified time
+// with ID not greater than lastID.
+//
+// Visits with greater ID are kept, so visits returned by FindBefore
+// can be deleted without touching visits created after that.
+func (s *VisitStore) DeleteBefore(
+	ctx context.Context, before time.Time, lastID int64,
+) error {
+	builder := s.db.Delete(s.table)
+	builder.SetWhere(gosql.Column("time").Less(before.Unix()).And(
+		gosql.Column("id").LessEqual(lastID),
+	))
+	query, values := builder.Build()
+	_, err := db.GetRunner(ctx, s.db).ExecContext(ctx, query, values...)
+	return err
+}
+
 // NewVisitStore creates a new instance of ViewStore.
 func NewVisitStore(dbConn *gosql.DB, table string) *VisitStore {
 	return &VisitStore{
 		db:     dbConn,
+		table:  table,
 		events: db.NewEventStore[Visit]("id", table, dbConn),
 	}
 }
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+
+	"github.com/udovin/gosql"
+	"github.com/udovin/solve/db"
+)
+
+// VisitRollup represents amount of visits aggregated by hour.
+type VisitRollup struct {
+	baseObject
+	// Time contains start of hour of visits.
+	Time int64 `db:"time"`
+	// AccountID contains ID of account of visits.
+	AccountID NInt64 `db:"account_id"`
+	// Path contains path of visits without query.
+	Path string `db:"path"`
+	// Status contains response status of visits.
+	Status int `db:"status"`
+	// Count contains amount of visits.
+	Count int64 `db:"count"`
+}
+
+// VisitRollupStore represents store for aggregated visits.
+//
+// Rollups are not cached in memory and are loaded from database on
+// every request.
+type VisitRollupStore struct {
+	db      *gosql.DB
+	objects db.ObjectStore[VisitRollup, *VisitRollup]
+}
+
+// Add adds amount of visits to rollup with the same time, account,
+// path and status.
+//
+// Rollup is created if there is no such rollup. Add should be called
+// inside transaction, otherwise concurrent calls can create
+// duplicate rollups.
+func (s *VisitRollupStore) Add(
+	ctx context.Context, rollup VisitRollup,
+) error {
+	var account any
+	if rollup.AccountID != 0 {
+		account = int64(rollup.AccountID)
+	}
+	rows, err := s.objects.FindObjects(ctx, gosql.Column("time").
+		Equal(rollup.Time).
+		And(gosql.Column("account_id").Equal(account)).
+		And(gosql.Column("path").Equal(rollup.Path)).
+		And(gosql.Column("status").Equal(rollup.Status)),
+	)
+	if err != nil {
+		return err
+	}
+	found := rows.Next()
+	if found {
+		count := rollup.Count
+		rollup = rows.Row()
+		rollup.Count += count
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if found {
+		return s.objects.UpdateObject(ctx, &rollup)
+	}
+	return s.objects.CreateObject(ctx, &rollup)
+}
+
+// FindByTime returns rollups with time in range [begin, end) ordered
+// by ID.
+func (s *VisitRollupStore) FindByTime(
+	ctx context.Context, begin, end int64,
+) ([]VisitRollup, error) {
+	rows, err := s.objects.FindObjects(ctx, gosql.Column("time").
+		GreaterEqual(begin).And(gosql.Column("time").Less(end)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var rollups []VisitRollup
+	for rows.Next() {
+		rollups = append(rollups, rows.Row())
+	}
+	return rollups, rows.Err()
+}
+
+// NewVisitRollupStore creates a new instance of VisitRollupStore.
+func NewVisitRollupStore(
+	dbConn *gosql.DB, table string,
+) *VisitRollupStore {
+	return &VisitRollupStore{
+		db:      dbConn,
+		objects: db.NewObjectStore[VisitRollup]("id", table, dbConn),
+	}
+}
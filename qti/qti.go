@@ -0,0 +1,750 @@
+// Package qti implements reading and writing of IMS QTI 2.1 packages.
+//
+// Package is a zip archive with imsmanifest.xml and XML file for every
+// assessment item. Only interactions that correspond to problem kinds
+// of goquiz are supported.
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/udovin/goquiz/models"
+)
+
+const (
+	manifestFile   = "imsmanifest.xml"
+	itemNamespace  = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	cpNamespace    = "http://www.imsglobal.org/xsd/imscp_v1p1"
+	itemType       = "imsqti_item_xmlv2p1"
+	responseID     = "RESPONSE"
+	scoreID        = "SCORE"
+	matchCorrect   = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	mapResponse    = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/map_response"
+	absoluteMode   = "absolute"
+	maxPackageSize = 64 << 20
+)
+
+// Item represents assessment item read from package.
+type Item struct {
+	// Href contains path of item file in package.
+	Href string
+	// Problem contains problem built from item.
+	Problem models.Problem
+}
+
+// Error represents error of reading item from package.
+type Error struct {
+	// Href contains path of item file in package.
+	Href string
+	// Message contains error message.
+	Message string
+}
+
+// Error returns error message with item path.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Href, e.Message)
+}
+
+// Errors represents list of errors of reading package.
+type Errors []*Error
+
+// Error returns messages of all errors.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Write writes package with specified problems.
+func Write(w io.Writer, problems []models.Problem) error {
+	archive := zip.NewWriter(w)
+	manifest := manifest{
+		XMLNS:      cpNamespace,
+		Identifier: "MANIFEST",
+		Metadata: manifestMetadata{
+			Schema:        "QTIv2.1 Package",
+			SchemaVersion: "1.0.0",
+		},
+	}
+	for _, problem := range problems {
+		href := fmt.Sprintf("items/item-%d.xml", problem.ID)
+		item, err := makeAssessmentItem(problem)
+		if err != nil {
+			return fmt.Errorf("problem %d: %w", problem.ID, err)
+		}
+		if err := writeXML(archive, href, item); err != nil {
+			return err
+		}
+		manifest.Resources = append(manifest.Resources, resource{
+			Identifier: item.Identifier,
+			Type:       itemType,
+			Href:       href,
+			Files:      []file{{Href: href}},
+		})
+	}
+	if err := writeXML(archive, manifestFile, manifest); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// Read reads problems from package.
+//
+// If package contains unsupported items then Errors with all found
+// errors will be returned and no problems should be imported.
+func Read(r io.ReaderAt, size int64) ([]Item, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, file := range archive.File {
+		files[path.Clean(file.Name)] = file
+	}
+	manifestEntry, ok := files[manifestFile]
+	if !ok {
+		return nil, fmt.Errorf("package does not have %s", manifestFile)
+	}
+	var manifest manifest
+	if err := readXML(manifestEntry, &manifest); err != nil {
+		return nil, fmt.Errorf("unable to read manifest: %w", err)
+	}
+	var items []Item
+	var errs Errors
+	for _, resource := range manifest.Resources {
+		if !strings.HasPrefix(resource.Type, "imsqti_item_xmlv2p") {
+			continue
+		}
+		href := path.Clean(resource.Href)
+		file, ok := files[href]
+		if !ok {
+			errs = append(errs, &Error{Href: href, Message: "file not found"})
+			continue
+		}
+		var item assessmentItem
+		if err := readXML(file, &item); err != nil {
+			errs = append(errs, &Error{Href: href, Message: err.Error()})
+			continue
+		}
+		problem, err := item.Problem()
+		if err != nil {
+			errs = append(errs, &Error{Href: href, Message: err.Error()})
+			continue
+		}
+		items = append(items, Item{Href: href, Problem: problem})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return items, nil
+}
+
+func writeXML(archive *zip.Writer, name string, value any) error {
+	writer, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	return encoder.Encode(value)
+}
+
+func readXML(file *zip.File, value any) error {
+	if file.UncompressedSize64 > maxPackageSize {
+		return fmt.Errorf("file too large")
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	return xml.NewDecoder(reader).Decode(value)
+}
+
+type manifest struct {
+	XMLName    xml.Name         `xml:"manifest"`
+	XMLNS      string           `xml:"xmlns,attr,omitempty"`
+	Identifier string           `xml:"identifier,attr"`
+	Metadata   manifestMetadata `xml:"metadata"`
+	// Organizations are required by content packaging, but QTI
+	// packages usually do not use them.
+	Organizations struct{}   `xml:"organizations"`
+	Resources     []resource `xml:"resources>resource"`
+}
+
+type manifestMetadata struct {
+	Schema        string `xml:"schema"`
+	SchemaVersion string `xml:"schemaversion"`
+}
+
+type resource struct {
+	Identifier string `xml:"identifier,attr"`
+	Type       string `xml:"type,attr"`
+	Href       string `xml:"href,attr"`
+	Files      []file `xml:"file"`
+}
+
+type file struct {
+	Href string `xml:"href,attr"`
+}
+
+type assessmentItem struct {
+	XMLName              xml.Name              `xml:"assessmentItem"`
+	XMLNS                string                `xml:"xmlns,attr,omitempty"`
+	Identifier           string                `xml:"identifier,attr"`
+	Title                string                `xml:"title,attr"`
+	Adaptive             bool                  `xml:"adaptive,attr"`
+	TimeDependent        bool                  `xml:"timeDependent,attr"`
+	ResponseDeclarations []responseDeclaration `xml:"responseDeclaration"`
+	OutcomeDeclarations  []outcomeDeclaration  `xml:"outcomeDeclaration"`
+	ItemBody             itemBody              `xml:"itemBody"`
+	ResponseProcessing   *responseProcessing   `xml:"responseProcessing"`
+}
+
+type responseDeclaration struct {
+	Identifier      string   `xml:"identifier,attr"`
+	Cardinality     string   `xml:"cardinality,attr"`
+	BaseType        string   `xml:"baseType,attr"`
+	CorrectResponse *values  `xml:"correctResponse"`
+	Mapping         *mapping `xml:"mapping"`
+}
+
+type outcomeDeclaration struct {
+	Identifier  string `xml:"identifier,attr"`
+	Cardinality string `xml:"cardinality,attr"`
+	BaseType    string `xml:"baseType,attr"`
+}
+
+type values struct {
+	Values []string `xml:"value"`
+}
+
+type mapping struct {
+	DefaultValue float64    `xml:"defaultValue,attr"`
+	Entries      []mapEntry `xml:"mapEntry"`
+}
+
+type mapEntry struct {
+	MapKey        string  `xml:"mapKey,attr"`
+	MappedValue   float64 `xml:"mappedValue,attr"`
+	CaseSensitive bool    `xml:"caseSensitive,attr"`
+}
+
+type responseProcessing struct {
+	Template   string              `xml:"template,attr,omitempty"`
+	Conditions []responseCondition `xml:"responseCondition"`
+}
+
+type responseCondition struct {
+	If responseIf `xml:"responseIf"`
+}
+
+type responseIf struct {
+	Equal    *equal           `xml:"equal"`
+	SetValue *setOutcomeValue `xml:"setOutcomeValue"`
+}
+
+type equal struct {
+	ToleranceMode string      `xml:"toleranceMode,attr"`
+	Tolerance     string      `xml:"tolerance,attr,omitempty"`
+	Variable      *identifier `xml:"variable"`
+	Correct       *identifier `xml:"correct"`
+}
+
+type identifier struct {
+	Identifier string `xml:"identifier,attr"`
+}
+
+type setOutcomeValue struct {
+	Identifier string    `xml:"identifier,attr"`
+	BaseValue  baseValue `xml:"baseValue"`
+}
+
+type baseValue struct {
+	BaseType string `xml:"baseType,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type choiceInteraction struct {
+	ResponseIdentifier string         `xml:"responseIdentifier,attr"`
+	Shuffle            bool           `xml:"shuffle,attr"`
+	MaxChoices         int            `xml:"maxChoices,attr"`
+	Prompt             string         `xml:"prompt,omitempty"`
+	Choices            []simpleChoice `xml:"simpleChoice"`
+}
+
+type simpleChoice struct {
+	Identifier string `xml:"identifier,attr"`
+	MatchMax   int    `xml:"matchMax,attr,omitempty"`
+	Text       string `xml:",chardata"`
+}
+
+type matchInteraction struct {
+	ResponseIdentifier string     `xml:"responseIdentifier,attr"`
+	Shuffle            bool       `xml:"shuffle,attr"`
+	MaxAssociations    int        `xml:"maxAssociations,attr"`
+	Prompt             string     `xml:"prompt,omitempty"`
+	Sets               []matchSet `xml:"simpleMatchSet"`
+}
+
+type matchSet struct {
+	Choices []simpleChoice `xml:"simpleAssociableChoice"`
+}
+
+type textEntryInteraction struct {
+	ResponseIdentifier string `xml:"responseIdentifier,attr"`
+	ExpectedLength     int    `xml:"expectedLength,attr,omitempty"`
+}
+
+// itemBody represents body of assessment item.
+//
+// Text of body outside of interactions is used as problem statement.
+type itemBody struct {
+	Statement string
+	Choice    *choiceInteraction
+	Match     *matchInteraction
+	TextEntry *textEntryInteraction
+}
+
+// MarshalXML writes statement followed by interaction.
+func (b itemBody) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	div := xml.StartElement{Name: xml.Name{Local: "div"}}
+	if err := e.EncodeElement(b.Statement, div); err != nil {
+		return err
+	}
+	switch {
+	case b.Choice != nil:
+		name := xml.Name{Local: "choiceInteraction"}
+		if err := e.EncodeElement(b.Choice, xml.StartElement{Name: name}); err != nil {
+			return err
+		}
+	case b.Match != nil:
+		name := xml.Name{Local: "matchInteraction"}
+		if err := e.EncodeElement(b.Match, xml.StartElement{Name: name}); err != nil {
+			return err
+		}
+	case b.TextEntry != nil:
+		// Text entry is inline interaction, so it should be wrapped.
+		if err := e.EncodeToken(div); err != nil {
+			return err
+		}
+		name := xml.Name{Local: "textEntryInteraction"}
+		if err := e.EncodeElement(b.TextEntry, xml.StartElement{Name: name}); err != nil {
+			return err
+		}
+		if err := e.EncodeToken(div.End()); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// blockElements contains elements that separate paragraphs of statement.
+var blockElements = map[string]struct{}{
+	"p": {}, "div": {}, "br": {}, "li": {}, "pre": {}, "blockquote": {},
+}
+
+// UnmarshalXML reads statement and interaction.
+func (b *itemBody) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var paragraphs []string
+	var current strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(current.String()); len(text) > 0 {
+			paragraphs = append(paragraphs, text)
+		}
+		current.Reset()
+	}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "choiceInteraction":
+				b.Choice = &choiceInteraction{}
+				if err := d.DecodeElement(b.Choice, &t); err != nil {
+					return err
+				}
+			case "matchInteraction":
+				b.Match = &matchInteraction{}
+				if err := d.DecodeElement(b.Match, &t); err != nil {
+					return err
+				}
+			case "textEntryInteraction":
+				b.TextEntry = &textEntryInteraction{}
+				if err := d.DecodeElement(b.TextEntry, &t); err != nil {
+					return err
+				}
+			default:
+				if _, ok := blockElements[t.Name.Local]; ok {
+					flush()
+				}
+			}
+		case xml.EndElement:
+			if t.Name == start.Name {
+				flush()
+				b.Statement = strings.Join(paragraphs, "\n\n")
+				return nil
+			}
+			if _, ok := blockElements[t.Name.Local]; ok {
+				flush()
+			}
+		case xml.CharData:
+			current.Write(t)
+		}
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func parseFloat(text string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("invalid value: %q", text)
+	}
+	return value, nil
+}
+
+func makeChoices(prefix string, options []models.ProblemOption) []simpleChoice {
+	var choices []simpleChoice
+	for i, option := range options {
+		choices = append(choices, simpleChoice{
+			Identifier: fmt.Sprintf("%s%d", prefix, i),
+			Text:       option.Text,
+		})
+	}
+	return choices
+}
+
+func makeAssessmentItem(problem models.Problem) (assessmentItem, error) {
+	var config models.ProblemConfig
+	if err := problem.ScanConfig(&config); err != nil {
+		return assessmentItem{}, err
+	}
+	var answer models.ProblemAnswer
+	if err := problem.ScanAnswer(&answer); err != nil {
+		return assessmentItem{}, err
+	}
+	if len(config.Variables) > 0 || len(answer.Formula) > 0 {
+		return assessmentItem{}, fmt.Errorf(
+			"parameterized problems are not supported",
+		)
+	}
+	item := assessmentItem{
+		XMLNS:      itemNamespace,
+		Identifier: fmt.Sprintf("ITEM-%d", problem.ID),
+		Title:      problem.Title,
+		OutcomeDeclarations: []outcomeDeclaration{{
+			Identifier:  scoreID,
+			Cardinality: "single",
+			BaseType:    "float",
+		}},
+		ItemBody: itemBody{Statement: problem.Statement},
+	}
+	response := responseDeclaration{
+		Identifier:      responseID,
+		Cardinality:     "single",
+		BaseType:        "identifier",
+		CorrectResponse: &values{},
+	}
+	processing := responseProcessing{Template: matchCorrect}
+	switch problem.Kind {
+	case models.SingleChoiceProblem, models.MultipleChoiceProblem:
+		interaction := choiceInteraction{
+			ResponseIdentifier: responseID,
+			Shuffle:            true,
+			MaxChoices:         1,
+			Choices:            makeChoices("C", config.Options),
+		}
+		if problem.Kind == models.MultipleChoiceProblem {
+			response.Cardinality = "multiple"
+			interaction.MaxChoices = 0
+		}
+		for _, option := range answer.Options {
+			response.CorrectResponse.Values = append(
+				response.CorrectResponse.Values, fmt.Sprintf("C%d", option),
+			)
+		}
+		item.ItemBody.Choice = &interaction
+	case models.MatchingProblem:
+		prompts := makeChoices("P", config.Prompts)
+		options := makeChoices("O", config.Options)
+		for i := range prompts {
+			prompts[i].MatchMax = 1
+		}
+		for i := range options {
+			options[i].MatchMax = len(prompts)
+		}
+		response.Cardinality = "multiple"
+		response.BaseType = "directedPair"
+		for i, option := range answer.Options {
+			response.CorrectResponse.Values = append(
+				response.CorrectResponse.Values,
+				fmt.Sprintf("P%d O%d", i, option),
+			)
+		}
+		item.ItemBody.Match = &matchInteraction{
+			ResponseIdentifier: responseID,
+			Shuffle:            true,
+			MaxAssociations:    len(prompts),
+			Sets:               []matchSet{{Choices: prompts}, {Choices: options}},
+		}
+	case models.TextProblem:
+		response.BaseType = "string"
+		response.Mapping = &mapping{}
+		for _, text := range answer.Texts {
+			response.Mapping.Entries = append(response.Mapping.Entries, mapEntry{
+				MapKey:      text,
+				MappedValue: 1,
+			})
+		}
+		if len(answer.Texts) > 0 {
+			response.CorrectResponse.Values = []string{answer.Texts[0]}
+		}
+		processing.Template = mapResponse
+		item.ItemBody.TextEntry = &textEntryInteraction{
+			ResponseIdentifier: responseID,
+		}
+	case models.NumericProblem:
+		response.BaseType = "float"
+		response.CorrectResponse.Values = []string{formatFloat(answer.Value)}
+		tolerance := formatFloat(answer.Tolerance)
+		processing = responseProcessing{
+			Conditions: []responseCondition{{If: responseIf{
+				Equal: &equal{
+					ToleranceMode: absoluteMode,
+					Tolerance:     tolerance + " " + tolerance,
+					Variable:      &identifier{Identifier: responseID},
+					Correct:       &identifier{Identifier: responseID},
+				},
+				SetValue: &setOutcomeValue{
+					Identifier: scoreID,
+					BaseValue:  baseValue{BaseType: "float", Value: "1"},
+				},
+			}}},
+		}
+		item.ItemBody.TextEntry = &textEntryInteraction{
+			ResponseIdentifier: responseID,
+		}
+	default:
+		return assessmentItem{}, fmt.Errorf("unsupported kind: %q", problem.Kind)
+	}
+	item.ResponseDeclarations = []responseDeclaration{response}
+	item.ResponseProcessing = &processing
+	return item, nil
+}
+
+// getResponse returns response declaration with specified identifier.
+func (i assessmentItem) getResponse(id string) (responseDeclaration, error) {
+	for _, response := range i.ResponseDeclarations {
+		if response.Identifier == id {
+			return response, nil
+		}
+	}
+	return responseDeclaration{}, fmt.Errorf("response %q is not declared", id)
+}
+
+// getCorrectValues returns correct values of response.
+func (r responseDeclaration) getCorrectValues() []string {
+	if r.CorrectResponse == nil {
+		return nil
+	}
+	var result []string
+	for _, value := range r.CorrectResponse.Values {
+		result = append(result, strings.TrimSpace(value))
+	}
+	return result
+}
+
+// getTolerance returns absolute tolerance of numeric response.
+func (i assessmentItem) getTolerance() (float64, error) {
+	if i.ResponseProcessing == nil {
+		return 0, nil
+	}
+	for _, condition := range i.ResponseProcessing.Conditions {
+		equal := condition.If.Equal
+		if equal == nil || equal.ToleranceMode != absoluteMode {
+			continue
+		}
+		fields := strings.Fields(equal.Tolerance)
+		if len(fields) == 0 {
+			return 0, nil
+		}
+		return parseFloat(fields[0])
+	}
+	return 0, nil
+}
+
+// Problem builds problem from assessment item.
+func (i assessmentItem) Problem() (models.Problem, error) {
+	problem := models.Problem{
+		Title:     strings.TrimSpace(i.Title),
+		Statement: i.ItemBody.Statement,
+	}
+	var config models.ProblemConfig
+	var answer models.ProblemAnswer
+	switch body := i.ItemBody; {
+	case body.Choice != nil:
+		interaction := body.Choice
+		problem.Kind = models.SingleChoiceProblem
+		response, err := i.getResponse(interaction.ResponseIdentifier)
+		if err != nil {
+			return models.Problem{}, err
+		}
+		if response.Cardinality != "single" || interaction.MaxChoices != 1 {
+			problem.Kind = models.MultipleChoiceProblem
+		}
+		indexes := map[string]int{}
+		for index, choice := range interaction.Choices {
+			indexes[choice.Identifier] = index
+			config.Options = append(config.Options, models.ProblemOption{
+				Text: strings.TrimSpace(choice.Text),
+			})
+		}
+		for _, value := range response.getCorrectValues() {
+			index, ok := indexes[value]
+			if !ok {
+				return models.Problem{}, fmt.Errorf("choice %q does not exist", value)
+			}
+			answer.Options = append(answer.Options, index)
+		}
+		problem.Statement = joinStatement(problem.Statement, interaction.Prompt)
+	case body.Match != nil:
+		interaction := body.Match
+		problem.Kind = models.MatchingProblem
+		response, err := i.getResponse(interaction.ResponseIdentifier)
+		if err != nil {
+			return models.Problem{}, err
+		}
+		if len(interaction.Sets) != 2 {
+			return models.Problem{}, fmt.Errorf("match interaction should have two sets")
+		}
+		prompts := map[string]int{}
+		for index, choice := range interaction.Sets[0].Choices {
+			prompts[choice.Identifier] = index
+			config.Prompts = append(config.Prompts, models.ProblemOption{
+				Text: strings.TrimSpace(choice.Text),
+			})
+		}
+		options := map[string]int{}
+		for index, choice := range interaction.Sets[1].Choices {
+			options[choice.Identifier] = index
+			config.Options = append(config.Options, models.ProblemOption{
+				Text: strings.TrimSpace(choice.Text),
+			})
+		}
+		answer.Options = make([]int, len(config.Prompts))
+		matched := make([]bool, len(config.Prompts))
+		for _, value := range response.getCorrectValues() {
+			pair := strings.Fields(value)
+			if len(pair) != 2 {
+				return models.Problem{}, fmt.Errorf("invalid pair %q", value)
+			}
+			prompt, ok := prompts[pair[0]]
+			if !ok {
+				return models.Problem{}, fmt.Errorf("choice %q does not exist", pair[0])
+			}
+			option, ok := options[pair[1]]
+			if !ok {
+				return models.Problem{}, fmt.Errorf("choice %q does not exist", pair[1])
+			}
+			answer.Options[prompt], matched[prompt] = option, true
+		}
+		for index, ok := range matched {
+			if !ok {
+				return models.Problem{}, fmt.Errorf(
+					"choice %q is not matched",
+					interaction.Sets[0].Choices[index].Identifier,
+				)
+			}
+		}
+		problem.Statement = joinStatement(problem.Statement, interaction.Prompt)
+	case body.TextEntry != nil:
+		response, err := i.getResponse(body.TextEntry.ResponseIdentifier)
+		if err != nil {
+			return models.Problem{}, err
+		}
+		switch response.BaseType {
+		case "float", "integer":
+			problem.Kind = models.NumericProblem
+			values := response.getCorrectValues()
+			if len(values) != 1 {
+				return models.Problem{}, fmt.Errorf(
+					"numeric response should have exactly one correct value",
+				)
+			}
+			if answer.Value, err = parseFloat(values[0]); err != nil {
+				return models.Problem{}, err
+			}
+			if answer.Tolerance, err = i.getTolerance(); err != nil {
+				return models.Problem{}, err
+			}
+		case "string":
+			problem.Kind = models.TextProblem
+			if response.Mapping != nil {
+				for _, entry := range response.Mapping.Entries {
+					if entry.MappedValue > 0 {
+						answer.Texts = append(answer.Texts, entry.MapKey)
+					}
+				}
+			}
+			if len(answer.Texts) == 0 {
+				answer.Texts = response.getCorrectValues()
+			}
+		default:
+			return models.Problem{}, fmt.Errorf(
+				"unsupported base type: %q", response.BaseType,
+			)
+		}
+	default:
+		return models.Problem{}, fmt.Errorf("unsupported interaction")
+	}
+	if err := problem.SetConfig(config); err != nil {
+		return models.Problem{}, err
+	}
+	if err := problem.SetAnswer(answer); err != nil {
+		return models.Problem{}, err
+	}
+	return problem, nil
+}
+
+func joinStatement(statement, prompt string) string {
+	prompt = strings.TrimSpace(prompt)
+	if len(prompt) == 0 {
+		return statement
+	}
+	if len(statement) == 0 {
+		return prompt
+	}
+	return statement + "\n\n" + prompt
+}
+
+// ReadBytes reads problems from package stored in memory.
+func ReadBytes(data []byte) ([]Item, error) {
+	return Read(bytes.NewReader(data), int64(len(data)))
+}
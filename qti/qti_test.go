@@ -0,0 +1,254 @@
+package qti
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/udovin/goquiz/models"
+)
+
+func testProblem(
+	t testing.TB, id int64, kind models.ProblemKind, title, statement string,
+	config models.ProblemConfig, answer models.ProblemAnswer,
+) models.Problem {
+	problem := models.Problem{Kind: kind, Title: title, Statement: statement}
+	problem.ID = id
+	if err := problem.SetConfig(config); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := problem.SetAnswer(answer); err != nil {
+		t.Fatal("Error:", err)
+	}
+	return problem
+}
+
+func TestRoundTrip(t *testing.T) {
+	problems := []models.Problem{
+		testProblem(
+			t, 1, models.SingleChoiceProblem, "Capital",
+			"What is the capital of <b>France</b>?",
+			models.ProblemConfig{Options: []models.ProblemOption{
+				{Text: "Paris"}, {Text: "Berlin"}, {Text: "Rome & Milan"},
+			}},
+			models.ProblemAnswer{Options: []int{0}},
+		),
+		testProblem(
+			t, 2, models.MultipleChoiceProblem, "Primes",
+			"Which numbers are prime?\n\nSelect all.",
+			models.ProblemConfig{Options: []models.ProblemOption{
+				{Text: "2"}, {Text: "3"}, {Text: "4"},
+			}},
+			models.ProblemAnswer{Options: []int{0, 1}},
+		),
+		testProblem(
+			t, 3, models.TextProblem, "Author",
+			`Who wrote "War and Peace"?`,
+			models.ProblemConfig{},
+			models.ProblemAnswer{Texts: []string{"Tolstoy", "Leo Tolstoy"}},
+		),
+		testProblem(
+			t, 4, models.NumericProblem, "Pi", "Value of pi",
+			models.ProblemConfig{},
+			models.ProblemAnswer{Value: 3.14159, Tolerance: 0.01},
+		),
+		testProblem(
+			t, 5, models.MatchingProblem, "Countries",
+			"Match countries with capitals",
+			models.ProblemConfig{
+				Options: []models.ProblemOption{
+					{Text: "Paris"}, {Text: "Rome"}, {Text: "Berlin"},
+				},
+				Prompts: []models.ProblemOption{
+					{Text: "France"}, {Text: "Italy"},
+				},
+			},
+			models.ProblemAnswer{Options: []int{0, 1}},
+		),
+	}
+	var buffer bytes.Buffer
+	if err := Write(&buffer, problems); err != nil {
+		t.Fatal("Error:", err)
+	}
+	items, err := ReadBytes(buffer.Bytes())
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(items) != len(problems) {
+		t.Fatalf("Expected %d items, got %d", len(problems), len(items))
+	}
+	for i, item := range items {
+		checkEquivalent(t, problems[i], item.Problem)
+	}
+	parameterized := testProblem(
+		t, 6, models.NumericProblem, "Sum", "Find {{a}} + 1",
+		models.ProblemConfig{Variables: []models.ProblemVariable{
+			{Name: "a", Min: 1, Max: 9},
+		}},
+		models.ProblemAnswer{Formula: "a + 1"},
+	)
+	if err := Write(
+		&buffer, []models.Problem{parameterized},
+	); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func checkEquivalent(t testing.TB, expected, actual models.Problem) {
+	if expected.Kind != actual.Kind {
+		t.Fatalf("Expected kind %v, got %v", expected.Kind, actual.Kind)
+	}
+	if expected.Title != actual.Title {
+		t.Fatalf("Expected title %q, got %q", expected.Title, actual.Title)
+	}
+	if expected.Statement != actual.Statement {
+		t.Fatalf(
+			"Expected statement %q, got %q", expected.Statement, actual.Statement,
+		)
+	}
+	var expectedConfig, actualConfig models.ProblemConfig
+	if err := expected.ScanConfig(&expectedConfig); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := actual.ScanConfig(&actualConfig); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !reflect.DeepEqual(expectedConfig, actualConfig) {
+		t.Fatalf("Expected config %+v, got %+v", expectedConfig, actualConfig)
+	}
+	var expectedAnswer, actualAnswer models.ProblemAnswer
+	if err := expected.ScanAnswer(&expectedAnswer); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := actual.ScanAnswer(&actualAnswer); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if !reflect.DeepEqual(expectedAnswer, actualAnswer) {
+		t.Fatalf("Expected answer %+v, got %+v", expectedAnswer, actualAnswer)
+	}
+}
+
+const testManifest = `<?xml version="1.0" encoding="UTF-8"?>
+<manifest xmlns="http://www.imsglobal.org/xsd/imscp_v1p1" identifier="M">
+  <resources>
+    <resource identifier="I1" type="imsqti_item_xmlv2p1" href="choice.xml"/>
+    <resource identifier="I2" type="imsqti_item_xmlv2p1" href="order.xml"/>
+    <resource identifier="I3" type="imsqti_item_xmlv2p1" href="missing.xml"/>
+  </resources>
+</manifest>`
+
+const testChoiceItem = `<?xml version="1.0" encoding="UTF-8"?>
+<assessmentItem xmlns="http://www.imsglobal.org/xsd/imsqti_v2p1"
+    identifier="choice" title="Unattended Luggage">
+  <responseDeclaration identifier="RESPONSE" cardinality="single" baseType="identifier">
+    <correctResponse><value>ChoiceA</value></correctResponse>
+  </responseDeclaration>
+  <itemBody>
+    <p>Look at the text in the picture.</p>
+    <choiceInteraction responseIdentifier="RESPONSE" shuffle="false" maxChoices="1">
+      <prompt>What does it say?</prompt>
+      <simpleChoice identifier="ChoiceA">You must stay with your luggage at all times.</simpleChoice>
+      <simpleChoice identifier="ChoiceB">Do not let someone else look after your luggage.</simpleChoice>
+    </choiceInteraction>
+  </itemBody>
+</assessmentItem>`
+
+const testOrderItem = `<?xml version="1.0" encoding="UTF-8"?>
+<assessmentItem xmlns="http://www.imsglobal.org/xsd/imsqti_v2p1"
+    identifier="order" title="Order">
+  <itemBody>
+    <orderInteraction responseIdentifier="RESPONSE"/>
+  </itemBody>
+</assessmentItem>`
+
+func TestReadForeignPackage(t *testing.T) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for name, content := range map[string]string{
+		"imsmanifest.xml": testManifest,
+		"choice.xml":      testChoiceItem,
+		"order.xml":       testOrderItem,
+	} {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal("Error:", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	_, err := ReadBytes(buffer.Bytes())
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("Expected Errors, got %v", err)
+	}
+	if len(errs) != 2 || errs[0].Href != "order.xml" ||
+		errs[1].Href != "missing.xml" {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	expected := testProblem(
+		t, 0, models.SingleChoiceProblem, "Unattended Luggage",
+		"Look at the text in the picture.\n\nWhat does it say?",
+		models.ProblemConfig{Options: []models.ProblemOption{
+			{Text: "You must stay with your luggage at all times."},
+			{Text: "Do not let someone else look after your luggage."},
+		}},
+		models.ProblemAnswer{Options: []int{0}},
+	)
+	var item assessmentItem
+	if err := readTestItem(testChoiceItem, &item); err != nil {
+		t.Fatal("Error:", err)
+	}
+	problem, err := item.Problem()
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	checkEquivalent(t, expected, problem)
+}
+
+func readTestItem(content string, item *assessmentItem) error {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	writer, err := archive.Create("item.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return err
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	reader, err := zip.NewReader(
+		bytes.NewReader(buffer.Bytes()), int64(buffer.Len()),
+	)
+	if err != nil {
+		return err
+	}
+	return readXML(reader.File[0], item)
+}
+
+func TestReadInvalidPackage(t *testing.T) {
+	if _, err := ReadBytes([]byte("not a zip")); err == nil {
+		t.Fatal("Expected error")
+	}
+	var buffer bytes.Buffer
+	if err := Write(&buffer, nil); err != nil {
+		t.Fatal("Error:", err)
+	}
+	items, err := ReadBytes(buffer.Bytes())
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if len(items) != 0 {
+		t.Fatalf("Expected no items, got %d", len(items))
+	}
+	if !strings.Contains(buffer.String(), manifestFile) {
+		t.Fatal("Expected manifest in package")
+	}
+}
@@ -0,0 +1,169 @@
+// Package xlsx implements writing of simple Office Open XML workbooks.
+//
+// Workbook contains one worksheet that is written row by row in the
+// same manner as encoding/csv writes records. Values that look like
+// numbers are written as numeric cells and other values are written
+// as inline strings, so no shared string table is required.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+const (
+	contentTypesFile = "[Content_Types].xml"
+	relsFile         = "_rels/.rels"
+	workbookFile     = "xl/workbook.xml"
+	workbookRelsFile = "xl/_rels/workbook.xml.rels"
+	sheetFile        = "xl/worksheets/sheet1.xml"
+)
+
+const contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const rels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const workbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
+
+const sheetHeader = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const sheetFooter = `</sheetData></worksheet>`
+
+const relsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+type workbookSheet struct {
+	Name    string `xml:"name,attr"`
+	SheetID int    `xml:"sheetId,attr"`
+	RID     string `xml:"r:id,attr"`
+}
+
+type workbook struct {
+	XMLName xml.Name        `xml:"http://schemas.openxmlformats.org/spreadsheetml/2006/main workbook"`
+	R       string          `xml:"xmlns:r,attr"`
+	Sheets  []workbookSheet `xml:"sheets>sheet"`
+}
+
+// ContentType contains MIME type of workbook.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Writer writes records to worksheet of workbook.
+type Writer struct {
+	archive *zip.Writer
+	sheet   io.Writer
+	rows    int
+}
+
+// NewWriter creates a new workbook with worksheet with specified name.
+func NewWriter(w io.Writer, name string) (*Writer, error) {
+	archive := zip.NewWriter(w)
+	workbookData, err := xml.Marshal(workbook{
+		R:      relsNamespace,
+		Sheets: []workbookSheet{{Name: name, SheetID: 1, RID: "rId1"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := []struct {
+		Name string
+		Data string
+	}{
+		{contentTypesFile, contentTypes},
+		{relsFile, rels},
+		{workbookFile, xml.Header + string(workbookData)},
+		{workbookRelsFile, workbookRels},
+	}
+	for _, file := range files {
+		writer, err := archive.Create(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(writer, file.Data); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := archive.Create(sheetFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, sheetHeader); err != nil {
+		return nil, err
+	}
+	return &Writer{archive: archive, sheet: sheet}, nil
+}
+
+// Write writes record as next row of worksheet.
+func (w *Writer) Write(record []string) error {
+	w.rows++
+	if _, err := fmt.Fprintf(w.sheet, `<row r="%d">`, w.rows); err != nil {
+		return err
+	}
+	for i, value := range record {
+		ref := columnName(i) + strconv.Itoa(w.rows)
+		if isNumber(value) {
+			if _, err := fmt.Fprintf(
+				w.sheet, `<c r="%s"><v>%s</v></c>`, ref, value,
+			); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(
+			w.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref,
+		); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(w.sheet, []byte(value)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w.sheet, `</t></is></c>`); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w.sheet, `</row>`)
+	return err
+}
+
+// Close finishes worksheet and workbook.
+//
+// Close does not close underlying writer.
+func (w *Writer) Close() error {
+	if _, err := io.WriteString(w.sheet, sheetFooter); err != nil {
+		return err
+	}
+	return w.archive.Close()
+}
+
+// columnName returns name of column with specified index.
+func columnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
+
+// isNumber reports whether value should be written as numeric cell.
+func isNumber(value string) bool {
+	if len(value) == 0 {
+		return false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return false
+	}
+	// Values with leading zeros like "007" are kept as strings.
+	return strconv.FormatFloat(number, 'f', -1, 64) == value
+}
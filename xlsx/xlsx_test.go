@@ -0,0 +1,76 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := NewWriter(&buffer, "Results")
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := writer.Write([]string{"login", "score"}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := writer.Write([]string{"a<b", "1.5", "007"}); err != nil {
+		t.Fatal("Error:", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal("Error:", err)
+	}
+	archive, err := zip.NewReader(
+		bytes.NewReader(buffer.Bytes()), int64(buffer.Len()),
+	)
+	if err != nil {
+		t.Fatal("Error:", err)
+	}
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal("Error:", err)
+		}
+		files[file.Name] = string(data)
+	}
+	for _, name := range []string{
+		contentTypesFile, relsFile, workbookFile, workbookRelsFile, sheetFile,
+	} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("File %q is missing", name)
+		}
+	}
+	if !strings.Contains(
+		files[workbookFile], `<sheet name="Results" sheetId="1" r:id="rId1">`,
+	) {
+		t.Fatalf("Unexpected workbook: %s", files[workbookFile])
+	}
+	sheet := files[sheetFile]
+	for _, part := range []string{
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">a&lt;b</t></is></c>`,
+		`<c r="B2"><v>1.5</v></c>`,
+		`<c r="C2" t="inlineStr"><is><t xml:space="preserve">007</t></is></c>`,
+		`</sheetData></worksheet>`,
+	} {
+		if !strings.Contains(sheet, part) {
+			t.Fatalf("Sheet does not contain %q: %s", part, sheet)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, name := range tests {
+		if value := columnName(index); value != name {
+			t.Fatalf("Expected %q, got %q", name, value)
+		}
+	}
+}